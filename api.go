@@ -0,0 +1,610 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+const API_TOKEN_PATH = "MasterPlan/api_token"
+
+// The automation API lets scripts and editor plugins drive the running instance of MasterPlan through JSON-RPC 2.0 requests
+// POSTed to http://127.0.0.1:<port>/. It's opt-in (see the "Enable Automation API" option in the global settings), and only
+// listens on the loopback interface. As other programs on the computer (including web pages, through DNS rebinding) can reach
+// the loopback interface too, requests also have to be addressed to 127.0.0.1 or localhost, and carry the token MasterPlan
+// writes to the api_token file in its settings directory while the API is running, as "Authorization: Bearer <token>".
+// Notifications (requests without an ID) are carried out, but not replied to.
+//
+// Requests are handled by the main thread in Project.Update(), so they go through the same code paths the GUI does (Board.CreateNewTask(),
+// Task.ReceiveMessage(), and so on), meaning any changes made through the API can be undone like any other change.
+//
+// Methods:
+//
+//   boards.list                                   -> [Board info]
+//   board.tasks    {board}                        -> [Task info]
+//   task.get       {id}                           -> Task info
+//   task.create    {board, type, description, x, y, select} -> Task info
+//   task.update    {id, type, description, x, y, current, max} -> Task info
+//   task.complete  {id, completed}                -> Task info
+//   task.select    {ids, add}                     -> [Task info]
+//   task.focus     {id}                           -> Task info
+//
+// Boards can be specified by index, by name, or by ID (which stays the same when Boards are reordered); all parameters other than Task IDs are optional.
+
+const (
+	apiErrorParse          = -32700
+	apiErrorInvalidRequest = -32600
+	apiErrorMethodNotFound = -32601
+	apiErrorInvalidParams  = -32602
+	apiErrorInternal       = -32603
+)
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *apiError) Error() string {
+	return err.Message
+}
+
+func newAPIError(code int, text string, values ...interface{}) *apiError {
+	return &apiError{Code: code, Message: fmt.Sprintf(text, values...)}
+}
+
+type apiRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type apiResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *apiError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type apiCall struct {
+	Request *apiRequest
+	Reply   chan *apiResponse
+}
+
+type apiParams struct {
+	ID          *int
+	IDs         []int
+	Board       json.RawMessage
+	Type        *string
+	Description *string
+	X, Y        *float32
+	Current     *int
+	Max         *int
+	Completed   *bool
+	Select      *bool
+	Add         bool
+}
+
+type APIBoardInfo struct {
+	Index     int
+	ID        string
+	Name      string
+	Current   bool
+	Archived  bool
+	Parent    string
+	TaskCount int
+	Completed int
+	Total     int
+}
+
+type APITaskInfo struct {
+	ID          int
+	Board       int
+	Type        string
+	Description string
+	X, Y        float32
+	Selected    bool
+	Completable bool
+	Complete    bool
+	Current     int `json:",omitempty"`
+	Max         int `json:",omitempty"`
+}
+
+func newAPITaskInfo(task *Task) *APITaskInfo {
+
+	info := &APITaskInfo{
+		ID:          task.ID,
+		Board:       task.Board.Index(),
+		Type:        TaskTypeStr(task.TaskType.CurrentChoice),
+		Description: task.Description.Text(),
+		X:           task.Position.X,
+		Y:           task.Position.Y,
+		Selected:    task.Selected,
+		Completable: task.IsCompletable(),
+		Complete:    task.IsComplete(),
+	}
+
+	if task.Is(TASK_TYPE_PROGRESSION) {
+		info.Current = task.CompletionProgressionCurrent.Number()
+		info.Max = task.CompletionProgressionMax.Number()
+	}
+
+	return info
+
+}
+
+type AutomationAPI struct {
+	Port   int
+	Token  string
+	Server *http.Server
+	Calls  chan *apiCall
+}
+
+var automationAPI *AutomationAPI
+
+// UpdateAutomationAPI starts, stops, or restarts the automation API to match the current program settings.
+func UpdateAutomationAPI() {
+
+	if automationAPI != nil && (!programSettings.AutomationAPI || automationAPI.Port != programSettings.AutomationAPIPort) {
+		automationAPI.Stop()
+		automationAPI = nil
+	}
+
+	if automationAPI == nil && programSettings.AutomationAPI {
+
+		api, err := StartAutomationAPI(programSettings.AutomationAPIPort)
+
+		if err != nil {
+			currentProject.Log("ERROR: Could not start automation API: %s", err.Error())
+		} else {
+			automationAPI = api
+			currentProject.Log("Automation API listening on 127.0.0.1:%d.", api.Port)
+		}
+
+	}
+
+}
+
+func StartAutomationAPI(port int) (*AutomationAPI, error) {
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	tokenPath, err := xdg.ConfigFile(API_TOKEN_PATH)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token's only readable by the user running MasterPlan, as anyone who can read it can use the API.
+	if err := ioutil.WriteFile(tokenPath, []byte(hex.EncodeToString(token)), 0600); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		os.Remove(tokenPath)
+		return nil, err
+	}
+
+	api := &AutomationAPI{
+		Port:  port,
+		Token: hex.EncodeToString(token),
+		Calls: make(chan *apiCall, 64),
+	}
+
+	api.Server = &http.Server{Handler: http.HandlerFunc(api.ServeHTTP)}
+
+	go api.Server.Serve(listener)
+
+	return api, nil
+
+}
+
+func (api *AutomationAPI) Stop() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	api.Server.Shutdown(ctx)
+
+	if tokenPath, err := xdg.ConfigFile(API_TOKEN_PATH); err == nil {
+		os.Remove(tokenPath)
+	}
+
+}
+
+func (api *AutomationAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	// Requiring a JSON content type means browsers have to send a CORS preflight request first (which we never approve), so web
+	// pages can't use the API behind the user's back.
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(w, "The automation API only accepts JSON-RPC requests POSTed with a Content-Type of application/json.", http.StatusBadRequest)
+		return
+	}
+
+	// Web pages can point a domain of their own at 127.0.0.1 to get around the same-origin policy, but the browser still
+	// addresses their requests to that domain.
+	if r.Host != fmt.Sprintf("127.0.0.1:%d", api.Port) && r.Host != fmt.Sprintf("localhost:%d", api.Port) {
+		http.Error(w, "The automation API only accepts requests addressed to 127.0.0.1 or localhost.", http.StatusForbidden)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(api.Token)) != 1 {
+		http.Error(w, "The automation API requires the token from MasterPlan's api_token file, as \"Authorization: Bearer <token>\".", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	request := &apiRequest{}
+
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		json.NewEncoder(w).Encode(&apiResponse{JSONRPC: "2.0", Error: newAPIError(apiErrorParse, "parse error: %s", err.Error()), ID: json.RawMessage("null")})
+		return
+	}
+
+	call := &apiCall{Request: request, Reply: make(chan *apiResponse, 1)}
+
+	api.Calls <- call
+
+	// Notifications don't get a response, so there's no need to wait for them to be handled.
+	if request.ID == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	select {
+	case response := <-call.Reply:
+		json.NewEncoder(w).Encode(response)
+	case <-time.After(time.Second * 10):
+		json.NewEncoder(w).Encode(&apiResponse{JSONRPC: "2.0", Error: newAPIError(apiErrorInternal, "timed out waiting for MasterPlan to handle the request"), ID: request.ID})
+	}
+
+}
+
+// Update handles any pending API calls against the given Project; it should be called once per frame from the main thread.
+func (api *AutomationAPI) Update(project *Project) {
+
+	for len(api.Calls) > 0 {
+
+		call := <-api.Calls
+
+		response := &apiResponse{JSONRPC: "2.0", ID: call.Request.ID}
+
+		if response.ID == nil {
+			response.ID = json.RawMessage("null")
+		}
+
+		result, err := project.handleAPIRequest(call.Request)
+
+		if err != nil {
+			response.Error = err
+		} else {
+			response.Result = result
+		}
+
+		call.Reply <- response
+
+	}
+
+}
+
+func (project *Project) handleAPIRequest(request *apiRequest) (interface{}, *apiError) {
+
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		return nil, newAPIError(apiErrorInvalidRequest, "invalid JSON-RPC 2.0 request")
+	}
+
+	params := apiParams{}
+
+	if len(request.Params) > 0 {
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, newAPIError(apiErrorInvalidParams, "invalid params: %s", err.Error())
+		}
+	}
+
+	if project.Locked && strings.HasPrefix(request.Method, "task.") && request.Method != "task.get" {
+		return nil, newAPIError(apiErrorInvalidRequest, "the project is locked")
+	}
+
+	switch request.Method {
+
+	case "boards.list":
+
+		boards := []*APIBoardInfo{}
+
+		for i, board := range project.Boards {
+
+			info := &APIBoardInfo{Index: i, ID: board.ID, Name: board.Name, Current: i == project.BoardIndex, Archived: board.Archived}
+
+			if parent := project.ParentBoard(board); parent != nil {
+				info.Parent = parent.ID
+			}
+
+			for _, task := range board.Tasks {
+				if task.Serializable() {
+					info.TaskCount++
+				}
+			}
+
+			info.Completed, info.Total = board.CompletionTotals()
+
+			boards = append(boards, info)
+
+		}
+
+		return boards, nil
+
+	case "board.tasks":
+
+		board, err := project.apiBoard(params.Board)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks := []*APITaskInfo{}
+		for _, task := range board.Tasks {
+			if task.Serializable() {
+				tasks = append(tasks, newAPITaskInfo(task))
+			}
+		}
+
+		return tasks, nil
+
+	case "task.get":
+
+		task, err := project.apiTask(params.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return newAPITaskInfo(task), nil
+
+	case "task.create":
+
+		board, err := project.apiBoard(params.Board)
+		if err != nil {
+			return nil, err
+		}
+
+		taskType := project.PreviousTaskType
+
+		if params.Type != nil {
+			if taskType, err = apiTaskType(*params.Type); err != nil {
+				return nil, err
+			}
+		}
+
+		insertingIntoStack := len(board.SelectedTasks(true)) > 0
+
+		task := board.CreateNewTask()
+		task.TaskType.CurrentChoice = taskType
+		task.ReceiveMessage(MessageTaskRestore, nil)
+
+		if params.X == nil && params.Y == nil && !insertingIntoStack {
+			// Otherwise, the Task would be created wherever the mouse happens to be, which is probably not where a script wants it;
+			// the center of the view is a better guess. (The camera pan is a negative offset.)
+			x, y := -project.CameraPan.X, -project.CameraPan.Y
+			params.X = &x
+			params.Y = &y
+		}
+
+		project.applyAPIParams(task, params)
+
+		if params.Select != nil && !*params.Select {
+			task.ReceiveMessage(MessageSelect, map[string]interface{}{"task": task, "invert": true})
+		}
+
+		return newAPITaskInfo(task), nil
+
+	case "task.update":
+
+		task, err := project.apiTask(params.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.Type != nil {
+
+			taskType, err := apiTaskType(*params.Type)
+			if err != nil {
+				return nil, err
+			}
+
+			if taskType != task.TaskType.CurrentChoice {
+				task.TaskType.CurrentChoice = taskType
+				if task.Contents != nil {
+					task.Contents.Destroy()
+				}
+				task.SetContents()
+			}
+
+		}
+
+		project.applyAPIParams(task, params)
+
+		return newAPITaskInfo(task), nil
+
+	case "task.complete":
+
+		task, err := project.apiTask(params.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !task.IsCompletable() || task.Contents == nil {
+			return nil, newAPIError(apiErrorInvalidParams, "Task %d can't be completed", task.ID)
+		}
+
+		if params.Completed == nil || *params.Completed {
+			task.Contents.Trigger(TASK_TRIGGER_SET)
+		} else {
+			task.Contents.Trigger(TASK_TRIGGER_CLEAR)
+		}
+
+		task.UndoChange = true
+		for _, sub := range task.SubTasks {
+			sub.UndoChange = true
+		}
+
+		return newAPITaskInfo(task), nil
+
+	case "task.select":
+
+		ids := params.IDs
+		if params.ID != nil {
+			ids = append(ids, *params.ID)
+		}
+
+		tasks := []*Task{}
+
+		for _, id := range ids {
+			task, err := project.apiTask(&id)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+
+		if !params.Add {
+			project.SendMessage(MessageSelect, nil)
+		}
+
+		selected := []*APITaskInfo{}
+
+		for _, task := range tasks {
+			task.ReceiveMessage(MessageSelect, map[string]interface{}{"task": task})
+			selected = append(selected, newAPITaskInfo(task))
+		}
+
+		return selected, nil
+
+	case "task.focus":
+
+		task, err := project.apiTask(params.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		project.SwitchToBoard(task.Board)
+		project.SendMessage(MessageSelect, map[string]interface{}{"task": task})
+		project.CurrentBoard().FocusViewOnSelectedTasks()
+
+		return newAPITaskInfo(task), nil
+
+	}
+
+	return nil, newAPIError(apiErrorMethodNotFound, "method not found: %s", request.Method)
+
+}
+
+// applyAPIParams applies the changes common to creating and updating Tasks through the API.
+func (project *Project) applyAPIParams(task *Task, params apiParams) {
+
+	if params.Description != nil {
+		task.Description.SetText(*params.Description)
+	}
+
+	if params.Max != nil {
+		task.CompletionProgressionMax.SetNumber(*params.Max)
+	}
+
+	if params.Current != nil {
+		task.CompletionProgressionCurrent.SetNumber(*params.Current)
+	}
+
+	if params.X != nil || params.Y != nil {
+
+		pos := task.Position
+
+		if params.X != nil {
+			pos.X = *params.X
+		}
+
+		if params.Y != nil {
+			pos.Y = *params.Y
+		}
+
+		task.Position = project.RoundPositionToGrid(pos)
+		task.Rect.X = task.Position.X
+		task.Rect.Y = task.Position.Y
+		task.ReceiveMessage(MessageDropped, nil)
+
+	}
+
+	task.UndoChange = true
+	task.Board.TaskChanged = true
+
+}
+
+func (project *Project) apiBoard(boardParam json.RawMessage) (*Board, *apiError) {
+
+	if len(boardParam) == 0 || string(boardParam) == "null" {
+		return project.CurrentBoard(), nil
+	}
+
+	index := 0
+	if err := json.Unmarshal(boardParam, &index); err == nil {
+		if index < 0 || index >= len(project.Boards) {
+			return nil, newAPIError(apiErrorInvalidParams, "no Board with index %d", index)
+		}
+		return project.Boards[index], nil
+	}
+
+	name := ""
+	if err := json.Unmarshal(boardParam, &name); err == nil {
+		if board := project.BoardByID(name); board != nil {
+			return board, nil
+		}
+		for _, board := range project.Boards {
+			if strings.EqualFold(board.Name, name) {
+				return board, nil
+			}
+		}
+		return nil, newAPIError(apiErrorInvalidParams, "no Board named %s", name)
+	}
+
+	return nil, newAPIError(apiErrorInvalidParams, "board must be an index, a name, or an ID")
+
+}
+
+func (project *Project) apiTask(id *int) (*Task, *apiError) {
+
+	if id == nil {
+		return nil, newAPIError(apiErrorInvalidParams, "a Task id is required")
+	}
+
+	for _, board := range project.Boards {
+		if task := board.TaskByID(*id); task != nil && task.Valid {
+			return task, nil
+		}
+	}
+
+	return nil, newAPIError(apiErrorInvalidParams, "no Task with id %d", *id)
+
+}
+
+func apiTaskType(name string) (int, *apiError) {
+
+	for taskType := TASK_TYPE_BOOLEAN; taskType <= TASK_TYPE_BOARD; taskType++ {
+		if taskType != TASK_TYPE_LINE && strings.EqualFold(TaskTypeStr(taskType), name) {
+			return taskType, nil
+		}
+	}
+
+	return 0, newAPIError(apiErrorInvalidParams, "unknown or unsupported Task type: %s", name)
+
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAutomationAPIRejectsRequests(t *testing.T) {
+
+	api := &AutomationAPI{Port: 8766, Token: "secret", Calls: make(chan *apiCall, 4)}
+
+	request := func(host, token, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "http://"+host+"/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	notification := `{"jsonrpc":"2.0","method":"boards.list"}`
+
+	if code := request("rebound.example.com:8766", "secret", notification); code != http.StatusForbidden {
+		t.Errorf("expected a request addressed to another host to be forbidden, got %d", code)
+	}
+
+	if code := request("127.0.0.1:8766", "", notification); code != http.StatusUnauthorized {
+		t.Errorf("expected a request without the token to be unauthorized, got %d", code)
+	}
+
+	if code := request("localhost:8766", "wrong", notification); code != http.StatusUnauthorized {
+		t.Errorf("expected a request with the wrong token to be unauthorized, got %d", code)
+	}
+
+	if len(api.Calls) != 0 {
+		t.Fatalf("expected rejected requests not to be handled, but %d were queued", len(api.Calls))
+	}
+
+	// Notifications are handled, but not replied to.
+	if code := request("localhost:8766", "secret", notification); code != http.StatusNoContent {
+		t.Errorf("expected a notification to get no content, got %d", code)
+	}
+
+	if len(api.Calls) != 1 {
+		t.Fatalf("expected the notification to be queued to be handled, but %d calls were queued", len(api.Calls))
+	}
+
+}
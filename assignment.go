@@ -0,0 +1,264 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Completable Tasks can be given a priority, from P0 (most urgent) to P3, and assigned to one of the people listed in the
+// Project's team roster. This is meant as lightweight ownership for small teams, rather than full issue tracking; both are
+// optional, and are shown together as a small badge before the Task's text.
+
+const (
+	PRIORITY_NONE = iota
+	PRIORITY_P0
+	PRIORITY_P1
+	PRIORITY_P2
+	PRIORITY_P3
+)
+
+const assigneeNobody = "Nobody"
+
+var priorityNames = []string{"None", "P0", "P1", "P2", "P3"}
+
+var priorityColors = map[int]rl.Color{
+	PRIORITY_P0: {225, 80, 80, 255},
+	PRIORITY_P1: {235, 150, 70, 255},
+	PRIORITY_P2: {215, 195, 85, 255},
+	PRIORITY_P3: {140, 170, 200, 255},
+}
+
+// parsePriority returns the priority named (like "P1" or "p1"), or PRIORITY_NONE if it isn't one.
+func parsePriority(name string) int {
+	for i, priorityName := range priorityNames {
+		if strings.EqualFold(priorityName, name) {
+			return i
+		}
+	}
+	return PRIORITY_NONE
+}
+
+// Roster returns the names of the people Tasks in the Project can be assigned to.
+func (project *Project) Roster() []string {
+
+	roster := []string{}
+
+	for _, name := range strings.Split(project.TeamRoster.Text(), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			roster = append(roster, name)
+		}
+	}
+
+	return roster
+
+}
+
+// Priority returns the Task's priority; only completable Tasks have one.
+func (task *Task) Priority() int {
+	if !task.IsCompletable() {
+		return PRIORITY_NONE
+	}
+	return task.PriorityButtons.CurrentChoice
+}
+
+// Assignee returns the name of the person the Task is assigned to, or an empty string if it isn't assigned to anyone.
+func (task *Task) Assignee() string {
+	if !task.IsCompletable() || task.AssigneeSpinner.CurrentChoice == 0 {
+		return ""
+	}
+	return task.AssigneeSpinner.ChoiceAsString()
+}
+
+// SetAssignee assigns the Task to the person named; an empty name unassigns it.
+func (task *Task) SetAssignee(name string) {
+
+	task.SyncAssigneeOptions(name)
+
+	task.AssigneeSpinner.CurrentChoice = 0
+	if name != "" {
+		task.AssigneeSpinner.SetChoice(name)
+	}
+
+}
+
+// SyncAssigneeOptions updates the assignee choices to the Project's roster. The current assignee (or the extra name given)
+// is kept as a choice even if they've been taken off the roster, so they aren't unassigned without anyone noticing.
+func (task *Task) SyncAssigneeOptions(extra string) {
+
+	current := task.Assignee()
+
+	options := append([]string{assigneeNobody}, task.Board.Project.Roster()...)
+
+	for _, name := range []string{current, extra} {
+
+		found := name == ""
+		for _, option := range options {
+			if option == name {
+				found = true
+			}
+		}
+
+		if !found {
+			options = append(options, name)
+		}
+
+	}
+
+	task.AssigneeSpinner.Options = options
+	task.AssigneeSpinner.CurrentChoice = 0
+	if current != "" {
+		task.AssigneeSpinner.SetChoice(current)
+	}
+
+}
+
+// badgeText returns the text of a Task's badge, like "P1 Sam".
+func badgeText(priority int, assignee string) string {
+
+	parts := []string{}
+
+	if priority != PRIORITY_NONE {
+		parts = append(parts, priorityNames[priority])
+	}
+
+	if assignee != "" {
+		parts = append(parts, assignee)
+	}
+
+	return strings.Join(parts, " ")
+
+}
+
+// BadgeText returns the text of the Task's priority and assignee badge, or an empty string if it has neither.
+func (task *Task) BadgeText() string {
+	return badgeText(task.Priority(), task.Assignee())
+}
+
+// drawTaskBadge draws the Task's priority and assignee badge at the position given, returning how much horizontal space it
+// took up.
+func drawTaskBadge(task *Task, pos rl.Vector2) float32 {
+
+	text := task.BadgeText()
+
+	if text == "" {
+		return 0
+	}
+
+	scale := float32(0.75)
+	height := float32(task.Board.Project.GridSize) - 4
+	size, _ := TextSize(text, false)
+
+	badge := rl.Rectangle{pos.X, pos.Y + 2, size.X*scale + 6, height}
+
+	color, hasPriority := priorityColors[task.Priority()]
+	if !hasPriority {
+		color = getThemeColor(GUI_INSIDE_DISABLED)
+	}
+
+	rl.DrawRectangleRec(badge, color)
+	rl.DrawRectangleLinesEx(badge, 1, getThemeColor(GUI_OUTLINE))
+
+	textColor := rl.Black
+	if !hasPriority {
+		textColor = getThemeColor(GUI_FONT_COLOR)
+	}
+
+	DrawTextColoredScale(rl.Vector2{badge.X + 3, badge.Y + (height-size.Y*scale)/2}, textColor, text, scale)
+
+	return badge.Width + 4
+
+}
+
+// SortSelectedStacks sorts the Tasks in the stacks of the selected Tasks by priority, most urgent first. Sub-Tasks move
+// along with the Task they're under, and Tasks without a priority go to the bottom, keeping their order otherwise.
+func (board *Board) SortSelectedStacks() {
+
+	heads := map[*Task]bool{}
+
+	for _, task := range board.SelectedTasks(false) {
+		if task.StackHead != nil && task.IsCompletable() {
+			heads[task.StackHead] = true
+		}
+	}
+
+	sorted := 0
+
+	for head := range heads {
+
+		stack := append([]*Task{head}, head.RestOfStack...)
+
+		// Split the stack into blocks, each made up of a Task at the top level of the stack and the sub-Tasks underneath it
+		blocks := [][]*Task{}
+
+		for _, task := range stack {
+			if len(blocks) == 0 || task.Position.X <= head.Position.X {
+				blocks = append(blocks, []*Task{task})
+			} else {
+				blocks[len(blocks)-1] = append(blocks[len(blocks)-1], task)
+			}
+		}
+
+		// The stack's head is often a heading (a Note, for example); if so, it stays where it is
+		start := 0
+		if !head.IsCompletable() {
+			start = 1
+		}
+
+		if len(blocks)-start < 2 {
+			continue
+		}
+
+		toSort := blocks[start:]
+
+		top := toSort[0][0].Position.Y
+
+		heights := map[*Task]float32{}
+		for i, block := range toSort {
+			if i < len(toSort)-1 {
+				heights[block[0]] = toSort[i+1][0].Position.Y - block[0].Position.Y
+			} else {
+				last := block[len(block)-1]
+				heights[block[0]] = last.Position.Y + last.DisplaySize.Y - block[0].Position.Y
+			}
+		}
+
+		rank := func(task *Task) int {
+			if priority := task.Priority(); priority != PRIORITY_NONE {
+				return priority
+			}
+			return len(priorityNames)
+		}
+
+		sort.SliceStable(toSort, func(i, j int) bool { return rank(toSort[i][0]) < rank(toSort[j][0]) })
+
+		y := top
+
+		for _, block := range toSort {
+
+			dy := y - block[0].Position.Y
+
+			for _, task := range block {
+				if dy != 0 {
+					task.Position.Y += dy
+					task.UndoChange = true
+				}
+			}
+
+			y += heights[block[0]]
+
+		}
+
+		sorted++
+
+	}
+
+	if sorted > 0 {
+		board.TaskChanged = true
+		board.Project.Log("Sorted %d stack(s) by priority.", sorted)
+	} else {
+		board.Project.Log("Select a Task in a stack of completable Tasks to sort it by priority.")
+	}
+
+}
@@ -24,18 +24,23 @@ type Board struct {
 	ToBeDeleted   []*Task
 	ToBeRestored  []*Task
 	Project       *Project
+	ID            string
 	Name          string
+	Archived      bool
 	TaskLocations map[Position][]*Task
 	UndoHistory   *UndoHistory
 	TaskChanged   bool
+	Bookmarks     []*CameraBookmark
 }
 
 func NewBoard(project *Project) *Board {
 	board := &Board{
 		Tasks:         []*Task{},
 		Project:       project,
+		ID:            newSyncID(),
 		Name:          fmt.Sprintf("Board %d", len(project.Boards)+1),
 		TaskLocations: map[Position][]*Task{},
+		Bookmarks:     []*CameraBookmark{},
 	}
 
 	board.UndoHistory = NewUndoHistory(board)
@@ -177,6 +182,7 @@ func (board *Board) DeleteTask(task *Task) {
 	if task.Valid {
 
 		task.Valid = false
+		task.invalidateBoardLink()
 		board.ToBeDeleted = append(board.ToBeDeleted, task)
 		task.ReceiveMessage(MessageDelete, map[string]interface{}{"task": task})
 
@@ -189,6 +195,7 @@ func (board *Board) RestoreTask(task *Task) {
 	if !task.Valid {
 
 		task.Valid = true
+		task.invalidateBoardLink()
 		board.ToBeRestored = append(board.ToBeRestored, task)
 		task.ReceiveMessage(MessageDropped, map[string]interface{}{"task": task})
 
@@ -303,10 +310,21 @@ func (board *Board) HandleDroppedFiles() {
 
 					if guess == TASK_TYPE_IMAGE {
 
-						task.FilePathTextbox.SetText(droppedPath)
+						task.FilePathTextbox.SetText(board.Project.StoreMedia(droppedPath))
 						task.SetContents()
 						task.Contents.(*ImageContents).ResetSize = true
 
+					} else if guess == TASK_TYPE_MEDIA {
+
+						task.FilePathTextbox.SetText(board.Project.StoreMedia(droppedPath))
+						task.SetContents()
+						task.Contents.(*MediaContents).ResetSize = true
+
+					} else if guess == TASK_TYPE_DOCUMENT {
+
+						task.FilePathTextbox.SetText(board.Project.StoreMedia(droppedPath))
+						task.SetContents()
+
 					} else {
 
 						text, err := ioutil.ReadFile(droppedPath)
@@ -366,6 +384,10 @@ func (board *Board) CopySelectedTasks() {
 
 		text := task.Description.Text()
 
+		if badge := task.BadgeText(); badge != "" {
+			text = "(" + badge + ") " + text
+		}
+
 		if task.PrefixText != "" {
 			text = task.PrefixText + " " + text
 		}
@@ -401,6 +423,24 @@ func (board *Board) CopySelectedTasks() {
 			icon = "IMAGE : "
 			text = `"` + task.FilePathTextbox.Text() + `"`
 
+		case TASK_TYPE_MEDIA:
+
+			icon = "MEDIA : "
+			text = `"` + task.FilePathTextbox.Text() + `"`
+
+		case TASK_TYPE_DOCUMENT:
+
+			icon = "DOCUMENT : "
+			text = `"` + task.FilePathTextbox.Text() + `"`
+
+		case TASK_TYPE_BOARD:
+
+			icon = "BOARD : "
+			if linked := task.LinkedBoard(); linked != nil {
+				completed, total := linked.CompletionTotals()
+				text = linked.Name + " [" + strconv.Itoa(completed) + "/" + strconv.Itoa(total) + "]"
+			}
+
 		case TASK_TYPE_TIMER:
 
 			if task.Contents != nil {
@@ -740,6 +780,12 @@ func (board *Board) PasteContent() {
 
 	clipboardData, _ := clipboard.ReadAll() // Tanks FPS if done every frame because of course it does
 
+	// Reading an image from the clipboard means starting another program (see ReadClipboardImage()), so images are only
+	// looked for if there's no text to paste.
+	if clipboardData == "" && board.PasteImage() {
+		return
+	}
+
 	if clipboardData != "" {
 
 		clipboardData = strings.ReplaceAll(clipboardData, "\r\n", "\n")
@@ -826,10 +872,19 @@ func (board *Board) PasteContent() {
 			task.TaskType.CurrentChoice = guess
 
 			if guess == TASK_TYPE_IMAGE {
-				task.FilePathTextbox.SetText(clipboardData)
+				task.FilePathTextbox.SetText(board.Project.StoreMedia(clipboardData))
 				task.SetContents()
 				task.Contents.(*ImageContents).ResetSize = true
 
+			} else if guess == TASK_TYPE_MEDIA {
+				task.FilePathTextbox.SetText(board.Project.StoreMedia(clipboardData))
+				task.SetContents()
+				task.Contents.(*MediaContents).ResetSize = true
+
+			} else if guess == TASK_TYPE_DOCUMENT {
+				task.FilePathTextbox.SetText(board.Project.StoreMedia(clipboardData))
+				task.SetContents()
+
 			} else {
 				task.Description.SetText(clipboardData)
 			}
@@ -855,6 +910,11 @@ func (board *Board) GuessTaskTypeFromText(filepath string) int {
 
 		if res.MimeIsImage() {
 			return TASK_TYPE_IMAGE
+		} else if res.MimeIsMedia() {
+			return TASK_TYPE_MEDIA
+		} else if res.MimeData.Is("application/pdf") {
+			// Text files are still turned into Notes, as that's usually what's wanted when dropping or pasting them
+			return TASK_TYPE_DOCUMENT
 		}
 
 	}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Board link Tasks lead to another Board; double-clicking one goes to that Board, and its face shows how much of that
+// Board is complete. A Board that a Board link leads to is nested inside the Board the link is on, rather than being
+// listed in the Boards panel on its own (deleting the link lists it again), so Boards can be organized into a hierarchy as
+// deep as needed. The Boards above the current one are shown as a trail of breadcrumbs in the status bar, and can be gone
+// back up to from there.
+
+// LinkedBoard returns the Board the Board link Task leads to, or nil if it doesn't lead anywhere.
+func (task *Task) LinkedBoard() *Board {
+
+	if !task.Is(TASK_TYPE_BOARD) {
+		return nil
+	}
+
+	return task.Board.Project.BoardByID(task.LinkedBoardID)
+
+}
+
+// SyncBoardLinkOptions updates the Board link Task's choice of Boards to link to. Boards above the Task's Board are left
+// out, as a link back up to one of them would loop.
+func (task *Task) SyncBoardLinkOptions() {
+
+	above := map[*Board]bool{}
+	for _, board := range task.Board.Project.BoardTrail(task.Board) {
+		above[board] = true
+	}
+
+	options := []string{"None"}
+	task.linkOptions = []*Board{nil}
+	choice := 0
+
+	for _, board := range task.Board.Project.Boards {
+
+		if above[board] {
+			continue
+		}
+
+		if board.ID == task.LinkedBoardID {
+			choice = len(options)
+		}
+
+		options = append(options, board.Name)
+		task.linkOptions = append(task.linkOptions, board)
+
+	}
+
+	task.BoardSpinner.Options = options
+	task.BoardSpinner.CurrentChoice = choice
+
+}
+
+// LinkBoard makes the Board link Task lead to the Board given; nil unlinks it.
+func (task *Task) LinkBoard(board *Board) {
+
+	if board == nil {
+		task.LinkedBoardID = ""
+	} else {
+		task.LinkedBoardID = board.ID
+	}
+
+	task.Board.Project.InvalidateBoardParents()
+	task.UndoChange = true
+
+}
+
+// CreateLinkedBoard adds a new Board and links the Board link Task to it.
+func (task *Task) CreateLinkedBoard() {
+
+	project := task.Board.Project
+
+	project.AddBoard()
+
+	board := project.Boards[len(project.Boards)-1]
+	board.Name = project.uniqueBoardName(task.Board.Name + " Sub-Board")
+
+	task.LinkBoard(board)
+	task.SyncBoardLinkOptions()
+
+	project.Modified = true
+	project.Log("Created Board %s inside of Board %s.", board.Name, task.Board.Name)
+
+}
+
+// InvalidateBoardParents has which Boards are nested inside of which worked out again the next time it's needed. It's
+// called whenever a Board link (or the set of Boards) might have changed.
+func (project *Project) InvalidateBoardParents() {
+	project.boardParentCache = nil
+}
+
+// invalidateBoardLink invalidates the Project's Board parents if the Task is (or was) a Board link.
+func (task *Task) invalidateBoardLink() {
+	if task.Is(TASK_TYPE_BOARD) || task.LinkedBoardID != "" {
+		task.Board.Project.InvalidateBoardParents()
+	}
+}
+
+// boardParents returns the Board each nested Board is inside of. If more than one Board link leads to a Board, it's
+// inside the Board with the first of them. Boards that link to each other in a loop can't be gone back up from to a
+// top-level Board, so they're all treated as top-level. As the Boards panel and status bar need this every frame, it's
+// kept until it's invalidated.
+func (project *Project) boardParents() map[*Board]*Board {
+
+	if project.boardParentCache != nil {
+		return project.boardParentCache
+	}
+
+	byID := map[string]*Board{}
+	for _, board := range project.Boards {
+		byID[board.ID] = board
+	}
+
+	parents := map[*Board]*Board{}
+
+	for _, board := range project.Boards {
+
+		for _, task := range board.Tasks {
+
+			if !task.Valid || !task.Is(TASK_TYPE_BOARD) {
+				continue
+			}
+
+			if child, exists := byID[task.LinkedBoardID]; exists && child != board && parents[child] == nil {
+				parents[child] = board
+			}
+
+		}
+
+	}
+
+	looped := []*Board{}
+
+	for board := range parents {
+		for parent, steps := parents[board], 0; parent != nil && steps < len(parents); parent, steps = parents[parent], steps+1 {
+			if parent == board {
+				looped = append(looped, board)
+				break
+			}
+		}
+	}
+
+	for _, board := range looped {
+		delete(parents, board)
+	}
+
+	project.boardParentCache = parents
+
+	return parents
+
+}
+
+// ParentBoard returns the Board the Board given is nested inside of, or nil if it's a top-level Board.
+func (project *Project) ParentBoard(board *Board) *Board {
+	return project.boardParents()[board]
+}
+
+// BoardTrail returns the Boards from the top-level Board down to the Board given, inclusive.
+func (project *Project) BoardTrail(board *Board) []*Board {
+
+	parents := project.boardParents()
+
+	trail := []*Board{board}
+
+	for parent := parents[board]; parent != nil; parent = parents[parent] {
+		trail = append([]*Board{parent}, trail...)
+	}
+
+	return trail
+
+}
+
+// BoardPath returns the names of the Boards from the top-level Board down to the Board given, like "Game > Levels > Desert".
+func (project *Project) BoardPath(board *Board) string {
+
+	names := []string{}
+	for _, b := range project.BoardTrail(board) {
+		names = append(names, b.Name)
+	}
+
+	return strings.Join(names, " > ")
+
+}
+
+// NestedBoards returns the Boards that are nested inside of other Boards and aren't archived, in order.
+func (project *Project) NestedBoards() []*Board {
+
+	parents := project.boardParents()
+	nested := []*Board{}
+
+	for _, board := range project.Boards {
+		if !board.Archived && parents[board] != nil {
+			nested = append(nested, board)
+		}
+	}
+
+	return nested
+
+}
+
+// IsBoardLinked returns if a Board link Task on another Board leads to the Board given.
+func (project *Project) IsBoardLinked(board *Board) bool {
+
+	for _, other := range project.Boards {
+
+		if other == board {
+			continue
+		}
+
+		for _, task := range other.Tasks {
+			if task.Valid && task.Is(TASK_TYPE_BOARD) && task.LinkedBoardID == board.ID {
+				return true
+			}
+		}
+
+	}
+
+	return false
+
+}
+
+// GoToParentBoard goes up from the current Board to the Board it's nested inside of.
+func (project *Project) GoToParentBoard() {
+
+	if parent := project.ParentBoard(project.CurrentBoard()); parent != nil {
+		project.SwitchToBoard(parent)
+	} else {
+		project.Log("This Board isn't inside of another Board.")
+	}
+
+}
+
+// CompletionTotals returns how many of the completable things on the Board itself are complete, and how many there are, as
+// the status bar, dashboard, API, and exports count them.
+func (board *Board) CompletionTotals() (int, int) {
+
+	completed, total := 0, 0
+
+	for _, t := range board.Tasks {
+		c, m := countCompletion(t)
+		completed += c
+		total += m
+	}
+
+	return completed, total
+
+}
+
+// NestedCompletionTotals returns the Board's completion totals along with those of the Boards its Board links lead to (and
+// so on), as shown on the face of a Board link leading to it.
+func (board *Board) NestedCompletionTotals() (int, int) {
+	return board.nestedCompletionTotals(map[*Board]bool{})
+}
+
+func (board *Board) nestedCompletionTotals(counted map[*Board]bool) (int, int) {
+
+	completed, total := 0, 0
+
+	counted[board] = true
+
+	for _, t := range board.Tasks {
+
+		if t.Is(TASK_TYPE_BOARD) {
+
+			// Boards are only counted once, however many links lead to them
+			if linked := t.LinkedBoard(); linked != nil && !counted[linked] {
+				c, m := linked.nestedCompletionTotals(counted)
+				completed += c
+				total += m
+			}
+
+		} else {
+			c, m := countCompletion(t)
+			completed += c
+			total += m
+		}
+
+	}
+
+	return completed, total
+
+}
+
+// DrawBoardTrail draws the breadcrumbs leading down to the current Board in the status bar, starting at the x position
+// given, if the current Board is nested inside of another one. Clicking on a Board in the trail goes back up to it.
+func (project *Project) DrawBoardTrail(x float32) {
+
+	trail := project.BoardTrail(project.CurrentBoard())
+
+	if len(trail) < 2 {
+		return
+	}
+
+	bar := project.StatusBar
+
+	for _, board := range trail[:len(trail)-1] {
+
+		size, _ := TextSize(board.Name, true)
+		rect := rl.Rectangle{x, bar.Y, size.X + 16, bar.Height}
+
+		if ImmediateButton(rect, board.Name, false) {
+			project.SwitchToBoard(board)
+		}
+
+		x += rect.Width + 4
+
+		DrawGUIText(rl.Vector2{x, bar.Y - 2}, ">")
+
+		separator, _ := TextSize(">", true)
+		x += separator.X + 4
+
+	}
+
+	DrawGUIText(rl.Vector2{x, bar.Y - 2}, project.CurrentBoard().Name)
+
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestBoardParents(t *testing.T) {
+
+	project := newTestProject()
+
+	boards := map[string]*Board{}
+	for _, name := range []string{"Game", "Levels", "Desert", "Art", "Sound", "Music"} {
+		board := NewBoard(project)
+		board.Name = name
+		boards[name] = board
+		project.Boards = append(project.Boards, board)
+	}
+
+	link := func(from, to string) *Task {
+		task := newTestTask(boards[from], TASK_TYPE_BOARD, "", 0, float32(len(boards[from].Tasks))*2, 4, 1)
+		task.LinkBoard(boards[to])
+		return task
+	}
+
+	link("Game", "Levels")
+	link("Levels", "Desert")
+	// A Board linking to itself isn't nested inside of itself.
+	link("Desert", "Desert")
+	// Art and Sound link to each other in a loop, so neither can be nested, but Music is still nested inside of Sound.
+	link("Art", "Sound")
+	link("Sound", "Art")
+	link("Sound", "Music")
+	// Desert is already inside of Levels, which comes before Music.
+	musicLink := link("Music", "Desert")
+
+	trails := map[string]string{
+		"Game":   "Game",
+		"Levels": "Game > Levels",
+		"Desert": "Game > Levels > Desert",
+		"Art":    "Art",
+		"Sound":  "Sound",
+		"Music":  "Sound > Music",
+	}
+
+	for name, path := range trails {
+		if p := project.BoardPath(boards[name]); p != path {
+			t.Errorf("expected %s to be at %s, got %s", name, path, p)
+		}
+	}
+
+	if nested := project.NestedBoards(); len(nested) != 3 || nested[0] != boards["Levels"] || nested[1] != boards["Desert"] || nested[2] != boards["Music"] {
+		t.Errorf("expected Levels, Desert, and Music to be nested, got %v", nested)
+	}
+
+	// Changing links changes where Boards are nested.
+	musicLink.LinkBoard(boards["Game"])
+
+	if p := project.BoardPath(boards["Desert"]); p != "Sound > Music > Game > Levels > Desert" {
+		t.Errorf("expected Game to be nested inside of Music once it was linked to, got %s", p)
+	}
+
+	// Deleting Sound's link to Art breaks the loop, so Sound is nested inside of Art.
+	boards["Sound"].DeleteTask(boards["Sound"].Tasks[0])
+
+	if p := project.BoardPath(boards["Music"]); p != "Art > Sound > Music" {
+		t.Errorf("expected Sound to be nested inside of Art once the loop was broken, got %s", p)
+	}
+
+	// Desert is inside of Levels until Art is moved before it.
+	link("Art", "Desert")
+
+	if p := project.BoardPath(boards["Desert"]); p != "Art > Sound > Music > Game > Levels > Desert" {
+		t.Errorf("expected Desert to stay inside of Levels, got %s", p)
+	}
+
+	project.MoveBoard(boards["Art"], 0)
+
+	if p := project.BoardPath(boards["Desert"]); p != "Art > Desert" {
+		t.Errorf("expected Desert to be nested inside of Art once it came first, got %s", p)
+	}
+
+}
+
+func TestNestedCompletionTotals(t *testing.T) {
+
+	project := newTestProject()
+
+	game := NewBoard(project)
+	levels := NewBoard(project)
+	project.Boards = []*Board{game, levels}
+
+	newTestTask(game, TASK_TYPE_BOOLEAN, "Title screen", 0, 0, 4, 1).CompletionCheckbox.Checked = true
+	newTestTask(game, TASK_TYPE_BOARD, "", 0, 2, 4, 1).LinkBoard(levels)
+
+	newTestTask(levels, TASK_TYPE_BOOLEAN, "Desert", 0, 0, 4, 1)
+	newTestTask(levels, TASK_TYPE_BOOLEAN, "Castle", 0, 2, 4, 1).CompletionCheckbox.Checked = true
+	// The Boards link to each other, but each one is only counted once.
+	newTestTask(levels, TASK_TYPE_BOARD, "", 0, 4, 4, 1).LinkBoard(game)
+
+	if completed, total := game.CompletionTotals(); completed != 1 || total != 1 {
+		t.Errorf("expected the Board itself to have 1 / 1 complete, got %d / %d", completed, total)
+	}
+
+	if completed, total := game.NestedCompletionTotals(); completed != 2 || total != 3 {
+		t.Errorf("expected the Board and the one it links to to have 2 / 3 complete, got %d / %d", completed, total)
+	}
+
+}
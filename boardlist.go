@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Boards can be reordered (by dragging them in the Boards panel, or with the arrows beside the current Board), duplicated
+// along with all of their Tasks, and archived. Archived Boards are tucked away under their own heading in the Boards panel
+// and skipped by the Board shortcuts, but their Tasks can still be searched for.
+//
+// Each Board has an ID that stays the same however the Boards are ordered; Tasks save the ID of their Board along with its
+// index, and Tasks shared over a LAN session find their Board by ID, so reordering Boards doesn't move Tasks between them.
+
+// BoardByID returns the Board with the ID given, or nil if there isn't one.
+func (project *Project) BoardByID(id string) *Board {
+
+	if id == "" {
+		return nil
+	}
+
+	for _, board := range project.Boards {
+		if board.ID == id {
+			return board
+		}
+	}
+
+	return nil
+
+}
+
+// ListedBoards returns the top-level Boards that aren't archived, in order; Boards nested inside of others aren't listed.
+func (project *Project) ListedBoards() []*Board {
+
+	parents := project.boardParents()
+	listed := []*Board{}
+
+	for _, board := range project.Boards {
+		if !board.Archived && parents[board] == nil {
+			listed = append(listed, board)
+		}
+	}
+
+	return listed
+
+}
+
+// ArchivedBoards returns the Boards that are archived, in order.
+func (project *Project) ArchivedBoards() []*Board {
+
+	archived := []*Board{}
+
+	for _, board := range project.Boards {
+		if board.Archived {
+			archived = append(archived, board)
+		}
+	}
+
+	return archived
+
+}
+
+// SwitchToBoard makes the Board given the current one.
+func (project *Project) SwitchToBoard(board *Board) {
+
+	if index := board.Index(); index >= 0 {
+		project.BoardIndex = index
+		project.Log("Switched to Board: %s.", board.Name)
+	}
+
+}
+
+// SwitchToListedBoard switches to the Board at the index given among the Boards that aren't archived, as listed in the
+// Boards panel.
+func (project *Project) SwitchToListedBoard(index int) {
+
+	if listed := project.ListedBoards(); index < len(listed) {
+		project.SwitchToBoard(listed[index])
+	}
+
+}
+
+// MoveBoard moves the Board given to the index given in the Project's list of Boards, keeping the same Board current.
+func (project *Project) MoveBoard(board *Board, index int) {
+
+	from := board.Index()
+
+	if from < 0 || index < 0 || index >= len(project.Boards) || index == from {
+		return
+	}
+
+	current := project.CurrentBoard()
+
+	project.Boards = append(project.Boards[:from], project.Boards[from+1:]...)
+	project.Boards = append(project.Boards[:index], append([]*Board{board}, project.Boards[index:]...)...)
+
+	// A Board led to by more than one link is inside the Board with the first of them, so the order matters
+	project.InvalidateBoardParents()
+
+	project.BoardIndex = current.Index()
+	project.Modified = true
+
+}
+
+// MoveBoardInList moves the Board given up (for a negative step) or down past the Boards beside it in the Boards panel;
+// archived Boards aren't listed with the others, so they're skipped over.
+func (project *Project) MoveBoardInList(board *Board, step int) {
+
+	listed := project.ListedBoards()
+
+	if board.Archived {
+		listed = project.ArchivedBoards()
+	}
+
+	for i, other := range listed {
+
+		if other == board {
+
+			if target := i + step; target >= 0 && target < len(listed) {
+				project.MoveBoard(board, listed[target].Index())
+				if step < 0 {
+					project.Log("Moved Board %s up.", board.Name)
+				} else {
+					project.Log("Moved Board %s down.", board.Name)
+				}
+			}
+
+			return
+
+		}
+
+	}
+
+}
+
+// DuplicateBoard adds a copy of the Board given (with copies of all of its Tasks and its camera bookmarks) right after it,
+// and switches to it.
+func (project *Project) DuplicateBoard(board *Board) *Board {
+
+	if len(board.Tasks) == 0 {
+		project.Log("Can't duplicate an empty Board.")
+		return nil
+	}
+
+	duplicate := NewBoard(project)
+	duplicate.Name = project.uniqueBoardName(board.Name + " Copy")
+	duplicate.Archived = board.Archived
+
+	for _, bookmark := range board.Bookmarks {
+		copied := *bookmark
+		duplicate.Bookmarks = append(duplicate.Bookmarks, &copied)
+	}
+
+	project.Boards = append(project.Boards, duplicate)
+	project.MoveBoard(duplicate, board.Index()+1)
+
+	duplicate.UndoHistory.On = false
+
+	clones := []*Task{}
+
+	cloneTask := func(srcTask *Task) *Task {
+
+		srcTask.Board = duplicate
+		clone := srcTask.Clone()
+		srcTask.Board = board
+
+		duplicate.InsertExistingTask(clone)
+		clones = append(clones, clone)
+
+		return clone
+
+	}
+
+	for _, task := range board.Tasks {
+
+		// Line endings are copied along with their bases, so they're connected to the copied base rather than the original
+		if !task.Valid || (task.Is(TASK_TYPE_LINE) && task.LineStart != nil) {
+			continue
+		}
+
+		clone := cloneTask(task)
+		clone.Selected = false
+
+		if task.Is(TASK_TYPE_LINE) {
+
+			clone.LineEndings = []*Task{}
+
+			for _, ending := range task.LineEndings {
+
+				if !ending.Valid {
+					continue
+				}
+
+				newEnding := cloneTask(ending)
+				newEnding.Selected = false
+				newEnding.LineStart = clone
+				clone.LineEndings = append(clone.LineEndings, newEnding)
+
+			}
+
+		}
+
+	}
+
+	duplicate.UndoHistory.On = true
+
+	for _, clone := range clones {
+		clone.ReceiveMessage(MessageTaskRestore, nil)
+	}
+
+	duplicate.TaskChanged = true
+
+	// Copied Board links lead to the same Boards as the originals, which stay nested inside of the original Board, as it
+	// comes first
+	project.InvalidateBoardParents()
+
+	project.BoardIndex = duplicate.Index()
+	project.Modified = true
+	project.Log("Duplicated Board %s, with %d Task(s).", board.Name, len(clones))
+
+	return duplicate
+
+}
+
+// SetBoardArchived archives or unarchives the Board given. The last top-level Board that isn't archived can't be archived,
+// and archiving the current Board switches to the Board it's nested inside of, or the nearest one that isn't archived.
+func (project *Project) SetBoardArchived(board *Board, archived bool) {
+
+	if board.Archived == archived {
+		return
+	}
+
+	if archived {
+
+		listed := project.ListedBoards()
+		parent := project.ParentBoard(board)
+
+		if parent == nil && len(listed) <= 1 {
+			project.Log("Can't archive the only Board that isn't archived.")
+			return
+		}
+
+		board.Archived = true
+
+		if board == project.CurrentBoard() && parent != nil {
+			project.BoardIndex = parent.Index()
+		} else if board == project.CurrentBoard() {
+
+			next := listed[0]
+
+			for i, other := range listed {
+				if other == board {
+					if i > 0 {
+						next = listed[i-1]
+					} else {
+						next = listed[i+1]
+					}
+				}
+			}
+
+			project.BoardIndex = next.Index()
+
+		}
+
+		project.Log("Archived Board %s; its Tasks can still be searched for.", board.Name)
+
+	} else {
+		board.Archived = false
+		project.Log("Restored Board %s from the archive.", board.Name)
+	}
+
+	project.Modified = true
+
+}
+
+// UpdateBoardDragging lets the listed Boards be reordered by dragging them in the Boards panel; rows are the positions of
+// the listed Boards' buttons.
+func (project *Project) UpdateBoardDragging(listed []*Board, rows []rl.Rectangle) {
+
+	if MousePressed(rl.MouseLeftButton) {
+
+		project.draggingBoard = nil
+
+		for i, row := range rows {
+			if rl.CheckCollisionPointRec(GetMousePosition(), row) {
+				project.draggingBoard = listed[i]
+			}
+		}
+
+	}
+
+	if project.draggingBoard == nil {
+		return
+	}
+
+	if !MouseDown(rl.MouseLeftButton) {
+		project.draggingBoard = nil
+		return
+	}
+
+	mouse := GetMousePosition()
+
+	for i, row := range rows {
+
+		if mouse.Y >= row.Y && mouse.Y < row.Y+row.Height && listed[i] != project.draggingBoard {
+			project.MoveBoard(project.draggingBoard, listed[i].Index())
+			project.Log("Moved Board %s to position %d.", project.draggingBoard.Name, i+1)
+			break
+		}
+
+	}
+
+	// Show where the Board is being dragged
+	for i, row := range rows {
+		if listed[i] == project.draggingBoard {
+			rl.DrawRectangleLinesEx(row, 2, getThemeColor(GUI_OUTLINE_HIGHLIGHTED))
+		}
+	}
+
+}
+
+// uniqueBoardName returns the name given, numbered if another Board already has it.
+func (project *Project) uniqueBoardName(name string) string {
+
+	taken := func(candidate string) bool {
+		for _, board := range project.Boards {
+			if board.Name == candidate {
+				return true
+			}
+		}
+		return false
+	}
+
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+
+	return unique
+
+}
+
+func (project *Project) serializeBoardList(data string) string {
+
+	ids := []string{}
+	archived := []string{}
+
+	for _, board := range project.Boards {
+		ids = append(ids, board.ID)
+		if board.Archived {
+			archived = append(archived, board.ID)
+		}
+	}
+
+	data, _ = sjson.Set(data, `BoardIDs`, ids)
+	data, _ = sjson.Set(data, `ArchivedBoards`, archived)
+
+	return data
+
+}
+
+// loadBoardList loads the IDs of the Boards and which are archived; the Boards have to have been created already. Plans
+// saved before Boards had IDs keep the ones the Boards were just given.
+func (project *Project) loadBoardList(data gjson.Result) {
+
+	for i, id := range data.Get(`BoardIDs`).Array() {
+		if i < len(project.Boards) && id.String() != "" {
+			project.Boards[i].ID = id.String()
+		}
+	}
+
+	for _, id := range data.Get(`ArchivedBoards`).Array() {
+		if board := project.BoardByID(id.String()); board != nil {
+			board.Archived = true
+		}
+	}
+
+	project.InvalidateBoardParents()
+
+}
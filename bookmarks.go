@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Camera bookmarks are named views (a camera position and zoom level) saved on a Board, so particular areas of a plan can
+// be jumped back to quickly. They're listed in the bookmarks panel below the Boards, and the first nine can be jumped to
+// with a keybinding each.
+//
+// Presentation mode steps through the current Board's bookmarks in order, gliding the camera from one to the next with
+// the rest of the GUI hidden, for walking through a plan in front of others. While presenting, Right, Space, Page Down,
+// or Enter go to the next bookmark, Left, Backspace, or Page Up go back, and Escape stops presenting.
+
+type CameraBookmark struct {
+	Name      string
+	Pan       rl.Vector2
+	ZoomLevel int
+}
+
+// How long the name of the bookmark being presented stays on screen before it fades out, in seconds.
+const presentationCaptionTime = 2.5
+
+// AddCameraBookmark bookmarks the current view on the current Board under the name given. Adding a bookmark with the name
+// of an existing one updates that bookmark to the current view.
+func (project *Project) AddCameraBookmark(name string) {
+
+	board := project.CurrentBoard()
+
+	bookmark := &CameraBookmark{Name: name, Pan: project.CameraPan, ZoomLevel: project.ZoomLevel}
+
+	for i, existing := range board.Bookmarks {
+		if existing.Name == name {
+			board.Bookmarks[i] = bookmark
+			project.Modified = true
+			project.Log("Updated camera bookmark [%s].", name)
+			return
+		}
+	}
+
+	board.Bookmarks = append(board.Bookmarks, bookmark)
+	project.Modified = true
+	project.Log("Added camera bookmark [%s].", name)
+
+}
+
+// GoToCameraBookmark moves the camera to the current Board's bookmark at the index given.
+func (project *Project) GoToCameraBookmark(index int) {
+
+	board := project.CurrentBoard()
+
+	if index < 0 || index >= len(board.Bookmarks) {
+		return
+	}
+
+	bookmark := board.Bookmarks[index]
+
+	project.CameraPan = bookmark.Pan
+	project.ZoomLevel = bookmark.ZoomLevel
+
+	if !project.Presenting {
+		project.Log("Jumped to camera bookmark [%s].", bookmark.Name)
+	}
+
+}
+
+// RemoveCameraBookmark deletes the current Board's bookmark at the index given.
+func (project *Project) RemoveCameraBookmark(index int) {
+
+	board := project.CurrentBoard()
+
+	if index < 0 || index >= len(board.Bookmarks) {
+		return
+	}
+
+	name := board.Bookmarks[index].Name
+	board.Bookmarks = append(board.Bookmarks[:index], board.Bookmarks[index+1:]...)
+	project.Modified = true
+	project.Log("Deleted camera bookmark [%s].", name)
+
+}
+
+// StartPresentation starts presenting the current Board's bookmarks, from the first one.
+func (project *Project) StartPresentation() {
+
+	if len(project.CurrentBoard().Bookmarks) == 0 {
+		project.Log("Add camera bookmarks to this Board to present it.")
+		return
+	}
+
+	// Where the camera was is kept, so it can go back there once the presentation's over
+	project.presentingFromPan = project.CameraPan
+	project.presentingFromZoom = project.ZoomLevel
+
+	project.Presenting = true
+	project.PresentationIndex = 0
+	project.ContextMenuOpen = false
+	project.Selecting = false
+	project.Searchbar.SetFocused(false)
+	project.GoToCameraBookmark(0)
+	project.presentationStepTime = rl.GetTime()
+
+}
+
+// StopPresentation stops presenting, returning the camera to where it was before the presentation started.
+func (project *Project) StopPresentation() {
+
+	project.Presenting = false
+	project.CameraPan = project.presentingFromPan
+	project.ZoomLevel = project.presentingFromZoom
+	project.Log("Presentation ended.")
+
+}
+
+// StepPresentation moves the presentation forward (or backward, for a negative step) through the current Board's bookmarks.
+func (project *Project) StepPresentation(step int) {
+
+	bookmarks := project.CurrentBoard().Bookmarks
+
+	if len(bookmarks) == 0 {
+		project.StopPresentation()
+		return
+	}
+
+	index := project.PresentationIndex + step
+
+	if index < 0 {
+		index = 0
+	} else if index >= len(bookmarks) {
+		index = len(bookmarks) - 1
+	}
+
+	if index != project.PresentationIndex {
+		project.PresentationIndex = index
+		project.GoToCameraBookmark(index)
+		project.presentationStepTime = rl.GetTime()
+	}
+
+}
+
+// PresentationShortcuts handles input while presenting; the regular shortcuts are left off so a stray key press doesn't
+// change the plan mid-presentation.
+func (project *Project) PresentationShortcuts() {
+
+	if rl.IsKeyPressed(rl.KeyEscape) || programSettings.Keybindings.On(KBTogglePresentation) {
+		project.StopPresentation()
+	} else if rl.IsKeyPressed(rl.KeyRight) || rl.IsKeyPressed(rl.KeySpace) || rl.IsKeyPressed(rl.KeyPageDown) || rl.IsKeyPressed(rl.KeyEnter) {
+		project.StepPresentation(1)
+	} else if rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressed(rl.KeyBackspace) || rl.IsKeyPressed(rl.KeyPageUp) {
+		project.StepPresentation(-1)
+	}
+
+}
+
+// DrawPresentation draws the name of the bookmark being presented, which fades out shortly after it's reached.
+func (project *Project) DrawPresentation() {
+
+	bookmarks := project.CurrentBoard().Bookmarks
+
+	if project.PresentationIndex >= len(bookmarks) {
+		return
+	}
+
+	elapsed := rl.GetTime() - project.presentationStepTime
+
+	if elapsed > presentationCaptionTime {
+		return
+	}
+
+	alpha := float32(1)
+	if fade := presentationCaptionTime - elapsed; fade < 0.5 {
+		alpha = fade / 0.5
+	}
+
+	text := fmt.Sprintf("%s (%d / %d)", bookmarks[project.PresentationIndex].Name, project.PresentationIndex+1, len(bookmarks))
+	size, _ := TextSize(text, true)
+
+	rect := rl.Rectangle{(float32(rl.GetScreenWidth()) - size.X) / 2, float32(rl.GetScreenHeight()) - size.Y - 32, size.X, size.Y}
+	rect.X -= 8
+	rect.Width += 16
+
+	bgColor := getThemeColor(GUI_INSIDE)
+	bgColor.A = uint8(float32(bgColor.A) * alpha)
+	fontColor := getThemeColor(GUI_FONT_COLOR)
+	fontColor.A = uint8(float32(fontColor.A) * alpha)
+
+	rl.DrawRectangleRec(rect, bgColor)
+	DrawGUITextColored(rl.Vector2{rect.X + 8, rect.Y}, fontColor, text)
+
+}
+
+// DrawBookmarkList draws the list of the current Board's camera bookmarks below the Boards, if it's open. Clicking on a
+// bookmark jumps to it.
+func (project *Project) DrawBookmarkList() {
+
+	project.BookmarkPanel = rl.Rectangle{}
+
+	if !project.BookmarkListOpen {
+		return
+	}
+
+	bookmarks := project.CurrentBoard().Bookmarks
+
+	w := float32(240)
+	h := float32(24)
+	x := float32(rl.GetScreenWidth()) - w - 64
+	y := project.BoardPanel.Y + project.BoardPanel.Height + 16
+
+	project.BookmarkPanel = rl.Rectangle{x, y, w, h * float32(len(bookmarks)+2)}
+
+	DrawGUIText(rl.Vector2{x, y - 2}, "Camera Bookmarks")
+	y += h
+
+	for i, bookmark := range bookmarks {
+
+		name := bookmark.Name
+		if i < 9 {
+			name = fmt.Sprintf("%d: %s", i+1, name)
+		}
+
+		if ImmediateButton(rl.Rectangle{x, y, w - h, h}, name, false) {
+			project.GoToCameraBookmark(i)
+		}
+
+		if ImmediateButton(rl.Rectangle{x + w - h, y, h, h}, "x", false) {
+			project.RemoveCameraBookmark(i)
+			break
+		}
+
+		y += h
+
+	}
+
+	if ImmediateButton(rl.Rectangle{x, y, w / 2, h}, "Add", false) {
+		project.PopupAction = ActionAddBookmark
+		project.PopupArgument = fmt.Sprintf("Bookmark %d", len(bookmarks)+1)
+	}
+
+	if ImmediateButton(rl.Rectangle{x + w/2, y, w / 2, h}, "Present", len(bookmarks) == 0) {
+		project.StartPresentation()
+	}
+
+}
+
+func (project *Project) serializeBookmarks(data string) string {
+
+	boards := [][]map[string]interface{}{}
+
+	for _, board := range project.Boards {
+
+		bookmarks := []map[string]interface{}{}
+
+		for _, bookmark := range board.Bookmarks {
+			bookmarks = append(bookmarks, map[string]interface{}{
+				"Name":      bookmark.Name,
+				"X":         bookmark.Pan.X,
+				"Y":         bookmark.Pan.Y,
+				"ZoomLevel": bookmark.ZoomLevel,
+			})
+		}
+
+		boards = append(boards, bookmarks)
+
+	}
+
+	data, _ = sjson.Set(data, `BoardBookmarks`, boards)
+
+	return data
+
+}
+
+// loadBookmarks loads the bookmarks for each Board; the Boards have to have been created already.
+func (project *Project) loadBookmarks(data gjson.Result) {
+
+	for i, bookmarks := range data.Get(`BoardBookmarks`).Array() {
+
+		if i >= len(project.Boards) {
+			break
+		}
+
+		board := project.Boards[i]
+		board.Bookmarks = []*CameraBookmark{}
+
+		for _, bookmarkData := range bookmarks.Array() {
+			board.Bookmarks = append(board.Bookmarks, &CameraBookmark{
+				Name:      bookmarkData.Get(`Name`).String(),
+				Pan:       rl.Vector2{float32(bookmarkData.Get(`X`).Float()), float32(bookmarkData.Get(`Y`).Float())},
+				ZoomLevel: int(bookmarkData.Get(`ZoomLevel`).Int()),
+			})
+		}
+
+	}
+
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// terminalOutput is the original standard output; init() redirects os.Stdout to the log file, but command-line
+// commands still need to be able to print to the terminal they were run from.
+var terminalOutput = os.Stdout
+
+type CLICommand struct {
+	Usage       string
+	Description string
+	Run         func(args []string) error
+}
+
+// cliCommands are the commands MasterPlan can run without opening a window, as "masterplan <command> [arguments]".
+var cliCommands = map[string]*CLICommand{
+	"host": {
+		Usage:       "host <plan file> [port]",
+		Description: "Hosts a LAN session for the given plan without opening a window, saving changes back to the file.",
+		Run:         hostPlan,
+	},
+	"serve": {
+		Usage:       "serve <plan file> [port] [address]",
+		Description: "Serves a read-only web dashboard and JSON API (under /api/) for the given plan, updating as the file changes. It's only served to this computer unless an address to listen on is given (like 0.0.0.0 for every network).",
+		Run:         servePlan,
+	},
+	"export": {
+		Usage:       "export <plan file> <output .png, .svg, .tmx or .json> [board number] [task x,y] [scale]",
+		Description: "Exports a board, or the Whiteboard or Map Task at the given position on it, to a PNG or SVG image. Map Tasks can also be exported to Tiled TMX or JSON maps.",
+		Run:         exportPlan,
+	},
+	"media": {
+		Usage:       "media <plan file> [collect]",
+		Description: "Lists the media files the given plan uses, noting any that are missing. With \"collect\", copies them into the plan's media folder and updates the plan to use the copies.",
+		Run:         collectPlanMedia,
+	},
+	"query": {
+		Usage:       "query <plan file> <query>",
+		Description: "Lists the Tasks in the given plan that match a search query, like 'type:checkbox is:incomplete due:<7d board:Art \"boss fight\"'.",
+		Run:         queryPlan,
+	},
+}
+
+// runCLICommand runs the command named by the program's arguments, if there is one, returning true if it did.
+func runCLICommand() bool {
+
+	if len(os.Args) < 2 {
+		return false
+	}
+
+	if os.Args[1] == "help" || os.Args[1] == "--help" {
+		fmt.Fprintln(terminalOutput, "Usage: masterplan [plan file]")
+		for _, command := range cliCommands {
+			fmt.Fprintf(terminalOutput, "       masterplan %s\n           %s\n", command.Usage, command.Description)
+		}
+		return true
+	}
+
+	command, exists := cliCommands[os.Args[1]]
+
+	if !exists {
+		return false
+	}
+
+	programSettings.Load()
+
+	if err := command.Run(os.Args[2:]); err != nil {
+		fmt.Fprintf(terminalOutput, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	return true
+
+}
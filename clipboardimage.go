@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	_ "golang.org/x/image/bmp"
+)
+
+// The clipboard library MasterPlan uses only handles text, so images are read from the clipboard with command-line tools
+// instead: xclip or wl-paste on Linux (as the clipboard library itself uses for text), osascript on macOS, and PowerShell
+// on Windows. Images that aren't PNGs are converted to PNG.
+
+var errNoClipboardImage = errors.New("no image in clipboard")
+
+// clipboardImageTypes are the image MIME types we look for on the clipboard, in order of preference.
+var clipboardImageTypes = []string{"image/png", "image/bmp", "image/jpeg", "image/gif"}
+
+// ReadClipboardImage returns the image on the clipboard, encoded as a PNG.
+func ReadClipboardImage() ([]byte, error) {
+
+	var data []byte
+	var err error
+
+	switch runtime.GOOS {
+	case "windows":
+		data, err = readClipboardImageWindows()
+	case "darwin":
+		data, err = readClipboardImageMac()
+	default:
+		data, err = readClipboardImageUnix()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, errNoClipboardImage
+	}
+
+	if bytes.HasPrefix(data, []byte("\x89PNG")) {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.Buffer{}
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+
+}
+
+func readClipboardImageUnix() ([]byte, error) {
+
+	// wl-paste for Wayland sessions; xclip otherwise (including XWayland, if wl-paste isn't installed)
+	var list, read func(mime string) *exec.Cmd
+
+	if _, err := exec.LookPath("wl-paste"); err == nil && os.Getenv("WAYLAND_DISPLAY") != "" {
+		list = func(string) *exec.Cmd { return exec.Command("wl-paste", "--list-types") }
+		read = func(mime string) *exec.Cmd { return exec.Command("wl-paste", "--no-newline", "--type", mime) }
+	} else if _, err := exec.LookPath("xclip"); err == nil {
+		list = func(string) *exec.Cmd { return exec.Command("xclip", "-selection", "clipboard", "-t", "TARGETS", "-o") }
+		read = func(mime string) *exec.Cmd { return exec.Command("xclip", "-selection", "clipboard", "-t", mime, "-o") }
+	} else {
+		return nil, errors.New("pasting images needs xclip or wl-clipboard to be installed")
+	}
+
+	types, err := list("").Output()
+	if err != nil {
+		return nil, errNoClipboardImage
+	}
+
+	available := map[string]bool{}
+	for _, t := range strings.Fields(string(types)) {
+		available[t] = true
+	}
+
+	for _, mime := range clipboardImageTypes {
+		if available[mime] {
+			return read(mime).Output()
+		}
+	}
+
+	return nil, errNoClipboardImage
+
+}
+
+var macClipboardData = regexp.MustCompile(`«data PNGf([0-9A-Fa-f]+)»`)
+
+func readClipboardImageMac() ([]byte, error) {
+
+	out, err := exec.Command("osascript", "-e", "the clipboard as «class PNGf»").Output()
+	if err != nil {
+		return nil, errNoClipboardImage
+	}
+
+	match := macClipboardData.FindSubmatch(out)
+	if match == nil {
+		return nil, errNoClipboardImage
+	}
+
+	return hex.DecodeString(string(match[1]))
+
+}
+
+func readClipboardImageWindows() ([]byte, error) {
+
+	temp, err := ioutil.TempFile("", "masterplan_clipboard*.png")
+	if err != nil {
+		return nil, err
+	}
+	temp.Close()
+	defer os.Remove(temp.Name())
+
+	script := `Add-Type -AssemblyName System.Windows.Forms; Add-Type -AssemblyName System.Drawing; ` +
+		`$image = [System.Windows.Forms.Clipboard]::GetImage(); ` +
+		`if ($image -ne $null) { $image.Save('` + filepath.Clean(temp.Name()) + `', [System.Drawing.Imaging.ImageFormat]::Png) }`
+
+	if err := exec.Command("powershell", "-NoProfile", "-STA", "-Command", script).Run(); err != nil {
+		return nil, errNoClipboardImage
+	}
+
+	return ioutil.ReadFile(temp.Name())
+
+}
+
+// PasteImage creates an Image Task at the mouse position from the image on the clipboard, saving the image into the
+// Project's media folder (or a temporary one, if the Project hasn't been saved yet; it's moved into the media folder when
+// the Project is saved). PasteImage returns false if there was no image to paste.
+func (board *Board) PasteImage() bool {
+
+	data, err := ReadClipboardImage()
+
+	if err != nil {
+		if err != errNoClipboardImage {
+			board.Project.Log("ERROR: Couldn't paste image from clipboard: %s", err.Error())
+		}
+		return false
+	}
+
+	planPath := board.Project.FilePath
+	if planPath == "" {
+		planPath = filepath.Join(board.Project.TempDir, "clipboard.plan")
+	}
+
+	path, err := storeMediaData(planPath, data, ".png")
+	if err != nil {
+		board.Project.Log("ERROR: Couldn't save pasted image: %s", err.Error())
+		return false
+	}
+
+	board.Project.LogOn = false
+
+	task := board.CreateNewTask()
+	task.TaskType.CurrentChoice = TASK_TYPE_IMAGE
+	task.FilePathTextbox.SetText(path)
+	task.SetContents()
+	task.Contents.(*ImageContents).ResetSize = true
+	task.ReceiveMessage(MessageTaskRestore, nil)
+
+	board.Project.LogOn = true
+
+	board.Project.Log("Pasted a new Image Task from the clipboard.")
+
+	return true
+
+}
@@ -0,0 +1,102 @@
+package main
+
+// Completion is counted in the same way everywhere it's shown - on Tasks, in the status bar, and on the dashboard (which
+// works from a plan's serialized data without a window, and so from TaskSummaries rather than Tasks) - by working through
+// the completable interface below.
+
+type completable interface {
+	Is(taskTypes ...int) bool
+	completionState() completionState
+	subTaskCount() int
+	subTask(index int) completable
+}
+
+// completionState is the state a completable thing is complete or not from; only the part relevant to its type is filled.
+type completionState struct {
+	Checked        bool
+	Current, Max   int
+	HasTable       bool
+	TableCompleted int
+	TableTotal     int
+}
+
+func isCompletable(c completable) bool {
+	return c.Is(TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_TABLE)
+}
+
+func isComplete(c completable) bool {
+
+	if c.Is(TASK_TYPE_BOOLEAN) && c.subTaskCount() > 0 {
+
+		for i := 0; i < c.subTaskCount(); i++ {
+			if !isComplete(c.subTask(i)) {
+				return false
+			}
+		}
+
+		return true
+
+	}
+
+	state := c.completionState()
+
+	if c.Is(TASK_TYPE_BOOLEAN) {
+		return state.Checked
+	} else if c.Is(TASK_TYPE_PROGRESSION) {
+		return state.Max > 0 && state.Current >= state.Max
+	} else if c.Is(TASK_TYPE_TABLE) && state.HasTable {
+		return state.TableCompleted >= state.TableTotal
+	}
+
+	return false
+
+}
+
+// countTotals returns how many of the thing's direct sub-Tasks are complete and how many there are, followed by the same
+// counted recursively, as a parent Checkbox Task displays them.
+func countTotals(c completable) (int, int, int, int) {
+
+	cnt, max, rcnt, rmax := 0, 0, 0, 0
+
+	for i := 0; i < c.subTaskCount(); i++ {
+
+		t := c.subTask(i)
+
+		if isCompletable(t) {
+			max++
+			rmax++
+			if isComplete(t) {
+				cnt++
+				rcnt++
+			}
+		}
+
+		if t.subTaskCount() > 0 {
+			_, _, rc, rm := countTotals(t)
+			rcnt += rc
+			rmax += rm
+		}
+
+	}
+
+	return cnt, max, rcnt, rmax
+
+}
+
+// countCompletion returns how many of the completable things the thing counts for in the status bar are complete, and
+// how many there are; Tables count each of their cells.
+func countCompletion(c completable) (int, int) {
+
+	if !isCompletable(c) {
+		return 0, 0
+	}
+
+	if state := c.completionState(); c.Is(TASK_TYPE_TABLE) && state.HasTable {
+		return state.TableCompleted, state.TableTotal
+	} else if isComplete(c) {
+		return 1, 1
+	}
+
+	return 0, 1
+
+}
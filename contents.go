@@ -96,6 +96,13 @@ func drawTaskBG(task *Task, fillColor rl.Color) {
 	outlineColor = applyGlow(task, outlineColor)
 
 	alpha := float32(task.Board.Project.TaskTransparency.Number()) / float32(task.Board.Project.TaskTransparency.Maximum)
+
+	// Tasks that don't match the active filter are dimmed
+	if task.FilteredOut {
+		alpha *= 0.25
+		outlineColor.A = uint8(float32(outlineColor.A) * 0.25)
+	}
+
 	fillColor.A = uint8(float32(fillColor.A) * alpha)
 
 	if task.Board.Project.OutlineTasks.Checked {
@@ -290,6 +297,10 @@ func (c *CheckboxContents) Draw() {
 
 	}
 
+	badgeWidth := drawTaskBadge(c.Task, cp)
+	cp.X += badgeWidth
+	displaySize.X += badgeWidth
+
 	txt := c.Task.Description.Text()
 
 	extendedText := false
@@ -475,6 +486,12 @@ func (c *ProgressionContents) Draw() {
 
 	}
 
+	cp.X += 4 // Give a bit more room before drawing the badge and text
+
+	badgeWidth := drawTaskBadge(c.Task, cp)
+	cp.X += badgeWidth
+	displaySize.X += badgeWidth
+
 	txt := c.Task.Description.Text()
 
 	extendedText := false
@@ -492,8 +509,6 @@ func (c *ProgressionContents) Draw() {
 
 	txt += fmt.Sprintf(" (%d/%d)", c.Task.CompletionProgressionCurrent.Number(), c.Task.CompletionProgressionMax.Number())
 
-	cp.X += 4 // Give a bit more room before drawing the text
-
 	txt += deadlineText(c.Task)
 
 	if txt != c.DisplayedText {
@@ -1006,20 +1021,515 @@ func (c *ImageContents) Draw() {
 		c.Task.DisplaySize.Y = 16
 	}
 
-}
+}
+
+func (c *ImageContents) Destroy() {
+
+	if c.GifPlayer != nil {
+		c.GifPlayer.Destroy()
+	}
+
+}
+
+func (c *ImageContents) ReceiveMessage(msg string) {}
+
+func (c *ImageContents) Trigger(trigger int) {}
+
+type MediaContents struct {
+	Task            *Task
+	Resource        *Resource
+	LoadedPath      string
+	ChangedResource bool
+	ResetSize       bool
+	ProgressBG      *taskBGProgress
+	DisplayedText   string
+	TextSize        rl.Vector2
+	scrubbing       bool
+	resizing        bool
+
+	// Each Media Task plays its file through its own stream, rather than the Resource's, so Tasks showing the same
+	// file can each be at a different point in it.
+	music     *MusicStream
+	video     *Video
+	streamErr error
+}
+
+func NewMediaContents(task *Task) *MediaContents {
+
+	contents := &MediaContents{
+		Task:       task,
+		ProgressBG: newTaskBGProgress(task),
+	}
+
+	contents.ProgressBG.Max = 1000
+
+	contents.LoadResource()
+
+	return contents
+
+}
+
+func (c *MediaContents) LoadResource() {
+
+	if c.Task.Open {
+
+		if c.Task.LoadMediaButton.Clicked {
+
+			patterns := []string{}
+			for _, ext := range []string{"wav", "ogg", "mp3", "flac", "mp4", "webm", "mkv", "mov", "avi"} {
+				patterns = append(patterns, PermutateCaseForString(ext, "*.")...)
+			}
+
+			if path, err := zenity.SelectFile(zenity.Title("Select audio or video file"), zenity.FileFilters{{Name: "Audio or Video File", Patterns: patterns}}); err == nil && path != "" {
+				c.Task.FilePathTextbox.SetText(path)
+			}
+
+		}
+
+		if c.Task.FilePathTextbox.Changed {
+			c.ChangedResource = true
+		}
+
+	}
+
+	fp := c.Task.FilePathTextbox.Text()
+
+	if !c.Task.Open && c.LoadedPath != fp {
+
+		c.LoadedPath = fp
+
+		c.destroyStream()
+
+		newResource := c.Task.Board.Project.LoadResource(fp)
+
+		if c.ChangedResource && newResource != c.Resource {
+			c.Task.MediaPosition = 0
+			c.ResetSize = true
+		}
+
+		c.ChangedResource = false
+		c.Resource = newResource
+
+	}
+
+	if c.Resource != nil && c.Resource.State() == RESOURCE_STATE_DELETED {
+		c.Resource = nil
+		c.LoadedPath = ""
+		c.destroyStream()
+	}
+
+	if c.Resource != nil && c.Resource.State() == RESOURCE_STATE_READY && c.music == nil && c.video == nil && c.streamErr == nil {
+		if c.Resource.IsMusic() {
+			c.music, c.streamErr = NewMusicStream(c.Resource.LocalFilepath)
+		} else if c.Resource.IsVideo() {
+			c.video = NewVideo(c.Resource.LocalFilepath, c.Task.Board.Project.TempDir)
+		}
+	}
+
+}
+
+func (c *MediaContents) destroyStream() {
+
+	if c.music != nil {
+		c.music.Destroy()
+		c.Task.MediaPlaying = false
+	}
+
+	if c.video != nil {
+		c.video.Destroy()
+	}
+
+	c.music = nil
+	c.video = nil
+	c.streamErr = nil
+
+}
+
+func (c *MediaContents) Update() {
+
+	c.LoadResource()
+
+	if c.resizing && MouseReleased(rl.MouseLeftButton) {
+		c.resizing = false
+		c.Task.UndoChange = true
+		c.Task.Board.TaskChanged = true
+	}
+
+	// Audio has to be streamed every frame, whether the Task is visible or not
+	if c.music != nil {
+
+		if c.music.Playing {
+
+			c.music.Update(c.Task.MediaLoop.Checked)
+
+			// The position is kept on the Task, so it's saved with the plan, and the audio picks up from there when it's
+			// loaded again.
+			c.Task.MediaPosition = c.music.Position()
+
+			if !c.music.Playing {
+				c.Task.MediaPlaying = false
+			}
+
+		}
+
+	} else if c.video != nil {
+
+		if !c.video.Ready() && !c.video.frameWanted && !c.video.extracting && c.video.Err == nil {
+			c.video.RequestFrame(c.Task.MediaPosition)
+		}
+
+		c.video.Update()
+
+	}
+
+}
+
+// Play starts or resumes playing the Task's audio from where it was.
+func (c *MediaContents) Play() {
+
+	if c.music == nil || c.Task.MediaPlaying {
+		return
+	}
+
+	if c.Task.MediaPosition >= c.music.Length() {
+		c.Task.MediaPosition = 0
+	}
+
+	c.music.Play(c.Task.MediaPosition)
+	c.Task.MediaPlaying = true
+
+}
+
+// Pause pauses the Task's audio.
+func (c *MediaContents) Pause() {
+
+	if c.music != nil && c.music.Playing {
+		c.Task.MediaPosition = c.music.Position()
+		c.music.Stop()
+	}
+
+	c.Task.MediaPlaying = false
+
+}
+
+// Stop stops the Task's audio, so it plays from the beginning next time.
+func (c *MediaContents) Stop() {
+
+	if c.music != nil {
+		c.music.Stop()
+		c.Task.MediaPosition = 0
+	}
+
+	c.Task.MediaPlaying = false
+
+}
+
+// mediaTimeText formats a time in seconds as minutes and seconds.
+func mediaTimeText(seconds float32) string {
+	s := int(seconds)
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+func (c *MediaContents) Draw() {
+
+	drawTaskBG(c.Task, getThemeColor(GUI_INSIDE))
+
+	project := c.Task.Board.Project
+	cp := rl.Vector2{c.Task.Rect.X, c.Task.Rect.Y}
+	text := ""
+
+	if c.Resource != nil {
+
+		switch c.Resource.State() {
+
+		case RESOURCE_STATE_READY:
+
+			if c.streamErr != nil {
+				text = c.streamErr.Error()
+			} else if c.music != nil {
+				c.drawAudio()
+				return
+			} else if c.video != nil {
+				text = c.drawVideo()
+			}
+
+		case RESOURCE_STATE_DOWNLOADING:
+
+			progress := c.Resource.Progress()
+			if progress >= 0 {
+				text = fmt.Sprintf("Downloading [%s]... [%d%%]", c.Resource.Filename(), progress)
+				c.ProgressBG.Max = 100
+				c.ProgressBG.Current = progress
+				c.ProgressBG.Draw()
+			} else {
+				text = fmt.Sprintf("Downloading [%s]...", c.Resource.Filename())
+			}
+
+		case RESOURCE_STATE_LOADING:
+
+			if FileExists(c.Resource.LocalFilepath) {
+				text = fmt.Sprintf("Loading [%s]...", c.Resource.Filename())
+			} else {
+				text = fmt.Sprintf("Non-existant file [%s]", c.Resource.Filename())
+			}
+
+		}
+
+	} else {
+		text = "No audio or video loaded."
+	}
+
+	if text != "" {
+
+		displaySize := rl.Vector2{16, 16}
+
+		if project.ShowIcons.Checked {
+			rl.DrawTexturePro(project.GUI_Icons, rl.Rectangle{80, 0, 16, 16}, rl.Rectangle{cp.X + 8, cp.Y + 8, 16, 16}, rl.Vector2{8, 8}, 0, getThemeColor(GUI_FONT_COLOR))
+			cp.X += 16
+			displaySize.X += 16
+		}
+
+		DrawText(cp, text)
+
+		if text != c.DisplayedText {
+			c.TextSize, _ = TextSize(text, false)
+			c.DisplayedText = text
+		}
+
+		displaySize.X += c.TextSize.X
+
+		if c.video == nil {
+			c.Task.DisplaySize = project.RoundPositionToGrid(displaySize)
+		} else {
+			c.Task.TempDisplaySize = project.RoundPositionToGrid(displaySize)
+		}
+
+	}
+
+	if c.Task.DisplaySize.X < 16 {
+		c.Task.DisplaySize.X = 16
+	}
+	if c.Task.DisplaySize.Y < 16 {
+		c.Task.DisplaySize.Y = 16
+	}
+
+}
+
+func (c *MediaContents) drawAudio() {
+
+	project := c.Task.Board.Project
+	cp := rl.Vector2{c.Task.Rect.X, c.Task.Rect.Y}
+
+	length := c.music.Length()
+	played := c.Task.MediaPosition
+
+	c.ProgressBG.Max = 1000
+	c.ProgressBG.Current = 0
+	if length > 0 {
+		c.ProgressBG.Current = int(played / length * 1000)
+	}
+	c.ProgressBG.Draw()
+
+	displaySize := rl.Vector2{48, 16}
+
+	playIcon := rl.Rectangle{16, 16, 16, 16}
+	if c.Task.MediaPlaying {
+		playIcon.X = 32
+	}
+
+	if c.Task.SmallButton(playIcon.X, playIcon.Y, 16, 16, cp.X, cp.Y) {
+		if c.Task.MediaPlaying {
+			c.Pause()
+		} else {
+			c.Play()
+		}
+		ConsumeMouseInput(rl.MouseLeftButton)
+	}
+
+	if c.Task.SmallButton(48, 16, 16, 16, cp.X+16, cp.Y) {
+		c.Stop()
+		c.Play()
+		ConsumeMouseInput(rl.MouseLeftButton)
+	}
+
+	cp.X += 32
+
+	if project.ShowIcons.Checked {
+		rl.DrawTexturePro(project.GUI_Icons, rl.Rectangle{80, 0, 16, 16}, rl.Rectangle{cp.X + 8, cp.Y + 8, 16, 16}, rl.Vector2{8, 8}, 0, getThemeColor(GUI_FONT_COLOR))
+		cp.X += 16
+		displaySize.X += 16
+	}
+
+	text := fmt.Sprintf("%s : %s / %s", c.Resource.Filename(), mediaTimeText(played), mediaTimeText(length))
+
+	// Pausing is stopping and playing again from where the stream was, so files that can't be seeked through start over.
+	if !c.music.CanSeek() {
+		text += " (can't seek; resumes from the start)"
+	}
+
+	DrawText(cp, text)
+
+	if text != c.DisplayedText {
+		c.TextSize, _ = TextSize(text, false)
+		c.DisplayedText = text
+	}
+
+	displaySize.X += c.TextSize.X
+
+	displaySize = project.RoundPositionToGrid(displaySize)
+
+	if displaySize != c.Task.DisplaySize {
+		c.Task.DisplaySize = displaySize
+		c.Task.Board.TaskChanged = true
+	}
+
+}
+
+// drawVideo draws the current frame of the video with a bar along the bottom to scrub through it, returning text to
+// display if there's no frame to show.
+func (c *MediaContents) drawVideo() string {
+
+	project := c.Task.Board.Project
+	video := c.video
+
+	if video.Err != nil && !video.Ready() {
+		return video.Err.Error()
+	}
+
+	if !video.Ready() {
+		return fmt.Sprintf("Loading video [%s]...", c.Resource.Filename())
+	}
+
+	tex := video.Texture
+
+	if c.ResetSize {
+
+		c.ResetSize = false
+
+		coverage := project.ScreenSize.X / camera.Zoom * 0.25
+
+		if tex.Width > tex.Height {
+			c.Task.DisplaySize = rl.Vector2{coverage, coverage * float32(tex.Height) / float32(tex.Width)}
+		} else {
+			c.Task.DisplaySize = rl.Vector2{coverage * float32(tex.Width) / float32(tex.Height), coverage}
+		}
+
+		c.Task.DisplaySize = project.RoundPositionToGrid(c.Task.DisplaySize)
+		c.Task.Board.TaskChanged = true
+
+	}
+
+	dst := rl.Rectangle{c.Task.Rect.X, c.Task.Rect.Y, c.Task.Rect.Width, c.Task.Rect.Height}
+
+	color := rl.White
+	if project.GraphicalTasksTransparent.Checked {
+		color.A = uint8(float32(color.A) * float32(project.TaskTransparency.Number()) / float32(project.TaskTransparency.Maximum))
+	}
+
+	rl.DrawTexturePro(tex, rl.Rectangle{0, 0, float32(tex.Width), float32(tex.Height)}, dst, rl.Vector2{}, 0, color)
+
+	mp := GetWorldMousePosition()
+
+	// Scrubbing bar
+	bar := rl.Rectangle{dst.X, dst.Y + dst.Height - 8, dst.Width, 8}
+	rl.DrawRectangleRec(bar, getThemeColor(GUI_INSIDE_DISABLED))
+
+	if video.Duration > 0 {
+		filled := bar
+		filled.Width *= c.Task.MediaPosition / video.Duration
+		rl.DrawRectangleRec(filled, getThemeColor(GUI_OUTLINE_HIGHLIGHTED))
+	}
+
+	if c.Task.Selected && project.IsInNeutralState() {
+
+		if MousePressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(mp, bar) && video.Duration > 0 {
+			c.scrubbing = true
+			ConsumeMouseInput(rl.MouseLeftButton)
+		}
+
+		corner := rl.Rectangle{dst.X + dst.Width - 8, dst.Y, 8, 8}
+
+		if MousePressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(mp, corner) {
+			c.resizing = true
+		}
+
+		DrawRectExpanded(corner, 1, getThemeColor(GUI_OUTLINE_HIGHLIGHTED))
+		rl.DrawRectangleRec(corner, getThemeColor(GUI_INSIDE))
+
+	}
+
+	if c.scrubbing {
+
+		c.Task.Dragging = false
+		project.Selecting = false
+
+		position := (mp.X - bar.X) / bar.Width
+		if position < 0 {
+			position = 0
+		} else if position > 1 {
+			position = 1
+		}
+
+		c.Task.MediaPosition = position * video.Duration
+		video.RequestFrame(c.Task.MediaPosition)
+
+		if MouseReleased(rl.MouseLeftButton) {
+			c.scrubbing = false
+			c.Task.UndoChange = true
+		}
+
+	}
+
+	if c.resizing {
+
+		c.Task.Dragging = false
+		project.Selecting = false
+
+		// Videos keep their aspect ratio, so only the width is dragged
+		c.Task.DisplaySize.X = mp.X + 4 - c.Task.Position.X
+		if c.Task.DisplaySize.X < 32 {
+			c.Task.DisplaySize.X = 32
+		}
+		c.Task.DisplaySize.Y = c.Task.DisplaySize.X * float32(tex.Height) / float32(tex.Width)
+		c.Task.DisplaySize = project.RoundPositionToGrid(c.Task.DisplaySize)
+
+		c.Task.Rect.Width = c.Task.DisplaySize.X
+		c.Task.Rect.Height = c.Task.DisplaySize.Y
+
+	}
+
+	DrawText(rl.Vector2{dst.X + 4, dst.Y}, fmt.Sprintf("%s / %s", mediaTimeText(c.Task.MediaPosition), mediaTimeText(video.Duration)))
+
+	return ""
+
+}
+
+func (c *MediaContents) Destroy() {
+	c.destroyStream()
+}
+
+func (c *MediaContents) ReceiveMessage(msg string) {}
+
+func (c *MediaContents) Trigger(trigger int) {
 
-func (c *ImageContents) Destroy() {
+	if c.music == nil {
+		return
+	}
 
-	if c.GifPlayer != nil {
-		c.GifPlayer.Destroy()
+	if trigger == TASK_TRIGGER_TOGGLE {
+		if c.Task.MediaPlaying {
+			c.Pause()
+		} else {
+			c.Play()
+		}
+	} else if trigger == TASK_TRIGGER_SET {
+		c.Play()
+	} else if trigger == TASK_TRIGGER_CLEAR {
+		c.Stop()
 	}
 
 }
 
-func (c *ImageContents) ReceiveMessage(msg string) {}
-
-func (c *ImageContents) Trigger(trigger int) {}
-
 type TimerContents struct {
 	Task          *Task
 	TimerValue    float32
@@ -1148,6 +1658,10 @@ func (c *TimerContents) TimeUp() {
 
 	project.Log("Timer [%s] went off.", c.Task.TimerName.Text())
 
+	project.Hooks.Trigger(HookTimerFired, c.Task)
+
+	project.RunTaskCommand(c.Task, HookTimerFired)
+
 	if c.Task.TimerTriggerMode.CurrentChoice != TASK_TRIGGER_NONE {
 
 		triggeredTasks := []*Task{}
@@ -1761,12 +2275,7 @@ func (c *WhiteboardContents) Draw() {
 
 		gs := float32(project.GridSize)
 
-		texture := c.Task.Whiteboard.Texture.Texture
-		src := rl.Rectangle{0, 0, float32(texture.Width), float32(texture.Height)}
-		dst := rl.Rectangle{c.Task.Rect.X + 1, c.Task.Rect.Y + 16 + 1, src.Width - 2, src.Height - 2}
-		src.Height *= -1
-
-		rl.DrawTexturePro(texture, src, dst, rl.Vector2{}, 0, rl.White)
+		c.Task.Whiteboard.Render()
 
 		if c.Task.Selected {
 
@@ -1828,13 +2337,7 @@ func (c *WhiteboardContents) Trigger(triggerMode int) {
 
 }
 
-func (c *WhiteboardContents) ReceiveMessage(msg string) {
-
-	if msg == MessageThemeChange {
-		c.Task.Whiteboard.Deserialize(c.Task.Whiteboard.Serialize())
-	}
-
-}
+func (c *WhiteboardContents) ReceiveMessage(msg string) {}
 
 type TableContents struct {
 	Task           *Task
@@ -2028,6 +2531,9 @@ func (c *TableContents) Draw() {
 
 		}
 
+		// The top-left corner of the Table is otherwise empty, so the priority and assignee badge goes there
+		drawTaskBadge(c.Task, rl.Vector2{c.Task.Rect.X + 2, c.Task.Rect.Y})
+
 		gridWidth := float32(len(c.Task.TableData.Columns)) * gs
 		gridHeight := float32(len(c.Task.TableData.Rows)) * gs
 
@@ -2196,3 +2702,357 @@ func (c *TableContents) ReceiveMessage(msg string) {
 	}
 
 }
+
+type DocumentContents struct {
+	Task             *Task
+	Resource         *Resource
+	LoadedPath       string
+	ChangedResource  bool
+	ProgressBG       *taskBGProgress
+	DisplayedText    string
+	TextSize         rl.Vector2
+	DisplayedPreview string
+	PreviewSize      rl.Vector2
+	PreviewRect      rl.Rectangle
+}
+
+func NewDocumentContents(task *Task) *DocumentContents {
+
+	contents := &DocumentContents{
+		Task:       task,
+		ProgressBG: newTaskBGProgress(task),
+	}
+
+	contents.ProgressBG.Max = 100
+
+	contents.LoadResource()
+
+	return contents
+
+}
+
+func (c *DocumentContents) LoadResource() {
+
+	if c.Task.Open {
+
+		if c.Task.LoadMediaButton.Clicked {
+
+			patterns := []string{}
+			for _, ext := range []string{"pdf", "txt", "md", "markdown"} {
+				patterns = append(patterns, PermutateCaseForString(ext, "*.")...)
+			}
+
+			if path, err := zenity.SelectFile(zenity.Title("Select document"), zenity.FileFilters{{Name: "PDF or Text File", Patterns: patterns}}); err == nil && path != "" {
+				c.Task.FilePathTextbox.SetText(path)
+			}
+
+		}
+
+		if c.Task.FilePathTextbox.Changed {
+			c.ChangedResource = true
+		}
+
+	}
+
+	fp := c.Task.FilePathTextbox.Text()
+
+	if !c.Task.Open && c.LoadedPath != fp {
+		c.LoadedPath = fp
+		c.Resource = c.Task.Board.Project.LoadResource(fp)
+		c.ChangedResource = false
+	}
+
+	if c.Resource != nil && c.Resource.State() == RESOURCE_STATE_DELETED {
+		c.Resource = nil
+		c.LoadedPath = ""
+	}
+
+}
+
+func (c *DocumentContents) Update() {
+
+	c.LoadResource()
+
+	if c.Resource != nil && c.Resource.State() == RESOURCE_STATE_READY && c.Resource.IsDocument() {
+		c.Resource.Document().Update()
+	}
+
+}
+
+// Open opens the Task's document in the system's default application if the mouse is over its preview, returning true if
+// it did. Double-clicking elsewhere on the Task (like its title) edits the Task as usual.
+func (c *DocumentContents) Open() bool {
+
+	if c.Resource == nil || c.Resource.State() != RESOURCE_STATE_READY || !c.Resource.IsDocument() {
+		return false
+	}
+
+	if !rl.CheckCollisionPointRec(GetWorldMousePosition(), c.PreviewRect) {
+		return false
+	}
+
+	if err := c.Resource.Document().Open(); err != nil {
+		c.Task.Board.Project.Log("ERROR: Couldn't open [%s]: %s", c.Resource.Filename(), err.Error())
+	} else {
+		c.Task.Board.Project.Log("Opened [%s].", c.Resource.Filename())
+	}
+
+	ConsumeMouseInput(rl.MouseLeftButton)
+
+	return true
+
+}
+
+func (c *DocumentContents) Draw() {
+
+	drawTaskBG(c.Task, getThemeColor(GUI_INSIDE))
+
+	project := c.Task.Board.Project
+	cp := rl.Vector2{c.Task.Rect.X, c.Task.Rect.Y}
+	text := ""
+
+	c.PreviewRect = rl.Rectangle{}
+
+	var document *Document
+
+	if c.Resource != nil {
+
+		switch c.Resource.State() {
+
+		case RESOURCE_STATE_READY:
+
+			if c.Resource.IsDocument() {
+
+				document = c.Resource.Document()
+
+				if summary := document.Summary(); summary != "" {
+					text = fmt.Sprintf("%s : %s", c.Resource.Filename(), summary)
+				} else {
+					text = c.Resource.Filename()
+				}
+
+				if document.Err != nil {
+					text += "\n" + document.Err.Error()
+				} else if !document.Loaded() {
+					text += "\nRendering preview..."
+				}
+
+			} else {
+				text = fmt.Sprintf("[%s] isn't a PDF or text file.", c.Resource.Filename())
+			}
+
+		case RESOURCE_STATE_DOWNLOADING:
+
+			progress := c.Resource.Progress()
+			if progress >= 0 {
+				text = fmt.Sprintf("Downloading [%s]... [%d%%]", c.Resource.Filename(), progress)
+				c.ProgressBG.Current = progress
+				c.ProgressBG.Draw()
+			} else {
+				text = fmt.Sprintf("Downloading [%s]...", c.Resource.Filename())
+			}
+
+		case RESOURCE_STATE_LOADING:
+
+			if FileExists(c.Resource.LocalFilepath) {
+				text = fmt.Sprintf("Loading [%s]...", c.Resource.Filename())
+			} else {
+				text = fmt.Sprintf("Non-existant file [%s]", c.Resource.Filename())
+			}
+
+		}
+
+	} else {
+		text = "No document loaded."
+	}
+
+	displaySize := rl.Vector2{16, 16}
+
+	if project.ShowIcons.Checked {
+		rl.DrawTexturePro(project.GUI_Icons, rl.Rectangle{96, 0, 16, 16}, rl.Rectangle{cp.X + 8, cp.Y + 8, 16, 16}, rl.Vector2{8, 8}, 0, getThemeColor(GUI_FONT_COLOR))
+		cp.X += 16
+		displaySize.X += 16
+	}
+
+	DrawText(cp, text)
+
+	if text != c.DisplayedText {
+		c.TextSize, _ = TextSize(text, false)
+		c.DisplayedText = text
+	}
+
+	displaySize.X += c.TextSize.X
+	displaySize.Y = c.TextSize.Y
+
+	if document != nil {
+
+		preview := rl.Vector2{c.Task.Rect.X, c.Task.Rect.Y + project.RoundPositionToGrid(displaySize).Y}
+
+		if document.Type == DOCUMENT_TYPE_PDF && document.Texture.ID > 0 {
+
+			// The first page is shown at a fixed width, keeping its aspect ratio
+			tex := document.Texture
+			width := float32(project.GridSize * 12)
+			height := width * float32(tex.Height) / float32(tex.Width)
+
+			c.PreviewRect = rl.Rectangle{preview.X, preview.Y, width, height}
+
+			color := rl.White
+			if project.GraphicalTasksTransparent.Checked {
+				color.A = uint8(float32(color.A) * float32(project.TaskTransparency.Number()) / float32(project.TaskTransparency.Maximum))
+			}
+
+			rl.DrawTexturePro(tex, rl.Rectangle{0, 0, float32(tex.Width), float32(tex.Height)}, c.PreviewRect, rl.Vector2{}, 0, color)
+
+		} else if document.Type == DOCUMENT_TYPE_TEXT && len(document.Preview) > 0 {
+
+			lines := strings.Join(document.Preview, "\n")
+
+			if lines != c.DisplayedPreview {
+				c.PreviewSize, _ = TextSize(lines, false)
+				c.DisplayedPreview = lines
+			}
+
+			c.PreviewRect = rl.Rectangle{preview.X, preview.Y, c.PreviewSize.X + 8, c.PreviewSize.Y}
+
+			rl.DrawRectangleRec(c.PreviewRect, getThemeColor(GUI_INSIDE_DISABLED))
+			DrawText(rl.Vector2{preview.X + 4, preview.Y}, lines)
+
+		}
+
+		if c.PreviewRect.Height > 0 {
+			if c.PreviewRect.Width > displaySize.X {
+				displaySize.X = c.PreviewRect.Width
+			}
+			displaySize.Y = c.PreviewRect.Y + c.PreviewRect.Height - c.Task.Rect.Y
+		}
+
+	}
+
+	displaySize = project.RoundPositionToGrid(displaySize)
+
+	if displaySize.X < 16 {
+		displaySize.X = 16
+	}
+	if displaySize.Y < 16 {
+		displaySize.Y = 16
+	}
+
+	if displaySize != c.Task.DisplaySize {
+		c.Task.DisplaySize = displaySize
+		c.Task.Board.TaskChanged = true
+	}
+
+}
+
+func (c *DocumentContents) Destroy() {}
+
+func (c *DocumentContents) ReceiveMessage(msg string) {}
+
+func (c *DocumentContents) Trigger(trigger int) {}
+
+type BoardLinkContents struct {
+	Task          *Task
+	bgProgress    *taskBGProgress
+	DisplayedText string
+	TextSize      rl.Vector2
+}
+
+func NewBoardLinkContents(task *Task) *BoardLinkContents {
+
+	contents := &BoardLinkContents{
+		Task:       task,
+		bgProgress: newTaskBGProgress(task),
+	}
+
+	return contents
+
+}
+
+func (c *BoardLinkContents) Update() {}
+
+// Open goes to the Board the Task links to, returning false if it doesn't link to one.
+func (c *BoardLinkContents) Open() bool {
+
+	board := c.Task.LinkedBoard()
+
+	if board == nil {
+		return false
+	}
+
+	c.Task.Board.Project.SwitchToBoard(board)
+
+	ConsumeMouseInput(rl.MouseLeftButton)
+
+	return true
+
+}
+
+func (c *BoardLinkContents) Draw() {
+
+	drawTaskBG(c.Task, getThemeColor(GUI_INSIDE))
+
+	project := c.Task.Board.Project
+	cp := rl.Vector2{c.Task.Rect.X, c.Task.Rect.Y}
+	text := ""
+
+	if board := c.Task.LinkedBoard(); board != nil {
+
+		completed, total := board.NestedCompletionTotals()
+
+		c.bgProgress.Current = completed
+		c.bgProgress.Max = total
+		c.bgProgress.Draw()
+
+		text = fmt.Sprintf("%s (%d/%d)", board.Name, completed, total)
+
+	} else if c.Task.LinkedBoardID != "" {
+		text = "Linked Board no longer exists."
+	} else {
+		text = "No Board linked."
+	}
+
+	if c.Task.PrefixText != "" {
+		text = c.Task.PrefixText + " " + text
+	}
+
+	displaySize := rl.Vector2{16, 16}
+
+	if project.ShowIcons.Checked {
+		rl.DrawTexturePro(project.GUI_Icons, rl.Rectangle{96, 16, 16, 16}, rl.Rectangle{cp.X + 8, cp.Y + 8, 16, 16}, rl.Vector2{8, 8}, 0, getThemeColor(GUI_FONT_COLOR))
+		cp.X += 16
+		displaySize.X += 16
+	}
+
+	DrawText(cp, text)
+
+	if text != c.DisplayedText {
+		c.TextSize, _ = TextSize(text, false)
+		c.DisplayedText = text
+	}
+
+	displaySize.X += c.TextSize.X
+	if c.TextSize.Y > 0 {
+		displaySize.Y = c.TextSize.Y
+	}
+
+	displaySize = project.RoundPositionToGrid(displaySize)
+
+	if displaySize != c.Task.DisplaySize {
+		c.Task.DisplaySize = displaySize
+		c.Task.Board.TaskChanged = true
+	}
+
+}
+
+func (c *BoardLinkContents) Destroy() {}
+
+func (c *BoardLinkContents) ReceiveMessage(msg string) {
+
+	if msg == MessageSettingsChange {
+		c.DisplayedText = ""
+	}
+
+}
+
+func (c *BoardLinkContents) Trigger(trigger int) {}
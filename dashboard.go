@@ -0,0 +1,712 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// The dashboard serves a read-only overview of a plan file over HTTP, for people who need to check on a plan's status without running
+// MasterPlan itself. As it runs without a window (and so without fonts or textures), it works from the plan's serialized data alone:
+// Tasks are stacked together using the sizes MasterPlan saved them with (or estimates of them, for plans that were saved without
+// any), and completion is then counted by the same code MasterPlan counts it with.
+
+type PlanSummary struct {
+	Name      string
+	Modified  time.Time
+	Boards    []*BoardSummary
+	Deadlines []*TaskSummary
+
+	tasks []*TaskSummary
+}
+
+type BoardSummary struct {
+	Index      int
+	Name       string
+	Completed  int
+	Total      int
+	Percentage int
+	Stacks     []*StackSummary
+}
+
+type StackSummary struct {
+	Title      string
+	Completed  int
+	Total      int
+	Percentage int
+	Tasks      []*TaskSummary
+}
+
+type TaskSummary struct {
+	Title       string
+	Description string
+	Type        string
+	Board       int
+	X, Y        float32
+	Completable bool
+	Complete    bool
+	// SubCompleted and SubTotal are the recursive totals of the Task's sub-Tasks, as a parent Checkbox Task displays them.
+	SubCompleted int      `json:",omitempty"`
+	SubTotal     int      `json:",omitempty"`
+	Deadline     string   `json:",omitempty"`
+	DaysLeft     int      `json:",omitempty"`
+	Tags         []string `json:",omitempty"`
+	Priority     string   `json:",omitempty"`
+	Assignee     string   `json:",omitempty"`
+
+	taskType      int
+	width, height float32
+	state         completionState
+	tableNames    []string
+	deadline      time.Time
+	below         *TaskSummary
+	hasAbove      bool
+	subTasks      []*TaskSummary
+}
+
+func (ts *TaskSummary) Is(taskTypes ...int) bool {
+	for _, t := range taskTypes {
+		if ts.taskType == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (ts *TaskSummary) IsCompletable() bool {
+	return isCompletable(ts)
+}
+
+func (ts *TaskSummary) IsComplete() bool {
+	return isComplete(ts)
+}
+
+func (ts *TaskSummary) CountTotals() (int, int, int, int) {
+	return countTotals(ts)
+}
+
+func (ts *TaskSummary) completionState() completionState {
+	return ts.state
+}
+
+func (ts *TaskSummary) subTaskCount() int {
+	return len(ts.subTasks)
+}
+
+func (ts *TaskSummary) subTask(index int) completable {
+	return ts.subTasks[index]
+}
+
+func newTaskSummary(taskData gjson.Result, taskType int, gridSize float32) *TaskSummary {
+
+	ts := &TaskSummary{
+		taskType:    taskType,
+		Type:        TaskTypeStr(taskType),
+		Board:       int(taskData.Get(`BoardIndex`).Int()),
+		X:           float32(taskData.Get(`Position\.X`).Float()),
+		Y:           float32(taskData.Get(`Position\.Y`).Float()),
+		Description: taskData.Get(`Description`).String(),
+		state: completionState{
+			Checked: taskData.Get(`Checkbox\.Checked`).Bool(),
+			Current: int(taskData.Get(`Progression\.Current`).Int()),
+			Max:     int(taskData.Get(`Progression\.Max`).Int()),
+		},
+	}
+
+	ts.Title = strings.Split(ts.Description, "\n")[0]
+
+	tags := parseTags(ts.Description)
+	for _, tag := range taskData.Get(`Tags`).Array() {
+		tags = append(tags, tag.String())
+	}
+	ts.Tags = uniqueTags(tags)
+
+	if ts.IsCompletable() {
+		if priority := parsePriority(taskData.Get(`Priority`).String()); priority != PRIORITY_NONE {
+			ts.Priority = priorityNames[priority]
+		}
+		ts.Assignee = taskData.Get(`Assignee`).String()
+	}
+
+	lines := strings.Split(ts.Description, "\n")
+
+	if ts.Is(TASK_TYPE_TIMER) {
+		ts.Title = taskData.Get(`TimerName\.Text`).String()
+		lines = []string{ts.Title + " : 00:00"}
+	} else if ts.Is(TASK_TYPE_IMAGE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT) {
+		filePath := taskData.Get(`FilePath`)
+		if filePath.IsArray() {
+			parts := []string{}
+			for _, part := range filePath.Array() {
+				parts = append(parts, part.String())
+			}
+			ts.Title = strings.Join(parts, "/")
+		} else {
+			ts.Title = filePath.String()
+		}
+	} else if ts.Is(TASK_TYPE_PROGRESSION) {
+		lines[0] += fmt.Sprintf(" %d/%d", ts.state.Current, ts.state.Max)
+	}
+
+	if ts.Is(TASK_TYPE_TABLE) {
+
+		table := gjson.Parse(taskData.Get(`TableData`).String())
+
+		ts.state.HasTable = table.Exists()
+
+		// Counted as TableData.CompletionCount() and CompletionMax() count them
+		for _, row := range table.Get(`Completion`).Array() {
+			for _, cell := range row.Array() {
+				if cell.Int() == 1 {
+					ts.state.TableCompleted++
+				}
+				if cell.Int() != 2 {
+					ts.state.TableTotal++
+				}
+			}
+		}
+
+		for _, name := range append(table.Get(`Rows`).Array(), table.Get(`Columns`).Array()...) {
+			ts.tableNames = append(ts.tableNames, name.String())
+		}
+
+	}
+
+	if taskData.Get(`DisplaySize\.X`).Exists() {
+
+		// The size MasterPlan drew the Task at when the plan was saved
+		ts.width = float32(taskData.Get(`DisplaySize\.X`).Float())
+		ts.height = float32(taskData.Get(`DisplaySize\.Y`).Float())
+
+	} else {
+
+		ts.estimateSize(taskData, lines, gridSize)
+
+	}
+
+	if taskData.Get(`DeadlineDaySpinner\.Number`).Exists() {
+
+		ts.deadline = time.Date(
+			int(taskData.Get(`DeadlineYearSpinner\.Number`).Int()),
+			time.Month(taskData.Get(`DeadlineMonthSpinner\.CurrentChoice`).Int()+1),
+			int(taskData.Get(`DeadlineDaySpinner\.Number`).Int()),
+			0, 0, 0, 0, time.Now().Location())
+
+		ts.Deadline = ts.deadline.Format("2006-01-02")
+
+	}
+
+	return ts
+
+}
+
+// estimateSize estimates the Task's size for plans that were saved without it (like those from older versions, or those
+// written by a headless "masterplan host" process). Text width is a rough estimate, as we don't have the font available; it
+// only needs to be close enough to tell which Tasks touch each other, though, so we err on the wider side.
+func (ts *TaskSummary) estimateSize(taskData gjson.Result, lines []string, gridSize float32) {
+
+	if ts.Is(TASK_TYPE_TABLE) {
+
+		table := gjson.Parse(taskData.Get(`TableData`).String())
+
+		rowNames := table.Get(`Rows`).Array()
+		longest := 0
+		for _, name := range rowNames {
+			if len(name.String()) > longest {
+				longest = len(name.String())
+			}
+		}
+
+		ts.width = float32(longest*8) + float32(len(table.Get(`Columns`).Array()))*gridSize
+		ts.height = float32(len(rowNames)+1) * gridSize
+
+	} else if taskData.Get(`ImageDisplaySize\.X`).Exists() {
+		ts.width = float32(taskData.Get(`ImageDisplaySize\.X`).Float())
+		ts.height = float32(taskData.Get(`ImageDisplaySize\.Y`).Float())
+	} else {
+
+		longest := 0
+		for _, line := range lines {
+			if len([]rune(line)) > longest {
+				longest = len([]rune(line))
+			}
+		}
+
+		ts.width = float32(longest*8) + gridSize*2
+		ts.height = float32(len(lines)) * gridSize
+
+	}
+
+	ts.width = float32(math.Max(float64(gridSize), math.Ceil(float64(ts.width/gridSize))*float64(gridSize)))
+	ts.height = float32(math.Max(float64(gridSize), math.Ceil(float64(ts.height/gridSize))*float64(gridSize)))
+
+}
+
+// SummarizePlan builds a PlanSummary from the contents of a plan file.
+func SummarizePlan(name string, planData gjson.Result) *PlanSummary {
+
+	summary := &PlanSummary{Name: name, Boards: []*BoardSummary{}, Deadlines: []*TaskSummary{}}
+
+	gridSize := float32(16)
+	if planData.Get(`GridSize`).Exists() {
+		gridSize = float32(planData.Get(`GridSize`).Int())
+	}
+
+	boardNames := planData.Get(`BoardNames`).Array()
+	boardCount := int(planData.Get(`BoardCount`).Int())
+
+	if boardCount < len(boardNames) {
+		boardCount = len(boardNames)
+	}
+
+	if boardCount < 1 {
+		boardCount = 1
+	}
+
+	for i := 0; i < boardCount; i++ {
+		board := &BoardSummary{Index: i, Name: fmt.Sprintf("Board %d", i+1), Stacks: []*StackSummary{}}
+		if i < len(boardNames) {
+			board.Name = boardNames[i].String()
+		}
+		summary.Boards = append(summary.Boards, board)
+	}
+
+	// Board links are titled with the name of the Board they lead to
+	linkedNames := map[string]string{}
+	for i, id := range planData.Get(`BoardIDs`).Array() {
+		if i < len(summary.Boards) {
+			linkedNames[id.String()] = summary.Boards[i].Name
+		}
+	}
+
+	boardTasks := make([][]*TaskSummary, boardCount)
+
+	for _, taskData := range planData.Get(`Tasks`).Array() {
+
+		taskType, ok := ParseTaskType(taskData)
+		if !ok || taskType == TASK_TYPE_LINE {
+			continue
+		}
+
+		ts := newTaskSummary(taskData, taskType, gridSize)
+
+		if ts.Is(TASK_TYPE_BOARD) {
+			ts.Title = linkedNames[taskData.Get(`LinkedBoard`).String()]
+		}
+
+		if ts.Board < 0 || ts.Board >= boardCount {
+			continue
+		}
+
+		boardTasks[ts.Board] = append(boardTasks[ts.Board], ts)
+		summary.tasks = append(summary.tasks, ts)
+
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	for boardIndex, tasks := range boardTasks {
+
+		board := summary.Boards[boardIndex]
+
+		linkStacks(tasks, gridSize)
+
+		for _, ts := range tasks {
+
+			completed, total := countCompletion(ts)
+			board.Completed += completed
+			board.Total += total
+
+			if len(ts.subTasks) > 0 {
+				_, _, ts.SubCompleted, ts.SubTotal = ts.CountTotals()
+			}
+
+			ts.Completable = ts.IsCompletable()
+			ts.Complete = ts.IsComplete()
+
+			if !ts.deadline.IsZero() && !ts.Complete {
+				ts.DaysLeft = int(math.Round(ts.deadline.Sub(today).Hours() / 24))
+				summary.Deadlines = append(summary.Deadlines, ts)
+			}
+
+		}
+
+		board.Percentage = percentage(board.Completed, board.Total)
+
+		for _, ts := range tasks {
+
+			if ts.hasAbove {
+				continue
+			}
+
+			stack := &StackSummary{Title: ts.Title, Tasks: []*TaskSummary{}}
+
+			for t, loop := ts, 0; t != nil && loop < 1000; t, loop = t.below, loop+1 {
+				stack.Tasks = append(stack.Tasks, t)
+				completed, total := countCompletion(t)
+				stack.Completed += completed
+				stack.Total += total
+			}
+
+			stack.Percentage = percentage(stack.Completed, stack.Total)
+
+			board.Stacks = append(board.Stacks, stack)
+
+		}
+
+		sort.SliceStable(board.Stacks, func(i, j int) bool {
+			a, b := board.Stacks[i].Tasks[0], board.Stacks[j].Tasks[0]
+			if a.Y != b.Y {
+				return a.Y < b.Y
+			}
+			return a.X < b.X
+		})
+
+	}
+
+	sort.SliceStable(summary.Deadlines, func(i, j int) bool { return summary.Deadlines[i].deadline.Before(summary.Deadlines[j].deadline) })
+
+	return summary
+
+}
+
+// linkStacks works out which Task is below which, and which Tasks are sub-Tasks of others, in the same way as
+// Board.AddTaskToGrid(), Task.UpdateNeighbors() and Task.SetPrefix() do.
+func linkStacks(tasks []*TaskSummary, gridSize float32) {
+
+	grid := map[Position][]*TaskSummary{}
+
+	for _, ts := range tasks {
+		startX, startY := int(math.Round(float64(ts.X/gridSize))), int(math.Round(float64(ts.Y/gridSize)))
+		endX, endY := int(math.Round(float64((ts.X+ts.width)/gridSize))), int(math.Round(float64((ts.Y+ts.height)/gridSize)))
+		for y := startY; y < endY; y++ {
+			for x := startX; x < endX; x++ {
+				grid[Position{x, y}] = append(grid[Position{x, y}], ts)
+			}
+		}
+	}
+
+	// inRect returns the first Task other than the one given in the rectangle, preferring Completable Tasks and Notes.
+	inRect := func(ts *TaskSummary, x, y, w, h float32) *TaskSummary {
+
+		found := []*TaskSummary{}
+		added := map[*TaskSummary]bool{}
+
+		for cy := y; cy < y+h; cy += gridSize {
+			for cx := x; cx < x+w; cx += gridSize {
+				for _, other := range grid[Position{int(cx / gridSize), int(cy / gridSize)}] {
+					if !added[other] {
+						found = append(found, other)
+						added[other] = true
+					}
+				}
+			}
+		}
+
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].IsCompletable() || (found[i].Is(TASK_TYPE_NOTE) && !found[j].IsCompletable())
+		})
+
+		for _, other := range found {
+			if other != ts {
+				return other
+			}
+		}
+
+		return nil
+
+	}
+
+	for _, ts := range tasks {
+		if ts.below = inRect(ts, ts.X, ts.Y+gridSize, ts.width, ts.height); ts.below != nil && ts.below == inRect(ts, ts.X, ts.Y, ts.width, ts.height) {
+			ts.below = nil
+		}
+	}
+
+	for _, ts := range tasks {
+		if ts.below != nil {
+			ts.below.hasAbove = true
+		}
+	}
+
+	for _, ts := range tasks {
+
+		if !ts.Is(TASK_TYPE_BOOLEAN) {
+			continue
+		}
+
+		taskX := int(ts.X / gridSize)
+
+		for below, loop := ts.below, 0; below != nil && below != ts && loop < 1000; below, loop = below.below, loop+1 {
+
+			if !below.IsCompletable() {
+				continue
+			}
+
+			belowX := int(below.X / gridSize)
+
+			if belowX == taskX+1 {
+				ts.subTasks = append(ts.subTasks, below)
+			} else if belowX <= taskX {
+				break
+			}
+
+		}
+
+	}
+
+}
+
+func percentage(completed, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return int(float32(completed) / float32(total) * 100)
+}
+
+// Dashboard keeps an up-to-date PlanSummary of a plan file, reloading it whenever the file changes on disk.
+type Dashboard struct {
+	PlanPath string
+	Summary  *PlanSummary
+	Error    string
+
+	modTime time.Time
+	mutex   sync.RWMutex
+}
+
+func (dashboard *Dashboard) Reload() {
+
+	stat, err := os.Stat(dashboard.PlanPath)
+
+	if err == nil && stat.ModTime().Equal(dashboard.modTime) {
+		return
+	}
+
+	var planData []byte
+
+	if err == nil {
+		planData, err = ioutil.ReadFile(dashboard.PlanPath)
+	}
+
+	dashboard.mutex.Lock()
+	defer dashboard.mutex.Unlock()
+
+	if err != nil {
+		dashboard.Error = err.Error()
+		return
+	}
+
+	if !gjson.ValidBytes(planData) {
+		// The file's probably mid-save; we'll try again on the next check.
+		return
+	}
+
+	dashboard.Error = ""
+	dashboard.modTime = stat.ModTime()
+	dashboard.Summary = SummarizePlan(stat.Name(), gjson.ParseBytes(planData))
+	dashboard.Summary.Modified = dashboard.modTime
+
+}
+
+func (dashboard *Dashboard) writeJSON(w http.ResponseWriter, value func(*PlanSummary) interface{}) {
+
+	dashboard.mutex.RLock()
+	defer dashboard.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if dashboard.Summary == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"Error": dashboard.Error})
+		return
+	}
+
+	json.NewEncoder(w).Encode(value(dashboard.Summary))
+
+}
+
+func (dashboard *Dashboard) Handler() http.Handler {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(dashboardPage))
+	})
+
+	mux.HandleFunc("/api/plan", func(w http.ResponseWriter, r *http.Request) {
+		dashboard.writeJSON(w, func(summary *PlanSummary) interface{} { return summary })
+	})
+
+	mux.HandleFunc("/api/boards", func(w http.ResponseWriter, r *http.Request) {
+		dashboard.writeJSON(w, func(summary *PlanSummary) interface{} { return summary.Boards })
+	})
+
+	mux.HandleFunc("/api/deadlines", func(w http.ResponseWriter, r *http.Request) {
+		dashboard.writeJSON(w, func(summary *PlanSummary) interface{} { return summary.Deadlines })
+	})
+
+	mux.HandleFunc("/api/modified", func(w http.ResponseWriter, r *http.Request) {
+		dashboard.writeJSON(w, func(summary *PlanSummary) interface{} { return summary.Modified })
+	})
+
+	return mux
+
+}
+
+// servePlan serves a read-only dashboard for the plan at the given path until the program is stopped.
+func servePlan(args []string) error {
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: masterplan serve <plan file> [port] [address]")
+	}
+
+	port := 8080
+	if len(args) > 1 {
+		if _, err := fmt.Sscanf(args[1], "%d", &port); err != nil {
+			return fmt.Errorf("invalid port: %s", args[1])
+		}
+	}
+
+	address := "127.0.0.1"
+	if len(args) > 2 {
+		address = args[2]
+	}
+
+	dashboard := &Dashboard{PlanPath: args[0]}
+	dashboard.Reload()
+
+	if dashboard.Summary == nil {
+		return fmt.Errorf("could not load plan %s: %s", args[0], dashboard.Error)
+	}
+
+	go func() {
+		for range time.Tick(time.Second) {
+			dashboard.Reload()
+		}
+	}()
+
+	fmt.Fprintf(terminalOutput, "Serving dashboard for %s at http://%s/\n", args[0], net.JoinHostPort(address, strconv.Itoa(port)))
+
+	return http.ListenAndServe(net.JoinHostPort(address, strconv.Itoa(port)), dashboard.Handler())
+
+}
+
+const dashboardPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>MasterPlan Dashboard</title>
+<style>
+	body { font-family: sans-serif; background: #f4f1ea; color: #333; margin: 2em; }
+	h1 small { font-size: 0.5em; color: #888; font-weight: normal; }
+	section { background: #fff; border: 1px solid #ccc; border-radius: 4px; padding: 1em; margin-bottom: 1em; }
+	.bar { background: #ddd; height: 8px; border-radius: 4px; overflow: hidden; }
+	.bar div { background: #5a9; height: 100%; }
+	.stack { margin: 0.5em 0 0.5em 1em; }
+	.task { margin-left: 1em; color: #555; }
+	.complete { text-decoration: line-through; color: #999; }
+	.overdue { color: #c33; font-weight: bold; }
+	table { border-collapse: collapse; }
+	td, th { padding: 0.2em 1em 0.2em 0; text-align: left; }
+</style>
+</head>
+<body>
+<h1 id="title">MasterPlan Dashboard</h1>
+<section><h2>Upcoming Deadlines</h2><table id="deadlines"></table></section>
+<div id="boards"></div>
+<script>
+let modified = null;
+
+function el(tag, text, className) {
+	const e = document.createElement(tag);
+	if (text !== undefined) e.textContent = text;
+	if (className) e.className = className;
+	return e;
+}
+
+function bar(percentage) {
+	const outer = el("div", undefined, "bar");
+	const inner = el("div");
+	inner.style.width = percentage + "%";
+	outer.appendChild(inner);
+	return outer;
+}
+
+function badge(task) {
+	const parts = [task.Priority, task.Assignee].filter(part => part);
+	return parts.length > 0 ? "[" + parts.join(" ") + "] " : "";
+}
+
+function render(plan) {
+
+	const title = document.getElementById("title");
+	title.textContent = plan.Name + " ";
+	title.appendChild(el("small", "last changed " + new Date(plan.Modified).toLocaleString()));
+
+	const deadlines = document.getElementById("deadlines");
+	deadlines.innerHTML = "<tr><th>Due</th><th>Task</th><th>Board</th></tr>";
+	for (const task of plan.Deadlines) {
+		const row = el("tr", undefined, task.DaysLeft < 0 ? "overdue" : "");
+		const days = task.DaysLeft < 0 ? (-task.DaysLeft) + " days overdue" : task.DaysLeft === 0 || task.DaysLeft === undefined ? "today" : "in " + task.DaysLeft + " days";
+		row.appendChild(el("td", task.Deadline + " (" + days + ")"));
+		row.appendChild(el("td", badge(task) + task.Title));
+		row.appendChild(el("td", plan.Boards[task.Board].Name));
+		deadlines.appendChild(row);
+	}
+
+	const boards = document.getElementById("boards");
+	boards.innerHTML = "";
+	for (const board of plan.Boards) {
+		const section = el("section");
+		section.appendChild(el("h2", board.Name + " - " + board.Completed + " / " + board.Total + " (" + board.Percentage + "%)"));
+		section.appendChild(bar(board.Percentage));
+		for (const stack of board.Stacks) {
+			const div = el("div", undefined, "stack");
+			const heading = stack.Total > 0 ? stack.Title + " - " + stack.Completed + " / " + stack.Total + " (" + stack.Percentage + "%)" : stack.Title;
+			div.appendChild(el("h3", heading));
+			if (stack.Total > 0) div.appendChild(bar(stack.Percentage));
+			for (const task of stack.Tasks.slice(1)) {
+				let text = badge(task) + task.Title;
+				if (task.SubTotal) text += " (" + task.SubCompleted + " / " + task.SubTotal + ")";
+				div.appendChild(el("div", text, "task" + (task.Complete ? " complete" : "")));
+			}
+			section.appendChild(div);
+		}
+		boards.appendChild(section);
+	}
+
+}
+
+async function refresh() {
+	try {
+		const current = await (await fetch("api/modified")).json();
+		if (current !== modified) {
+			render(await (await fetch("api/plan")).json());
+			modified = current;
+		}
+	} catch (e) {}
+}
+
+refresh();
+setInterval(refresh, 2000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
+)
+
+// newTestProject returns a Project with just enough set up to work with Tasks without a window.
+func newTestProject() *Project {
+	project := &Project{GridSize: 16, TeamRoster: NewTextbox(0, 0, 400, 32), SearchIndex: NewSearchIndex()}
+	project.Hooks = NewScriptHooks(project)
+	return project
+}
+
+// newTestTask adds a Task of the given type to the Board at the given grid position, at the size given in grid cells.
+func newTestTask(board *Board, taskType int, description string, x, y, w, h float32) *Task {
+
+	gs := float32(board.Project.GridSize)
+
+	task := NewTask(board)
+	task.TaskType.CurrentChoice = taskType
+	task.Description.SetText(description)
+	task.Position = rl.Vector2{x * gs, y * gs}
+	task.DisplaySize = rl.Vector2{w * gs, h * gs}
+	task.Rect = rl.Rectangle{task.Position.X, task.Position.Y, task.DisplaySize.X, task.DisplaySize.Y}
+
+	board.Tasks = append(board.Tasks, task)
+	board.AddTaskToGrid(task)
+
+	return task
+
+}
+
+func TestDashboardMatchesBoard(t *testing.T) {
+
+	project := newTestProject()
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	newTestTask(board, TASK_TYPE_NOTE, "Level 1", 0, 0, 4, 1)
+	parent := newTestTask(board, TASK_TYPE_BOOLEAN, "Enemies", 0, 1, 5, 1)
+	newTestTask(board, TASK_TYPE_BOOLEAN, "Slime", 1, 2, 3, 1).CompletionCheckbox.Checked = true
+	bat := newTestTask(board, TASK_TYPE_BOOLEAN, "Bat", 1, 3, 3, 1)
+	newTestTask(board, TASK_TYPE_BOOLEAN, "Bat sprite", 2, 4, 6, 1).CompletionCheckbox.Checked = true
+	newTestTask(board, TASK_TYPE_BOOLEAN, "Bat sounds", 2, 5, 6, 1)
+
+	progression := newTestTask(board, TASK_TYPE_PROGRESSION, "Tiles", 0, 6, 4, 1)
+	progression.CompletionProgressionCurrent.SetNumber(3)
+	progression.CompletionProgressionMax.SetNumber(3)
+
+	// This one's far wider than its description would suggest, so it only touches the Task below it going by its real size.
+	newTestTask(board, TASK_TYPE_BOOLEAN, "Music", 20, 0, 12, 1)
+	newTestTask(board, TASK_TYPE_BOOLEAN, "Boss theme", 30, 1, 4, 1).CompletionCheckbox.Checked = true
+
+	for _, task := range board.Tasks {
+		task.UpdateNeighbors()
+	}
+
+	for _, task := range board.Tasks {
+		task.SetPrefix()
+	}
+
+	saved := []string{}
+	for _, task := range board.Tasks {
+		saved = append(saved, task.saveData())
+	}
+
+	summary := SummarizePlan("test", gjson.Parse(`{"BoardNames":["Board 1"],"GridSize":16,"Tasks":[`+strings.Join(saved, ",")+`]}`))
+
+	completed, total := board.CompletionTotals()
+
+	if summary.Boards[0].Completed != completed || summary.Boards[0].Total != total {
+		t.Fatalf("expected the dashboard to count %d / %d, like the status bar, but it counted %d / %d", completed, total, summary.Boards[0].Completed, summary.Boards[0].Total)
+	}
+
+	if completed != 4 || total != 8 {
+		t.Fatalf("expected 4 / 8 completed, got %d / %d", completed, total)
+	}
+
+	// Each Task's sub-Task totals and stack should be the same on the dashboard as they are in MasterPlan.
+	stacks := map[string]string{}
+
+	for _, stack := range summary.Boards[0].Stacks {
+		for _, ts := range stack.Tasks {
+			stacks[ts.Title] = stack.Title
+		}
+	}
+
+	for i, task := range board.Tasks {
+
+		ts := summary.tasks[i]
+
+		cnt, max, rcnt, rmax := task.CountTotals()
+		tsCnt, tsMax, tsRCnt, tsRMax := ts.CountTotals()
+
+		if cnt != tsCnt || max != tsMax || rcnt != tsRCnt || rmax != tsRMax {
+			t.Errorf("expected %s to count %d / %d (%d / %d), but the dashboard counted %d / %d (%d / %d)", ts.Title, cnt, max, rcnt, rmax, tsCnt, tsMax, tsRCnt, tsRMax)
+		}
+
+		if ts.IsComplete() != task.IsComplete() {
+			t.Errorf("expected %s to be complete: %t, but the dashboard has it as %t", ts.Title, task.IsComplete(), ts.IsComplete())
+		}
+
+		if head := strings.Split(task.StackHead.Description.Text(), "\n")[0]; stacks[ts.Title] != head {
+			t.Errorf("expected %s to be in the stack headed by %s, but the dashboard has it headed by %s", ts.Title, head, stacks[ts.Title])
+		}
+
+	}
+
+	if len(parent.SubTasks) != 2 || len(bat.SubTasks) != 2 {
+		t.Fatalf("expected Enemies and Bat to have 2 sub-Tasks each, got %d and %d", len(parent.SubTasks), len(bat.SubTasks))
+	}
+
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/pkg/browser"
+)
+
+// Document Resources preview PDFs and text files (like Markdown). PDFs use poppler's pdfinfo and pdftoppm (if they're
+// installed) to count pages and render the first page as an image in the background, much like Videos use ffmpeg; text
+// files are read directly, keeping the first screenful of lines to draw.
+
+const (
+	DOCUMENT_TYPE_PDF = iota
+	DOCUMENT_TYPE_TEXT
+)
+
+// DOCUMENT_PREVIEW_LINES is how many lines of a text file are kept to preview.
+const DOCUMENT_PREVIEW_LINES = 24
+
+var errNoPoppler = errors.New("PDF previews need poppler-utils (pdfinfo and pdftoppm) to be installed")
+
+var pdfPageCount = regexp.MustCompile(`(?m)^Pages:\s+(\d+)`)
+
+type documentRender struct {
+	Pages int
+	Path  string
+	Err   error
+}
+
+type Document struct {
+	Path    string
+	Type    int
+	Pages   int
+	Lines   int
+	Preview []string
+	Texture rl.Texture2D
+	Err     error
+
+	renders chan documentRender
+	loaded  bool
+}
+
+func NewDocument(path, mime, tempDir string) *Document {
+
+	document := &Document{
+		Path:    path,
+		Type:    DOCUMENT_TYPE_TEXT,
+		renders: make(chan documentRender, 1),
+	}
+
+	if mime == "application/pdf" {
+		document.Type = DOCUMENT_TYPE_PDF
+		document.renderPDF(tempDir)
+	} else {
+		document.readText()
+	}
+
+	return document
+
+}
+
+func (document *Document) renderPDF(tempDir string) {
+
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		document.Err = errNoPoppler
+		document.loaded = true
+		return
+	}
+
+	// pdftoppm adds the extension itself
+	out := filepath.Join(tempDir, fmt.Sprintf("document_page_%p", document))
+
+	go func() {
+
+		render := documentRender{Path: out + ".png"}
+
+		if info, err := exec.Command("pdfinfo", document.Path).Output(); err == nil {
+			if match := pdfPageCount.FindSubmatch(info); match != nil {
+				render.Pages, _ = strconv.Atoi(string(match[1]))
+			}
+		}
+
+		render.Err = exec.Command("pdftoppm", "-png", "-f", "1", "-l", "1", "-singlefile", "-scale-to", "640", document.Path, out).Run()
+
+		if render.Err == nil && !FileExists(render.Path) {
+			render.Err = errors.New("no pages to render")
+		}
+
+		document.renders <- render
+
+	}()
+
+}
+
+func (document *Document) readText() {
+
+	document.loaded = true
+
+	file, err := os.Open(document.Path)
+	if err != nil {
+		document.Err = err
+		return
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+
+		if document.Lines < DOCUMENT_PREVIEW_LINES {
+			// Tabs don't draw in MasterPlan's fonts, and very long lines would only run off the edge of the Task anyway
+			line := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+			if len(line) > 120 {
+				line = line[:120]
+			}
+			document.Preview = append(document.Preview, line)
+		}
+
+		document.Lines++
+
+	}
+
+	document.Err = scanner.Err()
+
+}
+
+// Update picks up the rendered first page of a PDF; as with Videos, textures have to be loaded on the main thread.
+func (document *Document) Update() {
+
+	select {
+	case render := <-document.renders:
+
+		document.loaded = true
+		document.Pages = render.Pages
+
+		if render.Err != nil {
+			document.Err = fmt.Errorf("couldn't render PDF: %s", render.Err.Error())
+		} else {
+			document.Texture = rl.LoadTexture(render.Path)
+			os.Remove(render.Path)
+		}
+
+	default:
+	}
+
+}
+
+// Loaded returns if the Document has finished reading or rendering its preview (successfully or not).
+func (document *Document) Loaded() bool {
+	return document.loaded
+}
+
+// Summary returns the Document's page count (for PDFs) or line count (for text files).
+func (document *Document) Summary() string {
+
+	if document.Type == DOCUMENT_TYPE_PDF {
+		if document.Pages == 1 {
+			return "1 page"
+		} else if document.Pages > 0 {
+			return fmt.Sprintf("%d pages", document.Pages)
+		}
+		return ""
+	}
+
+	if document.Lines == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", document.Lines)
+
+}
+
+// Open opens the Document in the system's default application for it.
+func (document *Document) Open() error {
+	return browser.OpenFile(document.Path)
+}
+
+func (document *Document) Destroy() {
+	if document.Texture.ID > 0 {
+		rl.UnloadTexture(document.Texture)
+		document.Texture = rl.Texture2D{}
+	}
+}
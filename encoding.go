@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Map and Whiteboard Tasks hold a lot of data, and as Tasks are serialized whenever an undo state is captured (as well
+// as for syncing and saving), these are stored in a compact form: lists of integers are written as variable-length,
+// zig-zag encoded numbers (so small values, positive or negative, take a single byte) and then base64-encoded into a
+// single JSON string, rather than as arrays of JSON numbers.
+
+// packInts encodes a list of integers as a base64 string of varints.
+func packInts(values []int64) string {
+
+	buffer := make([]byte, 0, len(values)*2)
+	scratch := make([]byte, binary.MaxVarintLen64)
+
+	for _, value := range values {
+		n := binary.PutVarint(scratch, value)
+		buffer = append(buffer, scratch[:n]...)
+	}
+
+	return base64.StdEncoding.EncodeToString(buffer)
+
+}
+
+// unpackInts decodes a list of integers encoded with packInts.
+func unpackInts(packed string) ([]int64, error) {
+
+	buffer, err := base64.StdEncoding.DecodeString(packed)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []int64{}
+
+	for len(buffer) > 0 {
+		value, n := binary.Varint(buffer)
+		if n <= 0 {
+			return nil, fmt.Errorf("malformed packed data")
+		}
+		values = append(values, value)
+		buffer = buffer[n:]
+	}
+
+	return values, nil
+
+}
+
+// packCells run-length encodes a grid of cells, row by row, as pairs of run length and value. Maps are mostly made of
+// large areas of the same tile (usually empty), so this is far smaller than storing each cell.
+func packCells(cells [][]int32) string {
+
+	runs := []int64{}
+	count := int64(0)
+	current := int32(0)
+
+	for _, row := range cells {
+		for _, value := range row {
+			if count > 0 && value != current {
+				runs = append(runs, count, int64(current))
+				count = 0
+			}
+			current = value
+			count++
+		}
+	}
+
+	if count > 0 {
+		runs = append(runs, count, int64(current))
+	}
+
+	return packInts(runs)
+
+}
+
+// maxPackedCells is the largest width or height of a grid of cells that unpackCells will decode; Maps are far smaller than
+// this, so anything larger is malformed (or malicious) data that would otherwise be allocated in full.
+const maxPackedCells = 1024
+
+// unpackCells decodes cells encoded with packCells into a grid of the given size. The runs must cover the grid exactly.
+func unpackCells(packed string, width, height int) ([][]int32, error) {
+
+	if width < 0 || height < 0 || width > maxPackedCells || height > maxPackedCells {
+		return nil, fmt.Errorf("invalid cell data size %dx%d", width, height)
+	}
+
+	runs, err := unpackInts(packed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runs)%2 != 0 {
+		return nil, fmt.Errorf("malformed cell data")
+	}
+
+	total := int64(0)
+
+	for r := 0; r < len(runs); r += 2 {
+		if runs[r] <= 0 || runs[r+1] < math.MinInt32 || runs[r+1] > math.MaxInt32 {
+			return nil, fmt.Errorf("malformed cell data")
+		}
+		total += runs[r]
+		if total > int64(width*height) {
+			break
+		}
+	}
+
+	if total != int64(width*height) {
+		return nil, fmt.Errorf("cell data covers %d cells, rather than %dx%d", total, width, height)
+	}
+
+	cells := make([][]int32, height)
+	for y := range cells {
+		cells[y] = make([]int32, width)
+	}
+
+	i := 0
+
+	for r := 0; r < len(runs); r += 2 {
+		for n := int64(0); n < runs[r]; n++ {
+			cells[i/width][i%width] = int32(runs[r+1])
+			i++
+		}
+	}
+
+	return cells, nil
+
+}
+
+// pointPrecision is the number of steps per pixel that packed points are rounded to.
+const pointPrecision = 8
+
+// packPoints encodes a list of points, rounded to an eighth of a pixel, as the differences between each point and the last;
+// as consecutive points in a path are close together, most coordinates then fit in a single byte.
+func packPoints(points []rl.Vector2) string {
+
+	values := make([]int64, 0, len(points)*2)
+	prevX, prevY := int64(0), int64(0)
+
+	for _, point := range points {
+		x := int64(math.Round(float64(point.X * pointPrecision)))
+		y := int64(math.Round(float64(point.Y * pointPrecision)))
+		values = append(values, x-prevX, y-prevY)
+		prevX, prevY = x, y
+	}
+
+	return packInts(values)
+
+}
+
+// unpackPoints decodes a list of points encoded with packPoints.
+func unpackPoints(packed string) ([]rl.Vector2, error) {
+
+	values, err := unpackInts(packed)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]rl.Vector2, 0, len(values)/2)
+	x, y := int64(0), int64(0)
+
+	for i := 0; i+1 < len(values); i += 2 {
+		x += values[i]
+		y += values[i+1]
+		points = append(points, rl.Vector2{float32(x) / pointPrecision, float32(y) / pointPrecision})
+	}
+
+	return points, nil
+
+}
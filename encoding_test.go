@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/base64"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
+)
+
+func TestPackInts(t *testing.T) {
+
+	values := []int64{0, 1, -1, 63, -64, 64, 300, -300, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64}
+
+	unpacked, err := unpackInts(packInts(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(unpacked, values) {
+		t.Fatalf("expected %v, got %v", values, unpacked)
+	}
+
+	if _, err := unpackInts("not base64!"); err == nil {
+		t.Error("expected invalid base64 to fail to unpack")
+	}
+
+	// A varint cut off partway through is malformed.
+	if _, err := unpackInts(base64.StdEncoding.EncodeToString([]byte{0x80})); err == nil {
+		t.Error("expected a truncated varint to fail to unpack")
+	}
+
+}
+
+func TestPackCells(t *testing.T) {
+
+	cells := [][]int32{
+		{0, 0, 0, 0, 5},
+		{5, 5, 1, 0, 0},
+		{0, 0, 0, 0, 0},
+		{-3, 0, 0, math.MaxInt32, math.MinInt32},
+	}
+
+	packed := packCells(cells)
+
+	unpacked, err := unpackCells(packed, 5, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(unpacked, cells) {
+		t.Fatalf("expected %v, got %v", cells, unpacked)
+	}
+
+	// The runs have to cover the grid exactly, and the grid has to be a sensible size.
+	invalid := []struct {
+		packed        string
+		width, height int
+	}{
+		{packed, 5, 3},
+		{packed, 5, 5},
+		{packed, -5, -4},
+		{packInts([]int64{maxPackedCells * maxPackedCells * 4, 0}), maxPackedCells * 2, maxPackedCells * 2},
+		{packInts([]int64{20}), 5, 4},
+		{packInts([]int64{-20, 1, 40, 0}), 5, 4},
+		{packInts([]int64{20, math.MaxInt32 + 1}), 5, 4},
+	}
+
+	for _, data := range invalid {
+		if _, err := unpackCells(data.packed, data.width, data.height); err == nil {
+			t.Errorf("expected %v (%dx%d) to fail to unpack", data.packed, data.width, data.height)
+		}
+	}
+
+}
+
+func TestPackPoints(t *testing.T) {
+
+	points := []rl.Vector2{{0, 0}, {0.125, 0.5}, {-10, 300.75}, {511.875, -0.25}}
+
+	unpacked, err := unpackPoints(packPoints(points))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(unpacked, points) {
+		t.Fatalf("expected %v, got %v", points, unpacked)
+	}
+
+	// Points are rounded to the nearest eighth of a pixel.
+	unpacked, _ = unpackPoints(packPoints([]rl.Vector2{{1.1, 2.2}}))
+
+	if unpacked[0] != (rl.Vector2{1.125, 2.25}) {
+		t.Errorf("expected the point to be rounded to 1.125, 2.25, got %v", unpacked[0])
+	}
+
+}
+
+func TestMapFormats(t *testing.T) {
+
+	formats := map[string]string{
+		"version 0": `{"MapData":[[0,1],[1,0]]}`,
+		"version 1": `{"MapLayers":[{"Name":"Walls","Visible":true,"Data":[[0,1],[1,0]]}]}`,
+		"version 2": `{"MapFormat":2,"MapLayers":[{"Name":"Walls","Visible":true,"Width":2,"Height":2,"Cells":"` + packCells([][]int32{{0, 1}, {1, 0}}) + `"}]}`,
+	}
+
+	for name, data := range formats {
+
+		layers, err := mapLayersFromData(gjson.Parse(data))
+		if err != nil {
+			t.Fatalf("expected a %s Map to load, got %s", name, err.Error())
+		}
+
+		if len(layers) != 1 || !reflect.DeepEqual(layers[0].Data, [][]int32{{0, 1}, {1, 0}}) {
+			t.Errorf("expected a %s Map to load with a single layer of walls, got %+v", name, layers)
+		}
+
+	}
+
+	if _, err := mapLayersFromData(gjson.Parse(`{"MapFormat":3,"MapLayers":[]}`)); err == nil {
+		t.Error("expected a Map saved in a newer format not to load")
+	}
+
+	if _, err := mapLayersFromData(gjson.Parse(`{"MapFormat":2,"MapLayers":[{"Width":2,"Height":2,"Cells":"` + packCells([][]int32{{0, 1}}) + `"}]}`)); err == nil {
+		t.Error("expected a Map layer with too few cells not to load")
+	}
+
+}
+
+func TestWhiteboardFormats(t *testing.T) {
+
+	strokes := []*WhiteboardStroke{{Kind: WhiteboardStrokePath, Points: []rl.Vector2{{1, 2}, {3.5, 4}}, Color: 2, Width: 3}}
+
+	current := &Whiteboard{Strokes: strokes, Inverted: true}
+
+	formats := map[string]string{
+		"version 2": `{"Version":2,"Inverted":true,"Strokes":[{"Kind":"path","Points":[{"X":1,"Y":2},{"X":3.5,"Y":4}],"Color":2,"Width":3}]}`,
+		"version 3": current.Serialize(),
+	}
+
+	for name, data := range formats {
+
+		wb, err := decodeWhiteboard(gjson.Parse(data), false)
+		if err != nil {
+			t.Fatalf("expected a %s Whiteboard to load, got %s", name, err.Error())
+		}
+
+		if wb.Version != WhiteboardVersion || !wb.Inverted || !reflect.DeepEqual(wb.Strokes, strokes) {
+			t.Errorf("expected a %s Whiteboard to load its strokes, got %+v", name, wb)
+		}
+
+	}
+
+	// Version 1 Whiteboards were bitmaps, with each run of set pixels becoming a line.
+	row := base64.StdEncoding.EncodeToString([]byte{0, 1, 1, 1, 0})
+
+	wb, err := decodeWhiteboard(gjson.Parse(`["`+row+`"]`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(wb.Strokes) != 1 || wb.Strokes[0].Points[0].X != 1 || wb.Strokes[0].Points[1].X != 4 {
+		t.Errorf("expected the bitmap to load as a single line from 1 to 4, got %+v", wb.Strokes)
+	}
+
+	if _, err := decodeWhiteboard(gjson.Parse(`{"Version":4,"Strokes":[]}`), false); err == nil {
+		t.Error("expected a Whiteboard saved in a newer format not to load")
+	}
+
+}
+
+// BenchmarkTaskSerialize serializes large Map and Whiteboard Tasks, as happens whenever an undo state is captured while
+// drawing on them.
+func BenchmarkTaskSerialize(b *testing.B) {
+
+	project := newTestProject()
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	random := rand.New(rand.NewSource(1))
+
+	mapTask := newTestTask(board, TASK_TYPE_MAP, "Map", 0, 0, 32, 33)
+	mapTask.MapImage = &MapImage{Task: mapTask, cellWidth: 32, cellHeight: 32}
+
+	for i := 0; i < 4; i++ {
+		mapTask.MapImage.AddLayer()
+		for y := 0; y < 32; y++ {
+			for x := 0; x < 32; x++ {
+				if random.Intn(4) == 0 {
+					mapTask.MapImage.Data[y][x] = int32(random.Intn(64))
+				}
+			}
+		}
+	}
+
+	whiteboardTask := newTestTask(board, TASK_TYPE_WHITEBOARD, "Whiteboard", 0, 40, 32, 33)
+	whiteboardTask.Whiteboard = NewWhiteboard(whiteboardTask)
+	whiteboardTask.Whiteboard.Resize(512, 512)
+
+	for i := 0; i < 200; i++ {
+		stroke := &WhiteboardStroke{Kind: WhiteboardStrokePath, Width: 3}
+		point := rl.Vector2{random.Float32() * 512, random.Float32() * 512}
+		for p := 0; p < 100; p++ {
+			point = rl.Vector2{point.X + random.Float32()*4 - 2, point.Y + random.Float32()*4 - 2}
+			stroke.Points = append(stroke.Points, point)
+		}
+		whiteboardTask.Whiteboard.Strokes = append(whiteboardTask.Whiteboard.Strokes, stroke)
+	}
+
+	b.Run("Map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			// The Map's serialized layers are cached until it changes, so it's changed each time, as it is when drawing.
+			mapTask.MapImage.Changed = true
+			mapTask.Serialize()
+		}
+	})
+
+	b.Run("Whiteboard", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			whiteboardTask.Serialize()
+		}
+	})
+
+}
@@ -0,0 +1,911 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/goware/urlx"
+	"github.com/ncruces/zenity"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Exporting draws Whiteboard and Map Tasks (or a whole Board) to PNG or SVG files. It works from the plan's serialized
+// data rather than the render textures the Tasks draw with, so the same code can run from the command line without a window.
+
+const (
+	exportCapButt = iota
+	exportCapRound
+	exportCapSquare
+)
+
+// ExportCanvas is a surface that exported plan contents are drawn onto. Positions are in world coordinates; the
+// canvas translates them so the area being exported starts at its top-left corner.
+type ExportCanvas interface {
+	FillRect(rect rl.Rectangle, color rl.Color)
+	StrokeRect(rect rl.Rectangle, width float32, color rl.Color)
+	Polyline(points []rl.Vector2, width float32, color rl.Color, capStyle int)
+	Text(pos rl.Vector2, text string, size float32, color rl.Color)
+	Tile(dst rl.Rectangle, tileset image.Image, src image.Rectangle)
+	Encode() ([]byte, error)
+}
+
+// NewExportCanvas returns a canvas covering the area given, choosing the format from the file extension of the path.
+func NewExportCanvas(path string, area rl.Rectangle, scale float32) (ExportCanvas, error) {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return NewPNGCanvas(area, scale), nil
+	case ".svg":
+		return NewSVGCanvas(area), nil
+	}
+
+	return nil, fmt.Errorf("unsupported export format %s; use .png or .svg", filepath.Ext(path))
+
+}
+
+type PNGCanvas struct {
+	Image *image.RGBA
+	Area  rl.Rectangle
+	Scale float32
+}
+
+func NewPNGCanvas(area rl.Rectangle, scale float32) *PNGCanvas {
+
+	if scale <= 0 {
+		scale = 1
+	}
+
+	w := int(math.Ceil(float64(area.Width * scale)))
+	h := int(math.Ceil(float64(area.Height * scale)))
+
+	return &PNGCanvas{
+		Image: image.NewRGBA(image.Rect(0, 0, w, h)),
+		Area:  area,
+		Scale: scale,
+	}
+
+}
+
+func (canvas *PNGCanvas) toCanvas(point rl.Vector2) rl.Vector2 {
+	return rl.Vector2{(point.X - canvas.Area.X) * canvas.Scale, (point.Y - canvas.Area.Y) * canvas.Scale}
+}
+
+// blend mixes the color given into the pixel at x, y, with coverage being how much of the pixel the shape covers.
+func (canvas *PNGCanvas) blend(x, y int, c rl.Color, coverage float32) {
+
+	if !(image.Point{x, y}.In(canvas.Image.Rect)) || coverage <= 0 {
+		return
+	}
+
+	alpha := float32(c.A) / 255 * float32(math.Min(1, float64(coverage)))
+	dst := canvas.Image.RGBAAt(x, y)
+
+	mix := func(src, dst uint8) uint8 {
+		return uint8(float32(src)*alpha + float32(dst)*(1-alpha))
+	}
+
+	canvas.Image.SetRGBA(x, y, color.RGBA{
+		mix(c.R, dst.R),
+		mix(c.G, dst.G),
+		mix(c.B, dst.B),
+		uint8(float32(255)*alpha + float32(dst.A)*(1-alpha)),
+	})
+
+}
+
+func (canvas *PNGCanvas) FillRect(rect rl.Rectangle, c rl.Color) {
+
+	start := canvas.toCanvas(rl.Vector2{rect.X, rect.Y})
+	end := canvas.toCanvas(rl.Vector2{rect.X + rect.Width, rect.Y + rect.Height})
+
+	for y := int(math.Floor(float64(start.Y))); y < int(math.Ceil(float64(end.Y))); y++ {
+		for x := int(math.Floor(float64(start.X))); x < int(math.Ceil(float64(end.X))); x++ {
+			// Partially-covered edge pixels are blended by how much of them the rectangle covers.
+			coverX := math.Min(float64(x+1), float64(end.X)) - math.Max(float64(x), float64(start.X))
+			coverY := math.Min(float64(y+1), float64(end.Y)) - math.Max(float64(y), float64(start.Y))
+			canvas.blend(x, y, c, float32(coverX*coverY))
+		}
+	}
+
+}
+
+func (canvas *PNGCanvas) StrokeRect(rect rl.Rectangle, width float32, c rl.Color) {
+	canvas.Polyline([]rl.Vector2{
+		{rect.X, rect.Y},
+		{rect.X + rect.Width, rect.Y},
+		{rect.X + rect.Width, rect.Y + rect.Height},
+		{rect.X, rect.Y + rect.Height},
+		{rect.X, rect.Y},
+	}, width, c, exportCapSquare)
+}
+
+// Polyline rasterizes a thick, antialiased line through the points given. Each pixel is covered according to its distance
+// from the nearest segment, so the result is crisp at any scale. Only the pixels around each segment are checked against it,
+// so long strokes across a large Whiteboard don't check every pixel against every segment.
+func (canvas *PNGCanvas) Polyline(points []rl.Vector2, width float32, c rl.Color, capStyle int) {
+
+	if len(points) == 0 {
+		return
+	}
+
+	scaled := []rl.Vector2{}
+	for _, p := range points {
+		scaled = append(scaled, canvas.toCanvas(p))
+	}
+
+	if len(scaled) == 1 {
+		scaled = append(scaled, scaled[0])
+		capStyle = exportCapRound
+	}
+
+	half := width * canvas.Scale / 2
+
+	// Where segments meet, pixels are covered by the nearest one, rather than blended once for each segment.
+	coverage := map[image.Point]float32{}
+
+	for i := 0; i < len(scaled)-1; i++ {
+
+		start, end := scaled[i], scaled[i+1]
+
+		x0 := int(math.Floor(math.Min(float64(start.X), float64(end.X)) - float64(half) - 1))
+		y0 := int(math.Floor(math.Min(float64(start.Y), float64(end.Y)) - float64(half) - 1))
+		x1 := int(math.Ceil(math.Max(float64(start.X), float64(end.X)) + float64(half) + 1))
+		y1 := int(math.Ceil(math.Max(float64(start.Y), float64(end.Y)) + float64(half) + 1))
+
+		for y := y0; y <= y1; y++ {
+
+			for x := x0; x <= x1; x++ {
+
+				pixel := rl.Vector2{float32(x) + 0.5, float32(y) + 0.5}
+
+				if cov := segmentCoverage(pixel, start, end, half, capStyle); cov > coverage[image.Point{x, y}] {
+					coverage[image.Point{x, y}] = cov
+				}
+
+			}
+
+		}
+
+	}
+
+	for point, cov := range coverage {
+		canvas.blend(point.X, point.Y, c, cov)
+	}
+
+}
+
+// segmentCoverage returns how much of the pixel centered at point is covered by a line segment of the given half-width.
+func segmentCoverage(point, start, end rl.Vector2, half float32, capStyle int) float32 {
+
+	diff := rl.Vector2Subtract(end, start)
+	length := rl.Vector2Length(diff)
+
+	if length == 0 {
+		return clampCoverage(half + 0.5 - rl.Vector2Distance(point, start))
+	}
+
+	dir := rl.Vector2{diff.X / length, diff.Y / length}
+	rel := rl.Vector2Subtract(point, start)
+
+	along := rel.X*dir.X + rel.Y*dir.Y
+	across := float32(math.Abs(float64(rel.X*dir.Y - rel.Y*dir.X)))
+
+	if capStyle == exportCapRound {
+		t := float32(math.Max(0, math.Min(float64(length), float64(along))))
+		closest := rl.Vector2{start.X + dir.X*t, start.Y + dir.Y*t}
+		return clampCoverage(half + 0.5 - rl.Vector2Distance(point, closest))
+	}
+
+	extend := float32(0)
+	if capStyle == exportCapSquare {
+		extend = half
+	}
+
+	alongCoverage := clampCoverage(float32(math.Min(float64(along+extend), float64(length+extend-along))) + 0.5)
+
+	return clampCoverage(half+0.5-across) * alongCoverage
+
+}
+
+func clampCoverage(value float32) float32 {
+	return float32(math.Max(0, math.Min(1, float64(value))))
+}
+
+// Text draws text using a built-in bitmap font, as the font MasterPlan uses can only be loaded with a window open.
+func (canvas *PNGCanvas) Text(pos rl.Vector2, text string, size float32, c rl.Color) {
+
+	face := basicfont.Face7x13
+	lineHeight := face.Metrics().Height.Ceil()
+	textScale := size / float32(lineHeight) * canvas.Scale
+
+	for lineIndex, line := range strings.Split(text, "\n") {
+
+		if line == "" {
+			continue
+		}
+
+		mask := image.NewAlpha(image.Rect(0, 0, xfont.MeasureString(face, line).Ceil(), lineHeight))
+
+		drawer := xfont.Drawer{
+			Dst:  mask,
+			Src:  image.Opaque,
+			Face: face,
+			Dot:  fixed.P(0, face.Metrics().Ascent.Ceil()),
+		}
+		drawer.DrawString(line)
+
+		origin := canvas.toCanvas(rl.Vector2{pos.X, pos.Y + float32(lineIndex)*size})
+		w := int(math.Ceil(float64(float32(mask.Rect.Dx()) * textScale)))
+		h := int(math.Ceil(float64(float32(mask.Rect.Dy()) * textScale)))
+
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				alpha := mask.AlphaAt(int(float32(x)/textScale), int(float32(y)/textScale)).A
+				if alpha > 0 {
+					canvas.blend(int(origin.X)+x, int(origin.Y)+y, c, float32(alpha)/255)
+				}
+			}
+		}
+
+	}
+
+}
+
+// Tile draws the src region of the tileset into dst with nearest-neighbour scaling, so pixel art stays crisp.
+func (canvas *PNGCanvas) Tile(dst rl.Rectangle, tileset image.Image, src image.Rectangle) {
+
+	start := canvas.toCanvas(rl.Vector2{dst.X, dst.Y})
+	end := canvas.toCanvas(rl.Vector2{dst.X + dst.Width, dst.Y + dst.Height})
+	w, h := end.X-start.X, end.Y-start.Y
+
+	if w <= 0 || h <= 0 || src.Empty() {
+		return
+	}
+
+	for y := int(math.Floor(float64(start.Y))); y < int(math.Ceil(float64(end.Y))); y++ {
+		for x := int(math.Floor(float64(start.X))); x < int(math.Ceil(float64(end.X))); x++ {
+
+			sx := src.Min.X + int((float32(x)+0.5-start.X)/w*float32(src.Dx()))
+			sy := src.Min.Y + int((float32(y)+0.5-start.Y)/h*float32(src.Dy()))
+
+			if !(image.Point{sx, sy}.In(src)) {
+				continue
+			}
+
+			c := color.NRGBAModel.Convert(tileset.At(sx, sy)).(color.NRGBA)
+			canvas.blend(x, y, rl.Color{c.R, c.G, c.B, c.A}, 1)
+
+		}
+	}
+
+}
+
+func (canvas *PNGCanvas) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := png.Encode(&buffer, canvas.Image)
+	return buffer.Bytes(), err
+}
+
+type SVGCanvas struct {
+	Area     rl.Rectangle
+	elements strings.Builder
+	tilesets map[image.Image]string
+}
+
+func NewSVGCanvas(area rl.Rectangle) *SVGCanvas {
+	return &SVGCanvas{Area: area, tilesets: map[image.Image]string{}}
+}
+
+func svgColor(c rl.Color, attribute string) string {
+	return fmt.Sprintf(`%s="rgb(%d,%d,%d)" %s-opacity="%.3g"`, attribute, c.R, c.G, c.B, attribute, float32(c.A)/255)
+}
+
+func (canvas *SVGCanvas) FillRect(rect rl.Rectangle, c rl.Color) {
+	fmt.Fprintf(&canvas.elements, `<rect x="%g" y="%g" width="%g" height="%g" %s/>`+"\n",
+		rect.X-canvas.Area.X, rect.Y-canvas.Area.Y, rect.Width, rect.Height, svgColor(c, "fill"))
+}
+
+func (canvas *SVGCanvas) StrokeRect(rect rl.Rectangle, width float32, c rl.Color) {
+	fmt.Fprintf(&canvas.elements, `<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke-width="%g" %s/>`+"\n",
+		rect.X-canvas.Area.X, rect.Y-canvas.Area.Y, rect.Width, rect.Height, width, svgColor(c, "stroke"))
+}
+
+func (canvas *SVGCanvas) Polyline(points []rl.Vector2, width float32, c rl.Color, capStyle int) {
+
+	if len(points) == 0 {
+		return
+	}
+
+	if len(points) == 1 {
+		fmt.Fprintf(&canvas.elements, `<circle cx="%g" cy="%g" r="%g" %s/>`+"\n",
+			points[0].X-canvas.Area.X, points[0].Y-canvas.Area.Y, width/2, svgColor(c, "fill"))
+		return
+	}
+
+	coords := []string{}
+	for _, p := range points {
+		coords = append(coords, fmt.Sprintf("%g,%g", p.X-canvas.Area.X, p.Y-canvas.Area.Y))
+	}
+
+	capName, joinName := "butt", "miter"
+	if capStyle == exportCapRound {
+		capName, joinName = "round", "round"
+	} else if capStyle == exportCapSquare {
+		capName = "square"
+	}
+
+	fmt.Fprintf(&canvas.elements, `<polyline points="%s" fill="none" stroke-width="%g" stroke-linecap="%s" stroke-linejoin="%s" %s/>`+"\n",
+		strings.Join(coords, " "), width, capName, joinName, svgColor(c, "stroke"))
+
+}
+
+func (canvas *SVGCanvas) Text(pos rl.Vector2, text string, size float32, c rl.Color) {
+	for i, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(&canvas.elements, `<text x="%g" y="%g" font-family="sans-serif" font-size="%g" dominant-baseline="hanging" %s>%s</text>`+"\n",
+			pos.X-canvas.Area.X, pos.Y-canvas.Area.Y+float32(i)*size, size, svgColor(c, "fill"), html.EscapeString(line))
+	}
+}
+
+// Tile embeds the tileset into the SVG the first time it's used, and then draws tiles by referencing it through a nested
+// viewport that crops it to the tile.
+func (canvas *SVGCanvas) Tile(dst rl.Rectangle, tileset image.Image, src image.Rectangle) {
+
+	id, exists := canvas.tilesets[tileset]
+
+	if !exists {
+
+		buffer := bytes.Buffer{}
+		if err := png.Encode(&buffer, tileset); err != nil {
+			return
+		}
+
+		id = fmt.Sprintf("tileset%d", len(canvas.tilesets))
+		canvas.tilesets[tileset] = id
+
+		bounds := tileset.Bounds()
+		fmt.Fprintf(&canvas.elements, `<defs><image id="%s" width="%d" height="%d" image-rendering="pixelated" href="data:image/png;base64,%s"/></defs>`+"\n",
+			id, bounds.Dx(), bounds.Dy(), base64.StdEncoding.EncodeToString(buffer.Bytes()))
+
+	}
+
+	fmt.Fprintf(&canvas.elements, `<svg x="%g" y="%g" width="%g" height="%g" viewBox="%d %d %d %d" preserveAspectRatio="none"><use href="#%s"/></svg>`+"\n",
+		dst.X-canvas.Area.X, dst.Y-canvas.Area.Y, dst.Width, dst.Height, src.Min.X, src.Min.Y, src.Dx(), src.Dy(), id)
+
+}
+
+func (canvas *SVGCanvas) Encode() ([]byte, error) {
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n",
+		canvas.Area.Width, canvas.Area.Height, canvas.Area.Width, canvas.Area.Height)
+	return []byte(svg + canvas.elements.String() + "</svg>\n"), nil
+}
+
+// exportContentArea returns the world-space rectangle of a Whiteboard or Map Task's contents (that is, below its header).
+func exportContentArea(taskData gjson.Result, gridSize float32) rl.Rectangle {
+	return rl.Rectangle{
+		float32(taskData.Get(`Position\.X`).Float()),
+		float32(taskData.Get(`Position\.Y`).Float()) + 16,
+		float32(taskData.Get(`ImageDisplaySize\.X`).Float()),
+		float32(taskData.Get(`ImageDisplaySize\.Y`).Float()) - gridSize,
+	}
+}
+
+// exportWhiteboard draws a serialized Whiteboard Task's contents onto the canvas.
+func exportWhiteboard(canvas ExportCanvas, taskData gjson.Result, gridSize float32, theme map[string]rl.Color) error {
+
+	area := exportContentArea(taskData, gridSize)
+
+	wb, err := decodeWhiteboard(taskData.Get(`Whiteboard`), false)
+	if err != nil {
+		return err
+	}
+
+	// Colors are picked as Whiteboard.StrokeColor() and BackgroundColor() pick them, so exported colors match what's drawn in
+	// MasterPlan, but from the theme given.
+	ink, paper := theme[GUI_FONT_COLOR], theme[GUI_INSIDE]
+	if wb.Inverted {
+		ink, paper = paper, ink
+	}
+
+	canvas.FillRect(area, paper)
+
+	origin := rl.Vector2{area.X, area.Y}
+
+	for _, stroke := range wb.Strokes {
+
+		color := ink
+		if stroke.Color > 0 && stroke.Color < len(whiteboardPalette) {
+			color = whiteboardPalette[stroke.Color]
+		}
+
+		if stroke.Kind == WhiteboardStrokeText {
+			if len(stroke.Points) > 0 {
+				canvas.Text(rl.Vector2Add(origin, stroke.Points[0]), stroke.Text, stroke.Width, color)
+			}
+			continue
+		}
+
+		points := []rl.Vector2{}
+		for _, p := range stroke.Segments() {
+			points = append(points, rl.Vector2Add(origin, p))
+		}
+
+		capStyle := exportCapRound
+		if stroke.Kind == WhiteboardStrokeLine {
+			capStyle = exportCapButt
+		} else if stroke.Kind == WhiteboardStrokeRectangle {
+			capStyle = exportCapSquare
+		}
+
+		canvas.Polyline(points, stroke.Width, color, capStyle)
+
+	}
+
+	return nil
+
+}
+
+// exportMap draws a serialized Map Task's contents onto the canvas.
+func exportMap(canvas ExportCanvas, taskData gjson.Result, gridSize float32, theme map[string]rl.Color) error {
+
+	area := exportContentArea(taskData, gridSize)
+
+	canvas.FillRect(area, theme[GUI_INSIDE])
+
+	gridColor := theme[GUI_OUTLINE]
+	gridColor.A = 64
+
+	tileset, tileSize, err := loadExportTileset(taskData)
+	if err != nil {
+		return fmt.Errorf("couldn't load the Map's tileset: %s", err.Error())
+	}
+
+	cellsX := int(area.Width / gridSize)
+	cellsY := int(area.Height / gridSize)
+
+	layers, err := mapLayersFromData(taskData)
+	if err != nil {
+		return err
+	}
+
+	for _, layer := range layers {
+
+		if !layer.Visible {
+			continue
+		}
+
+		for y, row := range layer.Data {
+			for x, value := range row {
+
+				if value <= 0 || x >= cellsX || y >= cellsY {
+					continue
+				}
+
+				cell := rl.Rectangle{area.X + float32(x)*gridSize, area.Y + float32(y)*gridSize, gridSize, gridSize}
+
+				if tileset != nil {
+					canvas.Tile(cell, tileset, tileRect(tileset, tileSize, value))
+				} else {
+					canvas.FillRect(cell, theme[GUI_OUTLINE_HIGHLIGHTED])
+				}
+
+			}
+		}
+
+	}
+
+	for y := 0; y < cellsY; y++ {
+		for x := 0; x < cellsX; x++ {
+			canvas.StrokeRect(rl.Rectangle{area.X + float32(x)*gridSize, area.Y + float32(y)*gridSize, gridSize, gridSize}, 1, gridColor)
+		}
+	}
+
+	return nil
+
+}
+
+// loadExportTileset decodes the tileset image of a serialized Map Task, returning nil if the Map doesn't use one. Tilesets
+// that are still URLs (say, because we're exporting from the command line, and they haven't been downloaded) can't be
+// loaded either, so their tiles are drawn as walls, as they would be in MasterPlan.
+func loadExportTileset(taskData gjson.Result) (image.Image, int, error) {
+
+	path := taskData.Get(`MapTileset`).String()
+	tileSize := int(taskData.Get(`MapTileSize`).Int())
+
+	if path == "" || tileSize <= 0 {
+		return nil, 0, nil
+	}
+
+	if url, err := urlx.Parse(path); err == nil && url.Host != "" && url.Scheme != "" {
+		return nil, 0, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer file.Close()
+
+	tileset, _, err := image.Decode(file)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tileset, tileSize, nil
+
+}
+
+// tileRect returns the region of the tileset that the tile value given (the tile's index plus one) covers.
+func tileRect(tileset image.Image, tileSize int, value int32) image.Rectangle {
+
+	bounds := tileset.Bounds()
+	columns := bounds.Dx() / tileSize
+	if columns <= 0 {
+		columns = 1
+	}
+	index := int(value - 1)
+	min := bounds.Min.Add(image.Point{(index % columns) * tileSize, (index / columns) * tileSize})
+	return image.Rectangle{min, min.Add(image.Point{tileSize, tileSize})}
+
+}
+
+// exportTaskContents draws a serialized Task onto the canvas; Whiteboards and Maps draw their contents, while other Tasks
+// are drawn as a box with their text.
+func exportTaskContents(canvas ExportCanvas, taskData gjson.Result, taskType int, gridSize float32, theme map[string]rl.Color) error {
+
+	ts := newTaskSummary(taskData, taskType, gridSize)
+	rect := rl.Rectangle{ts.X, ts.Y, ts.width, ts.height}
+
+	if taskType == TASK_TYPE_LINE {
+
+		center := rl.Vector2{ts.X + gridSize/2, ts.Y + gridSize/2}
+		endings := taskData.Get(`LineEndings`).Array()
+
+		for i := 0; i+1 < len(endings); i += 2 {
+			end := rl.Vector2{float32(endings[i].Float()) + gridSize/2, float32(endings[i+1].Float()) + gridSize/2}
+			canvas.Polyline([]rl.Vector2{center, end}, 2, theme[GUI_FONT_COLOR], exportCapRound)
+		}
+
+		return nil
+
+	}
+
+	fill := theme[GUI_INSIDE]
+	if ts.IsCompletable() && ts.IsComplete() {
+		fill = theme[GUI_INSIDE_HIGHLIGHTED]
+	} else if taskType == TASK_TYPE_NOTE {
+		fill = theme[GUI_NOTE_COLOR]
+	}
+
+	canvas.FillRect(rect, fill)
+
+	var err error
+
+	if taskType == TASK_TYPE_WHITEBOARD {
+		err = exportWhiteboard(canvas, taskData, gridSize, theme)
+	} else if taskType == TASK_TYPE_MAP {
+		err = exportMap(canvas, taskData, gridSize, theme)
+	} else {
+		text := ts.Description
+		if taskType == TASK_TYPE_TIMER || taskType == TASK_TYPE_IMAGE || taskType == TASK_TYPE_MEDIA || taskType == TASK_TYPE_DOCUMENT {
+			text = ts.Title
+		}
+		if badge := badgeText(parsePriority(ts.Priority), ts.Assignee); badge != "" {
+			text = "(" + badge + ") " + text
+		}
+		canvas.Text(rl.Vector2{rect.X + gridSize/2, rect.Y + 2}, text, gridSize-4, theme[GUI_FONT_COLOR])
+	}
+
+	canvas.StrokeRect(rect, 1, theme[GUI_OUTLINE])
+
+	return err
+
+}
+
+// ExportTask writes a single serialized Whiteboard or Map Task to an image file at the given path, drawn with the theme
+// colors given.
+func ExportTask(taskData gjson.Result, gridSize float32, path string, scale float32, theme map[string]rl.Color) error {
+
+	taskType, ok := ParseTaskType(taskData)
+
+	if !ok || (taskType != TASK_TYPE_WHITEBOARD && taskType != TASK_TYPE_MAP) {
+		return fmt.Errorf("only Whiteboard and Map Tasks can be exported")
+	}
+
+	canvas, err := NewExportCanvas(path, exportContentArea(taskData, gridSize), scale)
+	if err != nil {
+		return err
+	}
+
+	if taskType == TASK_TYPE_WHITEBOARD {
+		err = exportWhiteboard(canvas, taskData, gridSize, theme)
+	} else {
+		err = exportMap(canvas, taskData, gridSize, theme)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeExport(canvas, path)
+
+}
+
+// ExportBoard writes every Task on a Board of the serialized plan given to an image file at the given path, covering the
+// area the Tasks take up, drawn with the theme colors given.
+func ExportBoard(planData gjson.Result, boardIndex int, path string, scale float32, theme map[string]rl.Color) error {
+
+	gridSize := float32(16)
+	if planData.Get(`GridSize`).Exists() {
+		gridSize = float32(planData.Get(`GridSize`).Int())
+	}
+
+	type exportedTask struct {
+		Data gjson.Result
+		Type int
+	}
+
+	tasks := []exportedTask{}
+	var area rl.Rectangle
+
+	for _, taskData := range planData.Get(`Tasks`).Array() {
+
+		taskType, ok := ParseTaskType(taskData)
+
+		if !ok || int(taskData.Get(`BoardIndex`).Int()) != boardIndex {
+			continue
+		}
+
+		ts := newTaskSummary(taskData, taskType, gridSize)
+		rect := rl.Rectangle{ts.X, ts.Y, ts.width, ts.height}
+
+		if len(tasks) == 0 {
+			area = rect
+		} else {
+			area = exportUnion(area, rect)
+		}
+
+		tasks = append(tasks, exportedTask{taskData, taskType})
+
+	}
+
+	if len(tasks) == 0 {
+		return fmt.Errorf("board %d has no Tasks to export", boardIndex+1)
+	}
+
+	area.X -= gridSize
+	area.Y -= gridSize
+	area.Width += gridSize * 2
+	area.Height += gridSize * 2
+
+	canvas, err := NewExportCanvas(path, area, scale)
+	if err != nil {
+		return err
+	}
+
+	canvas.FillRect(area, theme[GUI_INSIDE_DISABLED])
+
+	// Lines are drawn first, so that they sit underneath the Tasks they connect, as they do on the Board.
+	for _, lines := range []bool{true, false} {
+		for _, task := range tasks {
+			if (task.Type == TASK_TYPE_LINE) == lines {
+				if err := exportTaskContents(canvas, task.Data, task.Type, gridSize, theme); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return writeExport(canvas, path)
+
+}
+
+func exportUnion(a, b rl.Rectangle) rl.Rectangle {
+	x := float32(math.Min(float64(a.X), float64(b.X)))
+	y := float32(math.Min(float64(a.Y), float64(b.Y)))
+	x2 := float32(math.Max(float64(a.X+a.Width), float64(b.X+b.Width)))
+	y2 := float32(math.Max(float64(a.Y+a.Height), float64(b.Y+b.Height)))
+	return rl.Rectangle{x, y, x2 - x, y2 - y}
+}
+
+func writeExport(canvas ExportCanvas, path string) error {
+
+	data, err := canvas.Encode()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+
+}
+
+// selectExportPath asks the user where to export an image to, adding a .png extension if they didn't choose a format.
+func selectExportPath(title string) string {
+
+	path, err := zenity.SelectFileSave(
+		zenity.Title(title),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilters{
+			{Name: "PNG Image", Patterns: []string{"*.png"}},
+			{Name: "SVG Image", Patterns: []string{"*.svg"}},
+		})
+
+	if err != nil || path == "" {
+		return ""
+	}
+
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".png" && ext != ".svg" {
+		path += ".png"
+	}
+
+	return path
+
+}
+
+// ExportTaskImage exports a Whiteboard or Map Task to an image file of the user's choosing. The Task's data (and the theme's
+// colors) are taken as they are now, but the image is drawn in the background, as large Tasks can take a while to draw at
+// export scale.
+func (project *Project) ExportTaskImage(task *Task) {
+
+	if path := selectExportPath("Select a location to export the Task's image to."); path != "" {
+
+		taskData := gjson.Parse(exportTaskData(task))
+		gridSize := float32(project.GridSize)
+		theme := getThemeColors()
+
+		project.Log("Exporting image to %s...", path)
+
+		go func() {
+			if err := ExportTask(taskData, gridSize, path, 2, theme); err != nil {
+				project.Exported <- fmt.Sprintf("ERROR: Couldn't export image: %s", err.Error())
+			} else {
+				project.Exported <- fmt.Sprintf("Exported image to %s.", path)
+			}
+		}()
+
+	}
+
+}
+
+// ExportBoardImage exports the current Board to an image file of the user's choosing. Like ExportTaskImage(), the Board's
+// Tasks are taken as they are now, and the image is drawn in the background.
+func (project *Project) ExportBoardImage() {
+
+	if path := selectExportPath("Select a location to export the Board's image to."); path != "" {
+
+		board := project.CurrentBoard()
+
+		planData := "{}"
+		planData, _ = sjson.Set(planData, `GridSize`, project.GridSize)
+
+		taskData := []string{}
+		for _, task := range board.Tasks {
+			// Line endings are drawn as part of their Line.
+			if task.Serializable() {
+				data := exportTaskData(task)
+				data, _ = sjson.Set(data, `DisplaySize\.X`, task.DisplaySize.X)
+				data, _ = sjson.Set(data, `DisplaySize\.Y`, task.DisplaySize.Y)
+				taskData = append(taskData, data)
+			}
+		}
+
+		planData, _ = sjson.SetRaw(planData, `Tasks`, "["+strings.Join(taskData, ",")+"]")
+
+		boardData := gjson.Parse(planData)
+		boardIndex := board.Index()
+		theme := getThemeColors()
+
+		project.Log("Exporting Board image to %s...", path)
+
+		go func() {
+			if err := ExportBoard(boardData, boardIndex, path, 1, theme); err != nil {
+				project.Exported <- fmt.Sprintf("ERROR: Couldn't export image: %s", err.Error())
+			} else {
+				project.Exported <- fmt.Sprintf("Exported Board image to %s.", path)
+			}
+		}()
+
+	}
+
+}
+
+func exportPlan(args []string) error {
+
+	usage := fmt.Errorf("usage: masterplan export <plan file> <output .png, .svg, .tmx or .json> [board number] [task x,y] [scale]")
+
+	if len(args) < 2 {
+		return usage
+	}
+
+	planPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	planFile, err := ioutil.ReadFile(planPath)
+	if err != nil {
+		return err
+	}
+
+	planData := resolvePlanMedia(planPath, gjson.ParseBytes(planFile))
+	outputPath := args[1]
+
+	boardIndex := 0
+	if len(args) > 2 {
+		if _, err := fmt.Sscanf(args[2], "%d", &boardIndex); err != nil || boardIndex < 1 {
+			return fmt.Errorf("invalid board number: %s", args[2])
+		}
+		boardIndex--
+	}
+
+	scale := float32(1)
+	if len(args) > 4 {
+		if _, err := fmt.Sscanf(args[4], "%g", &scale); err != nil || scale <= 0 {
+			return fmt.Errorf("invalid scale: %s", args[4])
+		}
+	}
+
+	loadThemes()
+
+	if _, exists := guiColors[programSettings.Theme]; !exists {
+		return fmt.Errorf("couldn't load the color theme %s", programSettings.Theme)
+	}
+
+	if len(args) > 3 {
+
+		var x, y float32
+		if _, err := fmt.Sscanf(args[3], "%g,%g", &x, &y); err != nil {
+			return fmt.Errorf("invalid task position: %s", args[3])
+		}
+
+		gridSize := float32(16)
+		if planData.Get(`GridSize`).Exists() {
+			gridSize = float32(planData.Get(`GridSize`).Int())
+		}
+
+		for _, taskData := range planData.Get(`Tasks`).Array() {
+			if int(taskData.Get(`BoardIndex`).Int()) == boardIndex &&
+				float32(taskData.Get(`Position\.X`).Float()) == x &&
+				float32(taskData.Get(`Position\.Y`).Float()) == y {
+				if isTiledMapPath(outputPath) {
+					err = ExportTiledMap(taskData, gridSize, outputPath)
+				} else {
+					err = ExportTask(taskData, gridSize, outputPath, scale, getThemeColors())
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(terminalOutput, "Exported Task to %s.\n", outputPath)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("no Task at %s on board %d", args[3], boardIndex+1)
+
+	}
+
+	if err := ExportBoard(planData, boardIndex, outputPath, scale, getThemeColors()); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(terminalOutput, "Exported board %d to %s.\n", boardIndex+1, outputPath)
+
+	return nil
+
+}
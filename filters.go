@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Saved filters are named search queries kept in the Project. While one is active, Tasks that don't match it are dimmed
+// (or hidden entirely) on every Board, so a plan can be looked at as, say, just what's overdue, or just what's on the Art
+// Board. Filters are chosen from the dropdown beside the search bar.
+
+type SavedFilter struct {
+	Name  string
+	Query string
+	// Hide hides Tasks that don't match the filter, rather than dimming them.
+	Hide bool
+}
+
+const (
+	filterOptionNone   = "No Filter"
+	filterOptionSave   = "Save Search as Filter..."
+	filterOptionHide   = "Hide Non-Matching Tasks"
+	filterOptionDim    = "Dim Non-Matching Tasks"
+	filterOptionDelete = "Delete Filter"
+)
+
+// SetActiveFilter activates the saved filter at the index given; an index of -1 turns filtering off.
+func (project *Project) SetActiveFilter(index int) {
+
+	project.ActiveFilter = nil
+	project.activeFilterQuery = nil
+
+	if index < 0 || index >= len(project.SavedFilters) {
+		return
+	}
+
+	filter := project.SavedFilters[index]
+
+	query, err := ParseQuery(filter.Query)
+	if err != nil {
+		project.Log("ERROR: Couldn't use filter [%s]: %s", filter.Name, err.Error())
+		return
+	}
+
+	project.ActiveFilter = filter
+	project.activeFilterQuery = query
+
+}
+
+// SaveSearchAsFilter saves the current search as a filter with the name given, and activates it.
+func (project *Project) SaveSearchAsFilter(name string) {
+
+	if _, err := ParseQuery(project.Searchbar.Text()); err != nil {
+		project.Log("ERROR: Couldn't save filter: %s", err.Error())
+		return
+	}
+
+	filter := &SavedFilter{Name: name, Query: project.Searchbar.Text()}
+
+	// Saving a filter with an existing name replaces it
+	replaced := false
+	for i, existing := range project.SavedFilters {
+		if existing.Name == name {
+			filter.Hide = existing.Hide
+			project.SavedFilters[i] = filter
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		project.SavedFilters = append(project.SavedFilters, filter)
+	}
+
+	for i, existing := range project.SavedFilters {
+		if existing == filter {
+			project.SetActiveFilter(i)
+		}
+	}
+
+	project.Modified = true
+	project.Log("Saved filter [%s]: %s", name, filter.Query)
+
+}
+
+// FilterMatches returns if the Task matches the active filter (or if there's no active filter).
+func (project *Project) FilterMatches(task *Task) bool {
+
+	// Lines only connect other Tasks, so they're left alone
+	if project.activeFilterQuery == nil || task.Is(TASK_TYPE_LINE) {
+		return true
+	}
+
+	return project.SearchIndex.FilterMatches(project.activeFilterQuery, task)
+
+}
+
+// FilterHides returns if Tasks that don't match the active filter should be hidden, rather than dimmed.
+func (project *Project) FilterHides() bool {
+	return project.ActiveFilter != nil && project.ActiveFilter.Hide
+}
+
+func (project *Project) serializeFilters(data string) string {
+
+	filters := []map[string]interface{}{}
+
+	for _, filter := range project.SavedFilters {
+		filters = append(filters, map[string]interface{}{"Name": filter.Name, "Query": filter.Query, "Hide": filter.Hide})
+	}
+
+	data, _ = sjson.Set(data, `SavedFilters`, filters)
+
+	if project.ActiveFilter != nil {
+		data, _ = sjson.Set(data, `ActiveFilter`, project.ActiveFilter.Name)
+	}
+
+	return data
+
+}
+
+func (project *Project) loadFilters(data gjson.Result) {
+
+	project.SavedFilters = []*SavedFilter{}
+
+	for _, filterData := range data.Get(`SavedFilters`).Array() {
+		project.SavedFilters = append(project.SavedFilters, &SavedFilter{
+			Name:  filterData.Get(`Name`).String(),
+			Query: filterData.Get(`Query`).String(),
+			Hide:  filterData.Get(`Hide`).Bool(),
+		})
+	}
+
+	project.SetActiveFilter(-1)
+
+	active := data.Get(`ActiveFilter`).String()
+
+	for i, filter := range project.SavedFilters {
+		if filter.Name == active {
+			project.SetActiveFilter(i)
+		}
+	}
+
+}
+
+// UpdateFilterDropdown draws the dropdown to choose and manage saved filters, handling the option chosen.
+func (project *Project) UpdateFilterDropdown(rect rl.Rectangle) {
+
+	dropdown := project.FilterDropdown
+
+	dropdown.Rect = rect
+	dropdown.Name = "Filter"
+	if project.ActiveFilter != nil {
+		dropdown.Name = "Filter: " + project.ActiveFilter.Name
+	}
+
+	dropdown.Options = []string{filterOptionNone}
+	for _, filter := range project.SavedFilters {
+		dropdown.Options = append(dropdown.Options, filter.Name)
+	}
+
+	if project.Searchbar.Text() != "" {
+		dropdown.Options = append(dropdown.Options, filterOptionSave)
+	}
+
+	if project.ActiveFilter != nil {
+		if project.ActiveFilter.Hide {
+			dropdown.Options = append(dropdown.Options, filterOptionDim)
+		} else {
+			dropdown.Options = append(dropdown.Options, filterOptionHide)
+		}
+		dropdown.Options = append(dropdown.Options, filterOptionDelete)
+	}
+
+	dropdown.Update()
+
+	project.FilterMenuPanel = rl.Rectangle{}
+	if dropdown.Open {
+		project.FilterMenuPanel = dropdown.OptionsRect()
+	}
+
+	if dropdown.ChoiceIndex < 0 {
+		return
+	}
+
+	switch choice := dropdown.ChoiceAsString(); {
+
+	case dropdown.ChoiceIndex == 0:
+		project.SetActiveFilter(-1)
+		project.Log("Filter turned off.")
+
+	case dropdown.ChoiceIndex <= len(project.SavedFilters):
+		project.SetActiveFilter(dropdown.ChoiceIndex - 1)
+		if project.ActiveFilter != nil {
+			project.Log("Filtering by [%s]: %s", project.ActiveFilter.Name, project.ActiveFilter.Query)
+		}
+
+	case choice == filterOptionSave:
+		project.PopupAction = ActionSaveFilter
+		project.PopupArgument = fmt.Sprintf("Filter %d", len(project.SavedFilters)+1)
+
+	case choice == filterOptionHide || choice == filterOptionDim:
+		project.ActiveFilter.Hide = choice == filterOptionHide
+		project.Modified = true
+
+	case choice == filterOptionDelete:
+		for i, filter := range project.SavedFilters {
+			if filter == project.ActiveFilter {
+				project.SavedFilters = append(project.SavedFilters[:i], project.SavedFilters[i+1:]...)
+				break
+			}
+		}
+		project.Log("Deleted filter [%s].", project.ActiveFilter.Name)
+		project.SetActiveFilter(-1)
+		project.Modified = true
+
+	}
+
+}
@@ -17,6 +17,8 @@ require (
 	github.com/tanema/gween v0.0.0-20220318192052-2db1c2d931bd
 	github.com/tidwall/gjson v1.14.0
 	github.com/tidwall/sjson v1.2.4
+	github.com/yuin/gopher-lua v0.0.0-20191220021717-ab39c6098bdb
+	golang.org/x/image v0.0.0-20220321031419-a8550c1d254a
 )
 
 // The below line replaces the normal raylib-go dependency with my branch that has the config.h tweaked to
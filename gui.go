@@ -50,6 +50,16 @@ func getThemeColor(colorConstant string) rl.Color {
 	return guiColors[programSettings.Theme][colorConstant]
 }
 
+// getThemeColors returns a copy of the current theme's colors, for drawing away from the main thread, where the theme could
+// be changed underneath it.
+func getThemeColors() map[string]rl.Color {
+	colors := map[string]rl.Color{}
+	for name, color := range guiColors[programSettings.Theme] {
+		colors[name] = color
+	}
+	return colors
+}
+
 func loadThemes() {
 
 	newGUIColors := map[string]map[string]rl.Color{}
@@ -1647,6 +1657,10 @@ type DropdownMenu struct {
 	Open        bool
 	ChoiceIndex int
 	Clicked     bool
+	// Upward lists the options above the dropdown rather than beside it, for dropdowns at the bottom of the screen.
+	Upward bool
+
+	optionsRect rl.Rectangle
 }
 
 func NewDropdown(x, y, w, h float32, name string, options ...string) *DropdownMenu {
@@ -1712,6 +1726,8 @@ func (dropdown *DropdownMenu) Update() {
 	rl.DrawTexturePro(currentProject.GUI_Icons, rl.Rectangle{16, 16, 16, 16}, rl.Rectangle{dropdown.Rect.X + (dropdown.Rect.Width - 24), dropdown.Rect.Y + 8, 16, 16}, rl.Vector2{}, 0, arrowColor)
 	// rl.DrawPoly(rl.Vector2{dropdown.Rect.X + dropdown.Rect.Width - 14, dropdown.Rect.Y + dropdown.Rect.Height/2}, 3, 7, 26, getThemeColor(GUI_FONT_COLOR))
 
+	dropdown.optionsRect = rl.Rectangle{}
+
 	if dropdown.Open {
 
 		y := float32(0)
@@ -1722,9 +1738,23 @@ func (dropdown *DropdownMenu) Update() {
 
 			rect := dropdown.Rect
 			textWidth = rl.MeasureTextEx(font, txt, GUIFontSize(), spacing)
-			rect.X += rect.Width
+			if dropdown.Upward {
+				rect.Y -= rect.Height * float32(len(dropdown.Options)-i)
+			} else {
+				rect.X += rect.Width
+				rect.Y += y
+			}
 			rect.Width = textWidth.X + 16
-			rect.Y += y
+
+			if dropdown.optionsRect.Width == 0 {
+				dropdown.optionsRect = rect
+			} else {
+				x := float32(math.Min(float64(dropdown.optionsRect.X), float64(rect.X)))
+				y := float32(math.Min(float64(dropdown.optionsRect.Y), float64(rect.Y)))
+				right := float32(math.Max(float64(dropdown.optionsRect.X+dropdown.optionsRect.Width), float64(rect.X+rect.Width)))
+				bottom := float32(math.Max(float64(dropdown.optionsRect.Y+dropdown.optionsRect.Height), float64(rect.Y+rect.Height)))
+				dropdown.optionsRect = rl.Rectangle{x, y, right - x, bottom - y}
+			}
 
 			if ImmediateButton(rect, txt, false) {
 				dropdown.Clicked = true
@@ -1739,6 +1769,11 @@ func (dropdown *DropdownMenu) Update() {
 
 }
 
+// OptionsRect returns the area the dropdown's options cover while it's open.
+func (dropdown *DropdownMenu) OptionsRect() rl.Rectangle {
+	return dropdown.optionsRect
+}
+
 func (dropdown *DropdownMenu) ChoiceAsString() string {
 
 	if dropdown.ChoiceIndex >= 0 && len(dropdown.Options) > dropdown.ChoiceIndex {
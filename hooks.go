@@ -0,0 +1,675 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Scripts let users automate MasterPlan with Lua. Every .lua file in the "scripts" folder of MasterPlan's settings directory
+// is run when a project is opened, and can listen for events on the project's message bus with masterplan.on(), like:
+//
+//   masterplan.on("task completed", function(task)
+//       if task.stack == "Done" then
+//           task:move("Archive")
+//       end
+//   end)
+//
+// Events are either one of the hook events below, or one of the messages Tasks are sent through Board.SendMessage() and
+// Task.ReceiveMessage() ("select", "dropped", "double click", and so on), in which case the handler is called for each Task
+// that receives the message, along with the Task the message is about, if any. Handlers are called on the main thread at
+// the start of the next frame, and act on Tasks through the same functions the GUI uses, so what they do can be undone.
+//
+// Tasks have the fields id, type, description, board, stack (the first line of the Task at the top of its stack), x, y,
+// completable, complete, timer_name, and valid, and the methods move(board name), complete(), uncomplete(), describe(text),
+// delete(), select(), and receive_message(message[, task]). The masterplan table also has log(text), tasks([board name]),
+// send_message(message[, task]) (which sends the message to every Task in the project), and run(command[, task]) (which
+// runs the command through the system shell without waiting for it, logging what it prints).
+
+const (
+	HookTaskCreated     = "task created"
+	HookTaskDeleted     = "task deleted"
+	HookTaskCompleted   = "task completed"
+	HookTaskUncompleted = "task uncompleted"
+	HookTaskSelected    = "task selected"
+	HookTimerFired      = "timer fired"
+
+	SCRIPTS_PATH = "MasterPlan/scripts"
+
+	luaTaskType = "task"
+)
+
+type scriptEvent struct {
+	Name  string
+	Task  *Task
+	Other *Task
+}
+
+type ScriptHooks struct {
+	Project   *Project
+	Output    chan string
+	completed map[*Task]bool
+	state     *lua.LState
+	handlers  map[string][]*lua.LFunction
+	events    []scriptEvent
+}
+
+func NewScriptHooks(project *Project) *ScriptHooks {
+	return &ScriptHooks{
+		Project:   project,
+		Output:    make(chan string, 256),
+		completed: map[*Task]bool{},
+		handlers:  map[string][]*lua.LFunction{},
+		events:    []scriptEvent{},
+	}
+}
+
+// Active returns if scripts and commands should be run in response to events at all; in a LAN session, only the host runs
+// them, so each event is only handled once.
+func (hooks *ScriptHooks) Active() bool {
+	return !hooks.Project.Loading && (hooks.Project.Sync == nil || hooks.Project.Sync.Hosting)
+}
+
+// TaskChanged should be called when a Task's undo state is captured; it works out which events the change represents.
+func (hooks *ScriptHooks) TaskChanged(task *Task, created, deleted bool) {
+
+	wasComplete, known := hooks.completed[task]
+	isComplete := task.IsCompletable() && task.IsComplete()
+	hooks.completed[task] = isComplete
+
+	if !hooks.Active() {
+		return
+	}
+
+	if created {
+		hooks.Trigger(HookTaskCreated, task)
+	} else if deleted {
+		hooks.Trigger(HookTaskDeleted, task)
+		delete(hooks.completed, task)
+		return
+	}
+
+	if known && isComplete != wasComplete {
+		if isComplete {
+			hooks.Trigger(HookTaskCompleted, task)
+			if task.Is(TASK_TYPE_BOOLEAN) {
+				hooks.Project.RunTaskCommand(task, HookTaskCompleted)
+			}
+		} else {
+			hooks.Trigger(HookTaskUncompleted, task)
+		}
+	}
+
+}
+
+// Trigger queues up the event for the given Task to be handled by any scripts listening for it.
+func (hooks *ScriptHooks) Trigger(event string, task *Task) {
+	hooks.queue(scriptEvent{Name: event, Task: task})
+}
+
+// Received should be called when a Task receives a message, so that scripts listening for it can handle it.
+func (hooks *ScriptHooks) Received(task *Task, message string, data map[string]interface{}) {
+
+	// Messages like "neighbors" are sent to every Task several times a frame, so they're only queued up if a script wants them.
+	if len(hooks.handlers[message]) == 0 {
+		return
+	}
+
+	event := scriptEvent{Name: message, Task: task}
+
+	if other, ok := data["task"].(*Task); ok {
+		event.Other = other
+	}
+
+	hooks.queue(event)
+
+}
+
+func (hooks *ScriptHooks) queue(event scriptEvent) {
+	if len(hooks.handlers[event.Name]) > 0 && hooks.Active() {
+		hooks.events = append(hooks.events, event)
+	}
+}
+
+// Update runs the scripts the first time it's called, and then calls the scripts' handlers for the events that have happened
+// since the last time it was called; it should be called from the main thread.
+func (hooks *ScriptHooks) Update() {
+
+	if hooks.state == nil {
+		hooks.load()
+	}
+
+	for len(hooks.Output) > 0 {
+		hooks.Project.Log(<-hooks.Output)
+	}
+
+	// Events that handlers cause are handled on the next frame, so scripts can't get stuck handling each other's events.
+	events := hooks.events
+	hooks.events = []scriptEvent{}
+
+	for _, event := range events {
+
+		if !event.Task.Valid && event.Name != HookTaskDeleted {
+			continue
+		}
+
+		args := []lua.LValue{hooks.luaTask(event.Task)}
+		if event.Other != nil {
+			args = append(args, hooks.luaTask(event.Other))
+		}
+
+		for _, handler := range hooks.handlers[event.Name] {
+			if err := hooks.state.CallByParam(lua.P{Fn: handler, Protect: true}, args...); err != nil {
+				hooks.Project.Log("ERROR: Script failed handling [%s]: %s", event.Name, err.Error())
+			}
+		}
+
+	}
+
+}
+
+// Close shuts down the scripts' Lua state.
+func (hooks *ScriptHooks) Close() {
+	if hooks.state != nil {
+		hooks.state.Close()
+	}
+}
+
+func (hooks *ScriptHooks) load() {
+
+	L := lua.NewState()
+	hooks.state = L
+
+	mp := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"on":           hooks.luaOn,
+		"log":          hooks.luaLog,
+		"tasks":        hooks.luaTasks,
+		"send_message": hooks.luaSendMessage,
+		"run":          hooks.luaRun,
+	})
+
+	L.SetGlobal("masterplan", mp)
+	L.SetGlobal("print", L.NewFunction(hooks.luaLog))
+
+	methods := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"move":            hooks.luaMove,
+		"complete":        hooks.luaComplete,
+		"uncomplete":      hooks.luaUncomplete,
+		"describe":        hooks.luaDescribe,
+		"delete":          hooks.luaDelete,
+		"select":          hooks.luaSelect,
+		"receive_message": hooks.luaReceiveMessage,
+	})
+
+	meta := L.NewTypeMetatable(luaTaskType)
+	L.SetField(meta, "__index", L.NewFunction(func(L *lua.LState) int {
+		return hooks.luaTaskIndex(L, methods)
+	}))
+
+	files, _ := ioutil.ReadDir(filepath.Join(xdg.ConfigHome, SCRIPTS_PATH))
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	for _, file := range files {
+
+		if file.IsDir() || filepath.Ext(file.Name()) != ".lua" {
+			continue
+		}
+
+		if err := L.DoFile(filepath.Join(xdg.ConfigHome, SCRIPTS_PATH, file.Name())); err != nil {
+			hooks.Project.Log("ERROR: Could not run script [%s]: %s", file.Name(), err.Error())
+		}
+
+	}
+
+}
+
+func (hooks *ScriptHooks) luaTask(task *Task) lua.LValue {
+	ud := hooks.state.NewUserData()
+	ud.Value = task
+	hooks.state.SetMetatable(ud, hooks.state.GetTypeMetatable(luaTaskType))
+	return ud
+}
+
+func (hooks *ScriptHooks) checkTask(L *lua.LState, n int) *Task {
+
+	if task, ok := L.CheckUserData(n).Value.(*Task); ok {
+		return task
+	}
+
+	L.ArgError(n, "task expected")
+	return nil
+
+}
+
+// validTask returns the Task the method was called on, if it still exists.
+func (hooks *ScriptHooks) validTask(L *lua.LState) *Task {
+
+	task := hooks.checkTask(L, 1)
+
+	if !task.Valid {
+		hooks.Project.Log("WARNING: A script tried to change a Task that no longer exists.")
+		return nil
+	}
+
+	return task
+
+}
+
+func (hooks *ScriptHooks) luaTaskIndex(L *lua.LState, methods *lua.LTable) int {
+
+	task := hooks.checkTask(L, 1)
+	key := L.CheckString(2)
+
+	switch key {
+	case "id":
+		L.Push(lua.LNumber(task.ID))
+	case "type":
+		L.Push(lua.LString(TaskTypeStr(task.TaskType.CurrentChoice)))
+	case "description":
+		L.Push(lua.LString(task.Description.Text()))
+	case "board":
+		L.Push(lua.LString(task.Board.Name))
+	case "stack":
+		head := task
+		if task.StackHead != nil {
+			head = task.StackHead
+		}
+		L.Push(lua.LString(strings.TrimSpace(strings.Split(head.Description.Text(), "\n")[0])))
+	case "x":
+		L.Push(lua.LNumber(task.Position.X))
+	case "y":
+		L.Push(lua.LNumber(task.Position.Y))
+	case "completable":
+		L.Push(lua.LBool(task.IsCompletable()))
+	case "complete":
+		L.Push(lua.LBool(task.IsCompletable() && task.IsComplete()))
+	case "timer_name":
+		L.Push(lua.LString(task.TimerName.Text()))
+	case "valid":
+		L.Push(lua.LBool(task.Valid))
+	default:
+		L.Push(methods.RawGetString(key))
+	}
+
+	return 1
+
+}
+
+func (hooks *ScriptHooks) luaOn(L *lua.LState) int {
+	event := strings.ToLower(L.CheckString(1))
+	hooks.handlers[event] = append(hooks.handlers[event], L.CheckFunction(2))
+	return 0
+}
+
+func (hooks *ScriptHooks) luaLog(L *lua.LState) int {
+
+	parts := []string{}
+	for i := 1; i <= L.GetTop(); i++ {
+		parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+	}
+
+	hooks.Project.Log(strings.Join(parts, " "))
+
+	return 0
+
+}
+
+func (hooks *ScriptHooks) luaTasks(L *lua.LState) int {
+
+	boardName := L.OptString(1, "")
+	list := L.NewTable()
+
+	for _, board := range hooks.Project.Boards {
+		if boardName == "" || strings.EqualFold(board.Name, boardName) {
+			for _, task := range board.Tasks {
+				if task.Valid {
+					list.Append(hooks.luaTask(task))
+				}
+			}
+		}
+	}
+
+	L.Push(list)
+	return 1
+
+}
+
+// messageData returns the data for a message sent by a script, which can name the Task it's about as the argument given.
+func (hooks *ScriptHooks) messageData(L *lua.LState, n int) map[string]interface{} {
+
+	data := map[string]interface{}{}
+
+	if L.Get(n) != lua.LNil {
+		data["task"] = hooks.checkTask(L, n)
+	}
+
+	return data
+
+}
+
+func (hooks *ScriptHooks) luaSendMessage(L *lua.LState) int {
+	hooks.Project.SendMessage(L.CheckString(1), hooks.messageData(L, 2))
+	return 0
+}
+
+func (hooks *ScriptHooks) luaReceiveMessage(L *lua.LState) int {
+	if task := hooks.validTask(L); task != nil {
+		task.ReceiveMessage(L.CheckString(2), hooks.messageData(L, 3))
+	}
+	return 0
+}
+
+func (hooks *ScriptHooks) luaRun(L *lua.LState) int {
+
+	var task *Task
+	if L.Get(2) != lua.LNil {
+		task = hooks.checkTask(L, 2)
+	}
+
+	hooks.runCommand(L.CheckString(1), "script", task)
+
+	return 0
+
+}
+
+func (hooks *ScriptHooks) luaMove(L *lua.LState) int {
+
+	task := hooks.validTask(L)
+	boardName := L.CheckString(2)
+
+	if task == nil {
+		return 0
+	}
+
+	for _, board := range hooks.Project.Boards {
+		if strings.EqualFold(board.Name, boardName) {
+			task.Board.SendTasks([]*Task{task}, board)
+			return 0
+		}
+	}
+
+	hooks.Project.Log("WARNING: A script tried to move a Task to a non-existent Board [%s].", boardName)
+
+	return 0
+
+}
+
+func (hooks *ScriptHooks) setComplete(L *lua.LState, complete bool) int {
+
+	if task := hooks.validTask(L); task != nil && task.IsCompletable() && task.Contents != nil {
+
+		if complete {
+			task.Contents.Trigger(TASK_TRIGGER_SET)
+		} else {
+			task.Contents.Trigger(TASK_TRIGGER_CLEAR)
+		}
+
+		task.UndoChange = true
+
+	}
+
+	return 0
+
+}
+
+func (hooks *ScriptHooks) luaComplete(L *lua.LState) int {
+	return hooks.setComplete(L, true)
+}
+
+func (hooks *ScriptHooks) luaUncomplete(L *lua.LState) int {
+	return hooks.setComplete(L, false)
+}
+
+func (hooks *ScriptHooks) luaDescribe(L *lua.LState) int {
+
+	if task := hooks.validTask(L); task != nil {
+		task.Description.SetText(L.CheckString(2))
+		task.UndoChange = true
+		task.Board.TaskChanged = true
+	}
+
+	return 0
+
+}
+
+func (hooks *ScriptHooks) luaDelete(L *lua.LState) int {
+
+	if task := hooks.validTask(L); task != nil {
+		task.Board.DeleteTask(task)
+	}
+
+	return 0
+
+}
+
+func (hooks *ScriptHooks) luaSelect(L *lua.LState) int {
+
+	if task := hooks.validTask(L); task != nil {
+		hooks.Project.SwitchToBoard(task.Board)
+		hooks.Project.SendMessage(MessageSelect, map[string]interface{}{"task": task})
+		hooks.Project.CurrentBoard().FocusViewOnSelectedTasks()
+	}
+
+	return 0
+
+}
+
+// runCommand runs the command through the system shell for the given event and Task (if any), with environment variables
+// describing them, without waiting for it to finish. Anything the command prints is shown in the message log.
+func (hooks *ScriptHooks) runCommand(command, event string, task *Task) {
+
+	var cmd *exec.Cmd
+
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	env := map[string]string{
+		"MASTERPLAN_EVENT": event,
+		"MASTERPLAN_PLAN":  hooks.Project.FilePath,
+	}
+
+	if task != nil {
+
+		env["MASTERPLAN_TASK_ID"] = strconv.Itoa(task.ID)
+		env["MASTERPLAN_TASK_TYPE"] = TaskTypeStr(task.TaskType.CurrentChoice)
+		env["MASTERPLAN_DESCRIPTION"] = task.Description.Text()
+		env["MASTERPLAN_BOARD"] = task.Board.Name
+		env["MASTERPLAN_BOARD_INDEX"] = strconv.Itoa(task.Board.Index())
+
+		if task.Is(TASK_TYPE_TIMER) {
+			env["MASTERPLAN_TIMER_NAME"] = task.TimerName.Text()
+		}
+
+	}
+
+	if automationAPI != nil {
+		env["MASTERPLAN_API"] = fmt.Sprintf("http://127.0.0.1:%d/", automationAPI.Port)
+		env["MASTERPLAN_API_TOKEN"] = automationAPI.Token
+	}
+
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	go func() {
+
+		output, err := cmd.Output()
+
+		scanner := bufio.NewScanner(strings.NewReader(string(output)))
+
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				hooks.Output <- line
+			}
+		}
+
+		if err != nil {
+			hooks.Output <- fmt.Sprintf("ERROR: Command [%s] failed: %s", command, err.Error())
+		}
+
+	}()
+
+}
+
+// RunTaskCommand runs the command set on the Task itself (see Task.Command) for the given event. As plans can come from anywhere,
+// commands only run for plans the user has chosen to trust; otherwise, the user is asked first.
+func (project *Project) RunTaskCommand(task *Task, event string) {
+
+	command := task.Command.Text()
+
+	if command == "" || !project.Hooks.Active() {
+		return
+	}
+
+	if project.CommandsTrusted() {
+		project.Hooks.runCommand(command, event, task)
+	} else if project.CommandsDenied {
+		project.Log("WARNING: Didn't run command [%s], as this plan isn't trusted to run commands.", command)
+	} else {
+
+		project.PendingCommands = append(project.PendingCommands, PendingCommand{Command: command, Event: event, Task: task})
+
+		if project.PopupAction == "" {
+			project.PopupAction = ActionTrustCommands
+			project.trustListed = ""
+		}
+
+	}
+
+}
+
+type PendingCommand struct {
+	Command string
+	Event   string
+	Task    *Task
+}
+
+// TrustedPlan is a plan the user has trusted to run the commands set on its Tasks. Trust only extends to the commands the
+// plan had when the user trusted it, so that changing them (or getting new ones from a LAN session, or by pasting Tasks in)
+// needs the user's approval again.
+type TrustedPlan struct {
+	Path     string
+	Commands string // A hash of the plan's commands, as returned by commandsHash()
+}
+
+// Commands returns the different commands set on the Tasks in the project, in order.
+func (project *Project) Commands() []string {
+
+	commands := []string{}
+	added := map[string]bool{}
+
+	for _, task := range project.GetAllTasks() {
+		if command := task.Command.Text(); task.Valid && task.Is(TASK_TYPE_BOOLEAN, TASK_TYPE_TIMER) && command != "" && !added[command] {
+			commands = append(commands, command)
+			added[command] = true
+		}
+	}
+
+	sort.Strings(commands)
+
+	return commands
+
+}
+
+func commandsHash(commands []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(commands, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+// CommandsTrusted returns if the project is trusted to run the commands currently set on its Tasks, either from the user
+// trusting them for this plan file before, or since the project was opened.
+func (project *Project) CommandsTrusted() bool {
+
+	if project.CommandsDenied {
+		return false
+	}
+
+	hash := commandsHash(project.Commands())
+
+	if project.TrustedCommands == hash {
+		return true
+	}
+
+	index := project.trustedPlanIndex()
+
+	return index >= 0 && programSettings.TrustedPlans[index].Commands == hash
+
+}
+
+func (project *Project) trustedPlanIndex() int {
+
+	if project.FilePath == "" {
+		return -1
+	}
+
+	path, _ := filepath.Abs(project.FilePath)
+
+	for i, trusted := range programSettings.TrustedPlans {
+		if trusted.Path == path {
+			return i
+		}
+	}
+
+	return -1
+
+}
+
+// SetCommandsTrusted trusts or distrusts the project to run its current commands, running any commands that were waiting on
+// the user's decision.
+func (project *Project) SetCommandsTrusted(trusted bool) {
+
+	project.CommandsDenied = !trusted
+	project.TrustedCommands = ""
+
+	index := project.trustedPlanIndex()
+
+	if trusted {
+
+		project.TrustedCommands = commandsHash(project.Commands())
+
+		if project.FilePath != "" {
+
+			if index < 0 {
+				path, _ := filepath.Abs(project.FilePath)
+				programSettings.TrustedPlans = append(programSettings.TrustedPlans, TrustedPlan{Path: path})
+				index = len(programSettings.TrustedPlans) - 1
+			}
+
+			programSettings.TrustedPlans[index].Commands = project.TrustedCommands
+			programSettings.Save()
+
+		}
+
+	} else if index >= 0 {
+		programSettings.TrustedPlans = append(programSettings.TrustedPlans[:index], programSettings.TrustedPlans[index+1:]...)
+		programSettings.Save()
+	}
+
+	for _, pending := range project.PendingCommands {
+		if trusted && pending.Task.Valid {
+			project.Hooks.runCommand(pending.Command, pending.Event, pending.Task)
+		} else if !trusted {
+			project.Log("WARNING: Didn't run command [%s], as this plan isn't trusted to run commands.", pending.Command)
+		}
+	}
+
+	project.PendingCommands = []PendingCommand{}
+
+}
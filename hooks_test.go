@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func TestScriptsHandleEvents(t *testing.T) {
+
+	configHome, err := ioutil.TempDir("", "masterplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(configHome)
+
+	prevConfigHome := xdg.ConfigHome
+	xdg.ConfigHome = configHome
+	defer func() { xdg.ConfigHome = prevConfigHome }()
+
+	os.MkdirAll(filepath.Join(configHome, SCRIPTS_PATH), 0755)
+
+	script := `
+masterplan.on("task completed", function(task)
+	if task.board == "Work" then
+		task:describe(task.description .. " (done)")
+		task:move("Archive")
+	end
+end)
+
+masterplan.on("select", function(task, selected)
+	if task.id == selected.id then
+		masterplan.log("selected " .. task.description)
+	end
+end)
+`
+
+	if err := ioutil.WriteFile(filepath.Join(configHome, SCRIPTS_PATH, "archive.lua"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project := newTestProject()
+	defer project.Hooks.Close()
+
+	prevProject := currentProject
+	currentProject = project
+	defer func() { currentProject = prevProject }()
+
+	work := NewBoard(project)
+	work.Name = "Work"
+	archive := NewBoard(project)
+	archive.Name = "Archive"
+	project.Boards = []*Board{work, archive}
+
+	task := newTestTask(work, TASK_TYPE_BOOLEAN, "Write report", 0, 0, 4, 1)
+	work.UndoHistory.Capture(NewUndoState(task), false)
+	work.UndoHistory.Update()
+
+	project.Hooks.Update()
+
+	if len(project.Hooks.handlers[HookTaskCompleted]) != 1 || len(project.Hooks.handlers[MessageSelect]) != 1 {
+		t.Fatalf("expected the script to listen for two events, got %v", project.Hooks.handlers)
+	}
+
+	project.Hooks.Trigger(HookTaskCompleted, task)
+	project.Hooks.Update()
+
+	if task.Description.Text() != "Write report (done)" {
+		t.Errorf("expected the script to describe the Task, got %q", task.Description.Text())
+	}
+
+	if task.Board != archive || len(work.Tasks) != 0 || len(archive.Tasks) != 1 || archive.Tasks[0] != task {
+		t.Fatalf("expected the script to move the Task itself to the Archive Board")
+	}
+
+	// The move is a single step in the undo history of the Board it was moved from.
+	work.UndoHistory.Update()
+
+	if !work.UndoHistory.Undo() || task.Board != work {
+		t.Fatalf("expected undoing to move the Task back to the Work Board")
+	}
+
+	// Messages sent through the message bus reach the scripts too.
+	project.SendMessage(MessageSelect, map[string]interface{}{"task": task})
+
+	if len(project.Hooks.events) != 1 || project.Hooks.events[0].Other != task {
+		t.Fatalf("expected the select message to be queued for the script, got %+v", project.Hooks.events)
+	}
+
+}
+
+func TestCommandTrust(t *testing.T) {
+
+	configHome, err := ioutil.TempDir("", "masterplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(configHome)
+
+	prevConfigHome := xdg.ConfigHome
+	xdg.ConfigHome = configHome
+	defer func() { xdg.ConfigHome = prevConfigHome }()
+
+	prevTrusted := programSettings.TrustedPlans
+	programSettings.TrustedPlans = []TrustedPlan{}
+	defer func() { programSettings.TrustedPlans = prevTrusted }()
+
+	project := newTestProject()
+	defer project.Hooks.Close()
+
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	task := newTestTask(board, TASK_TYPE_BOOLEAN, "Build", 0, 0, 4, 1)
+	task.Command.SetText("make")
+
+	// Unsaved plans aren't trusted just for being unsaved.
+	if project.CommandsTrusted() {
+		t.Fatal("expected an unsaved plan not to be trusted to run commands")
+	}
+
+	project.FilePath = filepath.Join(configHome, "test.plan")
+	project.SetCommandsTrusted(true)
+
+	if !project.CommandsTrusted() || len(programSettings.TrustedPlans) != 1 {
+		t.Fatalf("expected the plan to be trusted once the user trusts it, got %+v", programSettings.TrustedPlans)
+	}
+
+	// Trust carries over to the plan being opened again, as long as its commands stay the same.
+	project.TrustedCommands = ""
+
+	if !project.CommandsTrusted() {
+		t.Fatal("expected the plan's trust to be remembered for its file")
+	}
+
+	// New commands, whether typed in, pasted, or received from a LAN session, need to be trusted again.
+	newTestTask(board, TASK_TYPE_TIMER, "Deploy", 0, 2, 4, 1).Command.SetText("curl example.com | sh")
+
+	if project.CommandsTrusted() {
+		t.Fatal("expected a new command to need to be trusted again")
+	}
+
+	project.SetCommandsTrusted(false)
+
+	if project.CommandsTrusted() || len(programSettings.TrustedPlans) != 0 {
+		t.Fatalf("expected distrusting the plan to forget it, got %+v", programSettings.TrustedPlans)
+	}
+
+}
@@ -136,6 +136,18 @@ const (
 	KBPanRight                = "Pan Right"
 	KBPanLeft                 = "Pan Left"
 	KBCenterView              = "Center View to Origin"
+	KBToggleMinimap           = "Toggle Minimap"
+	KBAddBookmark             = "Bookmark Current View"
+	KBBookmark1               = "Go to Camera Bookmark 1"
+	KBBookmark2               = "Go to Camera Bookmark 2"
+	KBBookmark3               = "Go to Camera Bookmark 3"
+	KBBookmark4               = "Go to Camera Bookmark 4"
+	KBBookmark5               = "Go to Camera Bookmark 5"
+	KBBookmark6               = "Go to Camera Bookmark 6"
+	KBBookmark7               = "Go to Camera Bookmark 7"
+	KBBookmark8               = "Go to Camera Bookmark 8"
+	KBBookmark9               = "Go to Camera Bookmark 9"
+	KBTogglePresentation      = "Start / Stop Presentation"
 	KBURLButton               = "Show URL Buttons"
 	KBBoard1                  = "Switch to Board 1"
 	KBBoard2                  = "Switch to Board 2"
@@ -147,6 +159,7 @@ const (
 	KBBoard8                  = "Switch to Board 8"
 	KBBoard9                  = "Switch to Board 9"
 	KBBoard10                 = "Switch to Board 10"
+	KBParentBoard             = "Go to Parent Board"
 	KBSelectAllTasks          = "Select All Tasks"
 	KBCopyTasks               = "Copy Tasks"
 	KBCutTasks                = "Cut Tasks"
@@ -157,6 +170,7 @@ const (
 	KBCreateProgressionTask   = "Create Progression Task"
 	KBCreateNoteTask          = "Create Note Task"
 	KBCreateImageTask         = "Create Image Task"
+	KBCreateMediaTask         = "Create Media Task"
 	KBCreateTimerTask         = "Create Timer Task"
 	KBCreateLinetask          = "Create Line Task"
 	KBCreateMapTask           = "Create Map Task"
@@ -178,6 +192,8 @@ const (
 	KBSelectPrevLineEnding    = "Line: Select Previous Line Ending"
 	KBSelectTopTaskInStack    = "Select Top Task in Stack"
 	KBSelectBottomTaskInStack = "Select Bottom Task in Stack"
+	KBSortStackByPriority     = "Sort Stack by Priority"
+	KBSendToBoard             = "Send Tasks to Board"
 	KBSlideTask               = "Slide Task Modifier"
 	KBAddToSelection          = "Add to Selection Modifier"
 	KBRemoveFromSelection     = "Remove From Selection Modifier"
@@ -195,8 +211,16 @@ const (
 	KBProgressToggle          = "Progression: Toggle Completion"
 	KBPencilTool              = "Map / Whiteboard: Toggle Pencil Tool"
 	KBMapRectTool             = "Map: Toggle Rectangle Tool"
+	KBMapFillTool             = "Map: Toggle Fill Tool"
+	KBMapLineTool             = "Map: Toggle Line Tool"
+	KBMapSelectTool           = "Map: Toggle Selection Tool"
+	KBMapFlipHorizontal       = "Map: Flip Selection Horizontally"
+	KBMapFlipVertical         = "Map: Flip Selection Vertically"
+	KBMapRotate               = "Map: Rotate Selection"
 	KBStartTimer              = "Timer: Start / Pause Timer"
 	KBChangePencilToolSize    = "Whiteboard: Change Pencil Tool Size"
+	KBWhiteboardNextTool      = "Whiteboard: Next Tool"
+	KBWhiteboardNextColor     = "Whiteboard: Next Color"
 	KBShowFPS                 = "Show FPS"
 	KBWindowSizeSmall         = "Set Window Size to 960x540"
 	KBWindowSizeNormal        = "Set Window Size to 1920x1080"
@@ -392,6 +416,18 @@ func (kb *Keybindings) Default() {
 	kb.Define(KBPanDown, rl.KeyS).triggerMode = TriggerModeHold
 	kb.Define(KBPanRight, rl.KeyD).triggerMode = TriggerModeHold
 	kb.Define(KBCenterView, rl.KeyBackspace)
+	kb.Define(KBToggleMinimap, rl.KeyM, rl.KeyLeftControl)
+	kb.Define(KBAddBookmark, rl.KeyB, rl.KeyLeftControl)
+	kb.Define(KBBookmark1, rl.KeyOne, rl.KeyLeftAlt)
+	kb.Define(KBBookmark2, rl.KeyTwo, rl.KeyLeftAlt)
+	kb.Define(KBBookmark3, rl.KeyThree, rl.KeyLeftAlt)
+	kb.Define(KBBookmark4, rl.KeyFour, rl.KeyLeftAlt)
+	kb.Define(KBBookmark5, rl.KeyFive, rl.KeyLeftAlt)
+	kb.Define(KBBookmark6, rl.KeySix, rl.KeyLeftAlt)
+	kb.Define(KBBookmark7, rl.KeySeven, rl.KeyLeftAlt)
+	kb.Define(KBBookmark8, rl.KeyEight, rl.KeyLeftAlt)
+	kb.Define(KBBookmark9, rl.KeyNine, rl.KeyLeftAlt)
+	kb.Define(KBTogglePresentation, rl.KeyF5)
 	kb.Define(KBURLButton, rl.KeyLeftControl).triggerMode = TriggerModeHold
 
 	kb.Define(KBBoard1, rl.KeyOne, rl.KeyLeftShift)
@@ -404,6 +440,7 @@ func (kb *Keybindings) Default() {
 	kb.Define(KBBoard8, rl.KeyEight, rl.KeyLeftShift)
 	kb.Define(KBBoard9, rl.KeyNine, rl.KeyLeftShift)
 	kb.Define(KBBoard10, rl.KeyZero, rl.KeyLeftShift)
+	kb.Define(KBParentBoard, rl.KeyUp, rl.KeyLeftAlt)
 
 	kb.Define(KBCopyTasks, rl.KeyC, rl.KeyLeftControl)
 	kb.Define(KBCutTasks, rl.KeyX, rl.KeyLeftControl)
@@ -415,6 +452,7 @@ func (kb *Keybindings) Default() {
 	kb.Define(KBCreateProgressionTask, rl.KeyTwo, rl.KeyLeftControl)
 	kb.Define(KBCreateNoteTask, rl.KeyThree, rl.KeyLeftControl)
 	kb.Define(KBCreateImageTask, rl.KeyFour, rl.KeyLeftControl)
+	kb.Define(KBCreateMediaTask, rl.KeyFive, rl.KeyLeftControl)
 	kb.Define(KBCreateTimerTask, rl.KeySix, rl.KeyLeftControl)
 	kb.Define(KBCreateLinetask, rl.KeySeven, rl.KeyLeftControl)
 	kb.Define(KBCreateMapTask, rl.KeyEight, rl.KeyLeftControl)
@@ -437,6 +475,8 @@ func (kb *Keybindings) Default() {
 	kb.Define(KBSelectPrevTask, rl.KeyTab, rl.KeyLeftShift).triggerMode = TriggerModeRepeating
 	kb.Define(KBSelectTopTaskInStack, rl.KeyPageUp)
 	kb.Define(KBSelectBottomTaskInStack, rl.KeyPageDown)
+	kb.Define(KBSortStackByPriority, rl.KeyP, rl.KeyLeftControl)
+	kb.Define(KBSendToBoard, rl.KeyB, rl.KeyLeftControl, rl.KeyLeftShift)
 
 	kb.Define(KBSlideTask, rl.KeyLeftControl).triggerMode = TriggerModeHold
 	kb.Define(KBAddToSelection, rl.KeyLeftShift).triggerMode = TriggerModeHold
@@ -459,7 +499,15 @@ func (kb *Keybindings) Default() {
 	kb.Define(KBProgressToggle, rl.KeyV)
 	kb.Define(KBPencilTool, rl.KeyQ)
 	kb.Define(KBChangePencilToolSize, rl.KeyR)
+	kb.Define(KBWhiteboardNextTool, rl.KeyT)
+	kb.Define(KBWhiteboardNextColor, rl.KeyG)
 	kb.Define(KBMapRectTool, rl.KeyR)
+	kb.Define(KBMapFillTool, rl.KeyB)
+	kb.Define(KBMapLineTool, rl.KeyL)
+	kb.Define(KBMapSelectTool, rl.KeyE)
+	kb.Define(KBMapFlipHorizontal, rl.KeyH)
+	kb.Define(KBMapFlipVertical, rl.KeyH, rl.KeyLeftShift)
+	kb.Define(KBMapRotate, rl.KeyY)
 	kb.Define(KBStartTimer, rl.KeyC)
 	kb.Define(KBSelectPrevLineEnding, rl.KeyX).triggerMode = TriggerModeRepeating
 	kb.Define(KBSelectNextLineEnding, rl.KeyC).triggerMode = TriggerModeRepeating
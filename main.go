@@ -97,6 +97,10 @@ func main() {
 		}
 	}()
 
+	if runCLICommand() {
+		return
+	}
+
 	rl.SetTraceLog(rl.LogError)
 
 	settingsLoaded := programSettings.Load()
@@ -122,6 +126,8 @@ func main() {
 
 	rl.SetWindowIcon(*rl.LoadImage(LocalPath("assets", "window_icon.png")))
 
+	rl.InitAudioDevice()
+
 	if programSettings.SaveWindowPosition && programSettings.WindowPosition.Width > 0 && programSettings.WindowPosition.Height > 0 {
 		rl.SetWindowPosition(int(programSettings.WindowPosition.X), int(programSettings.WindowPosition.Y))
 		rl.SetWindowSize(int(programSettings.WindowPosition.Width), int(programSettings.WindowPosition.Height))
@@ -131,6 +137,8 @@ func main() {
 
 	currentProject = NewProject()
 
+	UpdateAutomationAPI()
+
 	rl.SetExitKey(0) /// We don't want Escape to close the program.
 
 	attemptAutoload := 5
@@ -266,7 +274,7 @@ func main() {
 				v += "Modified"
 			}
 
-			if len(v) > 0 {
+			if len(v) > 0 && !currentProject.Presenting {
 				size, _ := TextSize(v, true)
 				x -= size.X
 				DrawGUITextColored(rl.Vector2{x, 8}, color, v)
@@ -277,7 +285,7 @@ func main() {
 
 			y := float32(24)
 
-			if !programSettings.DisableMessageLog {
+			if !programSettings.DisableMessageLog && !currentProject.Presenting {
 
 				for i := 0; i < len(eventLogBuffer); i++ {
 
@@ -424,10 +432,14 @@ func main() {
 		programSettings.Save()
 	}
 
+	resourceCache.Flush()
+
 	log.Println("MasterPlan exited successfully.")
 
 	currentProject.Destroy()
 
+	rl.CloseAudioDevice()
+
 }
 
 func profileCPU() {
@@ -1,52 +1,242 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
+	"strconv"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
 )
 
 const (
 	MapEditToolNone = iota
 	MapEditToolPencil
 	MapEditToolRectangle
+	MapEditToolFill
+	MapEditToolLine
+	MapEditToolSelect
 )
 
+// MapRegion is a rectangle of cells in a Map.
+type MapRegion struct {
+	X, Y, W, H int
+}
+
+// Contains returns if the cell given is within the region.
+func (region MapRegion) Contains(x, y int) bool {
+	return x >= region.X && x < region.X+region.W && y >= region.Y && y < region.Y+region.H
+}
+
+// mapClipboard holds the cells last copied from a Map selection; it's shared between Maps so regions can be copied from one to another.
+var mapClipboard [][]int32
+
+// MapLayer is a single layer of tiles in a Map. A value of 0 is an empty cell; otherwise, the value is the index of
+// the tile in the Map's tileset plus one (the same as a Tiled global tile ID with a single tileset). Without a tileset,
+// any non-zero value is drawn as a wall.
+type MapLayer struct {
+	Name    string
+	Visible bool
+	Data    [][]int32
+}
+
+// MapFormat is the version of the serialized Map format. Version 0 was a single layer of walls stored as MapData, version 1
+// stored MapLayers with each cell as a JSON number, and version 2 packs each layer's cells into a string.
+const MapFormat = 2
+
+// packedMapLayer is how a MapLayer is serialized.
+type packedMapLayer struct {
+	Name    string
+	Visible bool
+	Width   int
+	Height  int
+	Cells   string
+}
+
 type MapImage struct {
+	Layers     []*MapLayer
+	LayerIndex int
+	// Data is the current layer's data.
 	Data           [][]int32
 	Task           *Task
 	Texture        rl.RenderTexture2D
 	Changed        bool
 	EditTool       int
 	RectangleStart []int
-
-	cellWidth  int
-	cellHeight int
+	// Selection is the region selected with the selection tool, or nil if nothing is selected.
+	Selection *MapRegion
+
+	// TilesetPath is the path or URL of the tileset image, loaded as a Resource; TileSize is the size of each tile in it, in pixels.
+	TilesetPath  string
+	TileSize     int32
+	Tileset      *Resource
+	SelectedTile int32
+
+	tilesetReady     bool
+	redraw           bool // Set when the Map needs to be drawn again without having changed (e.g. when the tileset loads)
+	moveStart        []int
+	serializedLayers string
+	cellWidth        int
+	cellHeight       int
 }
 
 func NewMapImage(task *Task) *MapImage {
 
 	mi := &MapImage{
-		Task:       task,
-		Data:       [][]int32{},
-		cellWidth:  4,
-		cellHeight: 4,
-		Texture:    rl.LoadRenderTexture(512, 512),
+		Task:         task,
+		Layers:       []*MapLayer{},
+		cellWidth:    4,
+		cellHeight:   4,
+		Texture:      rl.LoadRenderTexture(512, 512),
+		TileSize:     task.Board.Project.GridSize,
+		SelectedTile: 1,
 	}
 
-	gridSize := 512 / task.Board.Project.GridSize
+	mi.AddLayer()
+
+	mi.Changed = true
+
+	mi.Draw()
+	return mi
+}
+
+func (mapImage *MapImage) newLayerData() [][]int32 {
+
+	data := [][]int32{}
+
+	gridSize := 512 / mapImage.Task.Board.Project.GridSize
 
 	for i := 0; i < int(gridSize); i++ {
-		mi.Data = append(mi.Data, []int32{})
+		data = append(data, []int32{})
 		for j := 0; j < int(gridSize); j++ {
-			mi.Data[i] = append(mi.Data[i], 0)
+			data[i] = append(data[i], 0)
 		}
 	}
 
-	mi.Changed = true
+	return data
+
+}
+
+// AddLayer adds a new, empty layer above the current one and switches to it.
+func (mapImage *MapImage) AddLayer() {
+
+	layer := &MapLayer{Name: "Layer " + strconv.Itoa(len(mapImage.Layers)+1), Visible: true, Data: mapImage.newLayerData()}
+
+	index := len(mapImage.Layers)
+	if len(mapImage.Layers) > 0 {
+		index = mapImage.LayerIndex + 1
+	}
+
+	mapImage.Layers = append(mapImage.Layers[:index], append([]*MapLayer{layer}, mapImage.Layers[index:]...)...)
+	mapImage.SetLayer(index)
+	mapImage.Changed = true
 
-	mi.Draw()
-	return mi
+}
+
+// DeleteLayer removes the current layer; a Map always has at least one layer, so deleting the last one just clears it.
+func (mapImage *MapImage) DeleteLayer() {
+
+	if len(mapImage.Layers) <= 1 {
+		mapImage.Clear()
+		return
+	}
+
+	mapImage.Layers = append(mapImage.Layers[:mapImage.LayerIndex], mapImage.Layers[mapImage.LayerIndex+1:]...)
+	mapImage.SetLayer(mapImage.LayerIndex - 1)
+	mapImage.Changed = true
+
+}
+
+// SetLayer switches the layer that editing tools work on.
+func (mapImage *MapImage) SetLayer(index int) {
+
+	if index < 0 {
+		index = 0
+	} else if index >= len(mapImage.Layers) {
+		index = len(mapImage.Layers) - 1
+	}
+
+	mapImage.LayerIndex = index
+	mapImage.Data = mapImage.Layers[index].Data
+
+}
+
+func (mapImage *MapImage) CurrentLayer() *MapLayer {
+	return mapImage.Layers[mapImage.LayerIndex]
+}
+
+// SetTileset changes the tileset image the Map draws with; an empty path goes back to drawing walls.
+func (mapImage *MapImage) SetTileset(path string, tileSize int32) {
+
+	if tileSize < 1 {
+		tileSize = mapImage.Task.Board.Project.GridSize
+	}
+
+	if path == mapImage.TilesetPath && tileSize == mapImage.TileSize {
+		return
+	}
+
+	mapImage.TilesetPath = path
+	mapImage.TileSize = tileSize
+	mapImage.Tileset = nil
+	mapImage.tilesetReady = false
+
+	if path != "" {
+		mapImage.Tileset = mapImage.Task.Board.Project.LoadResource(path)
+		if mapImage.Tileset == nil {
+			mapImage.Task.Board.Project.Log("ERROR: Couldn't load tileset [%s].", path)
+		}
+	}
+
+	mapImage.Changed = true
+
+}
+
+// TilesetTexture returns the tileset's texture and true if a tileset is loaded and ready to draw with.
+func (mapImage *MapImage) TilesetTexture() (rl.Texture2D, bool) {
+
+	if mapImage.Tileset == nil || mapImage.Tileset.State() != RESOURCE_STATE_READY || !mapImage.Tileset.IsTexture() {
+		return rl.Texture2D{}, false
+	}
+
+	return mapImage.Tileset.Texture(), true
+
+}
+
+// TileCount returns how many tiles there are in the tileset, along with how many columns of tiles there are.
+func (mapImage *MapImage) TileCount() (int32, int32) {
+
+	texture, ready := mapImage.TilesetTexture()
+
+	if !ready {
+		return 0, 0
+	}
+
+	columns := texture.Width / mapImage.TileSize
+	rows := texture.Height / mapImage.TileSize
+
+	return columns * rows, columns
+
+}
+
+// TileSource returns the rectangle in the tileset texture of the tile with the given value.
+func (mapImage *MapImage) TileSource(value int32) rl.Rectangle {
+	_, columns := mapImage.TileCount()
+	if columns <= 0 {
+		columns = 1
+	}
+	index := value - 1
+	ts := float32(mapImage.TileSize)
+	return rl.Rectangle{float32(index%columns) * ts, float32(index/columns) * ts, ts, ts}
+}
+
+// Brush returns the value that the editing tools paint with.
+func (mapImage *MapImage) Brush() int32 {
+	if _, ready := mapImage.TilesetTexture(); ready {
+		return mapImage.SelectedTile
+	}
+	return 1
 }
 
 func (mapImage *MapImage) Draw() {
@@ -57,43 +247,62 @@ func (mapImage *MapImage) Draw() {
 		mapImage.EditTool = MapEditToolNone
 	}
 
-	if mapImage.Changed {
+	tileset, tilesetReady := mapImage.TilesetTexture()
+
+	// Redraw once the tileset finishes loading (or is reloaded or deleted)
+	if tilesetReady != mapImage.tilesetReady {
+		mapImage.tilesetReady = tilesetReady
+		mapImage.redraw = true
+	}
+
+	if mapImage.Changed || mapImage.redraw {
 
 		rl.BeginTextureMode(mapImage.Texture)
 		rl.ClearBackground(rl.Color{0, 0, 0, 0})
 
 		dst := rl.Rectangle{0, 0, 16, 16}
 
-		getValue := func(x, y int) int32 {
-			if y >= mapImage.cellHeight {
-				return 0
-			} else if y < 0 {
-				return 0
-			}
+		for _, layer := range mapImage.Layers {
 
-			if x >= mapImage.cellWidth {
-				return 0
-			} else if x < 0 {
-				return 0
+			if !layer.Visible {
+				continue
 			}
 
-			return mapImage.Data[y][x]
+			getValue := func(x, y int) int32 {
+				if y >= mapImage.cellHeight {
+					return 0
+				} else if y < 0 {
+					return 0
+				}
 
-		}
+				if x >= mapImage.cellWidth {
+					return 0
+				} else if x < 0 {
+					return 0
+				}
 
-		for y := 0; y < mapImage.cellHeight; y++ {
+				return layer.Data[y][x]
 
-			for x := 0; x < mapImage.cellWidth; x++ {
+			}
 
-				color := getThemeColor(GUI_OUTLINE_HIGHLIGHTED)
-				src := rl.Rectangle{48, 32, 16, 16}
-				rotation := float32(0)
-				dst.X = float32(x*16) + 8
-				dst.Y = float32(y*16) + 8
-				gridColor := rl.White
-				gridColor.A = 160
+			for y := 0; y < mapImage.cellHeight; y++ {
+
+				for x := 0; x < mapImage.cellWidth; x++ {
+
+					if getValue(x, y) == 0 {
+						continue
+					}
+
+					if tilesetReady {
+						rl.DrawTexturePro(tileset, mapImage.TileSource(getValue(x, y)), rl.Rectangle{float32(x * 16), float32(y * 16), 16, 16}, rl.Vector2{}, 0, rl.White)
+						continue
+					}
 
-				if getValue(x, y) > 0 {
+					color := getThemeColor(GUI_OUTLINE_HIGHLIGHTED)
+					src := rl.Rectangle{48, 32, 16, 16}
+					rotation := float32(0)
+					dst.X = float32(x*16) + 8
+					dst.Y = float32(y*16) + 8
 
 					if getValue(x+1, y) > 0 && getValue(x, y+1) > 0 && (getValue(x-1, y) == 0 && getValue(x, y-1) == 0) {
 						src.X = 64
@@ -110,15 +319,28 @@ func (mapImage *MapImage) Draw() {
 
 					rl.DrawTexturePro(project.GUI_Icons, src, dst, rl.Vector2{8, 8}, rotation, color)
 
+				}
+
+			}
+
+		}
+
+		for y := 0; y < mapImage.cellHeight; y++ {
+
+			for x := 0; x < mapImage.cellWidth; x++ {
+
+				gridColor := rl.White
+				gridColor.A = 160
+
+				if mapImage.Data[y][x] > 0 {
 					gridColor = rl.Black
 					gridColor.A = 32
-
 				}
 
-				src.X = 80
-				src.Y = 32
+				dst.X = float32(x*16) + 8
+				dst.Y = float32(y*16) + 8
 
-				rl.DrawTexturePro(project.GUI_Icons, src, dst, rl.Vector2{8, 8}, 0, gridColor)
+				rl.DrawTexturePro(project.GUI_Icons, rl.Rectangle{80, 32, 16, 16}, dst, rl.Vector2{8, 8}, 0, gridColor)
 
 			}
 
@@ -129,7 +351,12 @@ func (mapImage *MapImage) Draw() {
 		rl.BeginMode2D(camera) // We have to call BeginMode2D again because BeginTextureMode modifies the OpenGL view matrix to render at a "GUI" level
 		// And we're not in the GUI, but drawing "into" the world here
 
+		if mapImage.Changed {
+			mapImage.serializedLayers = "" // The Map was drawn because it changed, so it needs to be serialized again as well
+		}
+
 		mapImage.Changed = false
+		mapImage.redraw = false
 
 	}
 
@@ -144,6 +371,10 @@ func (mapImage *MapImage) Draw() {
 		cx := int(math.Floor(float64((mousePos.X - rect.X) / gs)))
 		cy := int(math.Floor(float64((mousePos.Y - rect.Y) / gs)))
 
+		if mapImage.EditTool != MapEditToolNone && tilesetReady {
+			mapImage.DrawTilePalette()
+		}
+
 		if mapImage.EditTool == MapEditToolPencil {
 
 			if cx >= 0 && cx <= mapImage.cellWidth-1 && cy >= 0 && cy <= mapImage.cellHeight-1 {
@@ -156,8 +387,10 @@ func (mapImage *MapImage) Draw() {
 				rl.DrawRectangleLinesEx(r, 2, c)
 
 				if MouseDown(rl.MouseLeftButton) || MousePressed(rl.MouseLeftButton) {
-					mapImage.Data[cy][cx] = 1
-					mapImage.Changed = true
+					if mapImage.Data[cy][cx] != mapImage.Brush() {
+						mapImage.Data[cy][cx] = mapImage.Brush()
+						mapImage.Changed = true
+					}
 				} else if MouseDown(rl.MouseRightButton) || MouseReleased(rl.MouseRightButton) {
 					// This if statement has to have MouseReleased too because right click opens the menu
 					// And by ensuring this runs on release of right click, we can consume the input below
@@ -167,6 +400,74 @@ func (mapImage *MapImage) Draw() {
 
 			}
 
+		} else if mapImage.EditTool == MapEditToolFill {
+
+			if cx >= 0 && cx <= mapImage.cellWidth-1 && cy >= 0 && cy <= mapImage.cellHeight-1 {
+
+				r := rl.Rectangle{mapImage.Task.Rect.X + float32(cx)*gs, mapImage.Task.Rect.Y + float32(cy)*gs + gs, gs, gs}
+				c := rl.Color{127, 127, 127, 255}
+				f := uint8(((math.Sin(float64(rl.GetTime())*math.Pi) + 1) / 2) * 128)
+				c.R += f
+				c.G += f
+				c.B += f
+				rl.DrawRectangleLinesEx(r, 2, c)
+
+				if MousePressed(rl.MouseLeftButton) {
+					mapImage.FloodFill(cx, cy, mapImage.Brush())
+				} else if MouseReleased(rl.MouseRightButton) {
+					mapImage.FloodFill(cx, cy, 0)
+				}
+
+			}
+
+		} else if mapImage.EditTool == MapEditToolLine {
+
+			if cx >= 0 && cx <= mapImage.cellWidth-1 && cy >= 0 && cy <= mapImage.cellHeight-1 {
+
+				if MousePressed(rl.MouseLeftButton) || MousePressed(rl.MouseRightButton) {
+					mapImage.RectangleStart = []int{cx, cy}
+					mapImage.Task.Dragging = false
+				}
+
+				cells := [][2]int{{cx, cy}}
+
+				if len(mapImage.RectangleStart) > 0 {
+					cells = mapLineCells(mapImage.RectangleStart[0], mapImage.RectangleStart[1], cx, cy)
+				}
+
+				c := rl.Color{127, 127, 127, 255}
+				f := uint8(((math.Sin(float64(rl.GetTime())*math.Pi) + 1) / 2) * 128)
+				c.R += f
+				c.G += f
+				c.B += f
+
+				for _, cell := range cells {
+					rl.DrawRectangleLinesEx(rl.Rectangle{mapImage.Task.Rect.X + float32(cell[0])*gs, mapImage.Task.Rect.Y + float32(cell[1])*gs + gs, gs, gs}, 2, c)
+				}
+
+				if len(mapImage.RectangleStart) > 0 && (MouseReleased(rl.MouseLeftButton) || MouseReleased(rl.MouseRightButton)) {
+
+					value := mapImage.Brush()
+					if MouseReleased(rl.MouseRightButton) {
+						value = 0
+					}
+
+					for _, cell := range cells {
+						mapImage.Data[cell[1]][cell[0]] = value
+					}
+
+					mapImage.Changed = true
+
+					mapImage.RectangleStart = []int{}
+
+				}
+
+			}
+
+		} else if mapImage.EditTool == MapEditToolSelect {
+
+			mapImage.updateSelection(cx, cy)
+
 		} else if mapImage.EditTool == MapEditToolRectangle {
 
 			if cx >= 0 && cx <= mapImage.cellWidth-1 && cy >= 0 && cy <= mapImage.cellHeight-1 {
@@ -217,7 +518,7 @@ func (mapImage *MapImage) Draw() {
 				c.B += f
 				rl.DrawRectangleLinesEx(rect, 2, c)
 
-				if MouseReleased(rl.MouseLeftButton) || MouseReleased(rl.MouseRightButton) {
+				if len(mapImage.RectangleStart) > 0 && (MouseReleased(rl.MouseLeftButton) || MouseReleased(rl.MouseRightButton)) {
 
 					x, y, x2, y2 := 0, 0, 0, 0
 
@@ -245,7 +546,7 @@ func (mapImage *MapImage) Draw() {
 						for j := y; j <= y2; j++ {
 
 							if MouseReleased(rl.MouseLeftButton) {
-								mapImage.Data[j][i] = 1
+								mapImage.Data[j][i] = mapImage.Brush()
 							} else if MouseReleased(rl.MouseRightButton) {
 								mapImage.Data[j][i] = 0
 							}
@@ -275,6 +576,7 @@ func (mapImage *MapImage) Draw() {
 
 	pencilButton := false
 	rectButton := false
+	toolButton := false
 
 	if mapImage.Task.Selected {
 
@@ -290,6 +592,8 @@ func (mapImage *MapImage) Draw() {
 			rectButton = mapImage.Task.SmallButton(64, 48, 16, 16, mapImage.Task.Rect.X+32, mapImage.Task.Rect.Y)
 		}
 
+		toolButton = mapImage.toolButton(rl.Rectangle{mapImage.Task.Rect.X + 48, mapImage.Task.Rect.Y, 16, 16})
+
 		if pencilButton || programSettings.Keybindings.On(KBPencilTool) || (mapImage.EditTool == MapEditToolPencil && !mapImage.Task.Selected) {
 			mapImage.TogglePencil()
 			ConsumeMouseInput(rl.MouseLeftButton)
@@ -302,16 +606,533 @@ func (mapImage *MapImage) Draw() {
 			mapImage.Changed = true
 		}
 
+		if toolButton {
+			// The last header button cycles through the tools that don't have their own icons
+			switch mapImage.EditTool {
+			case MapEditToolFill:
+				mapImage.SetTool(MapEditToolLine)
+			case MapEditToolLine:
+				mapImage.SetTool(MapEditToolSelect)
+			case MapEditToolSelect:
+				mapImage.SetTool(MapEditToolNone)
+			default:
+				mapImage.SetTool(MapEditToolFill)
+			}
+			ConsumeMouseInput(rl.MouseLeftButton)
+		}
+
+		if programSettings.Keybindings.On(KBMapFillTool) {
+			mapImage.ToggleTool(MapEditToolFill)
+		}
+
+		if programSettings.Keybindings.On(KBMapLineTool) {
+			mapImage.ToggleTool(MapEditToolLine)
+		}
+
+		if programSettings.Keybindings.On(KBMapSelectTool) {
+			mapImage.ToggleTool(MapEditToolSelect)
+		}
+
+		if mapImage.Selection != nil {
+
+			if programSettings.Keybindings.On(KBMapFlipHorizontal) {
+				mapImage.FlipSelection(true)
+			} else if programSettings.Keybindings.On(KBMapFlipVertical) {
+				mapImage.FlipSelection(false)
+			} else if programSettings.Keybindings.On(KBMapRotate) {
+				mapImage.RotateSelection()
+			}
+
+		}
+
 	} else {
 		mapImage.EditTool = MapEditToolNone
 	}
 
+	if mapImage.EditTool != MapEditToolSelect {
+		mapImage.Selection = nil
+		mapImage.moveStart = nil
+	}
+
 	if mapImage.Changed {
 		mapImage.Task.UndoChange = true
 	}
 
 }
 
+// toolButton draws the header button for the fill, line and selection tools, which don't have icons of their own, as a
+// small glyph of the active tool; it returns true if it was clicked.
+func (mapImage *MapImage) toolButton(rect rl.Rectangle) bool {
+
+	color := getThemeColor(GUI_FONT_COLOR)
+	mouseOver := rl.CheckCollisionPointRec(GetWorldMousePosition(), rect)
+
+	if mouseOver && !MousePressed(rl.MouseLeftButton) {
+		color = getThemeColor(GUI_INSIDE_DISABLED)
+	}
+
+	glyph := rl.Rectangle{rect.X + 4, rect.Y + 4, 8, 8}
+
+	switch mapImage.EditTool {
+	case MapEditToolFill:
+		rl.DrawRectangleRec(glyph, color)
+	case MapEditToolLine:
+		rl.DrawLineEx(rl.Vector2{glyph.X, glyph.Y + glyph.Height}, rl.Vector2{glyph.X + glyph.Width, glyph.Y}, 2, color)
+	case MapEditToolSelect:
+		for i := float32(0); i < 8; i += 4 {
+			rl.DrawRectangleRec(rl.Rectangle{glyph.X + i, glyph.Y, 2, 1}, color)
+			rl.DrawRectangleRec(rl.Rectangle{glyph.X + i + 2, glyph.Y + 7, 2, 1}, color)
+			rl.DrawRectangleRec(rl.Rectangle{glyph.X, glyph.Y + i + 2, 1, 2}, color)
+			rl.DrawRectangleRec(rl.Rectangle{glyph.X + 7, glyph.Y + i, 1, 2}, color)
+		}
+	default:
+		rl.DrawRectangleLinesEx(glyph, 1, color)
+		rl.DrawRectangleRec(rl.Rectangle{glyph.X, glyph.Y + 4, 8, 4}, color)
+	}
+
+	return mouseOver && MousePressed(rl.MouseLeftButton)
+
+}
+
+// updateSelection handles the selection tool; dragging outside of the selection selects a new region, while dragging the
+// selection moves its cells.
+func (mapImage *MapImage) updateSelection(cx, cy int) {
+
+	gs := float32(mapImage.Task.Board.Project.GridSize)
+	inside := cx >= 0 && cx <= mapImage.cellWidth-1 && cy >= 0 && cy <= mapImage.cellHeight-1
+
+	// Clamp to the Map so dragging outside of it still works
+	clampedX, clampedY := cx, cy
+	if clampedX < 0 {
+		clampedX = 0
+	} else if clampedX >= mapImage.cellWidth {
+		clampedX = mapImage.cellWidth - 1
+	}
+	if clampedY < 0 {
+		clampedY = 0
+	} else if clampedY >= mapImage.cellHeight {
+		clampedY = mapImage.cellHeight - 1
+	}
+
+	if inside && MousePressed(rl.MouseLeftButton) {
+
+		mapImage.Task.Dragging = false
+
+		if mapImage.Selection != nil && mapImage.Selection.Contains(cx, cy) {
+			mapImage.moveStart = []int{cx, cy}
+		} else {
+			mapImage.Selection = nil
+			mapImage.RectangleStart = []int{cx, cy}
+		}
+
+	}
+
+	if inside && MouseReleased(rl.MouseRightButton) && mapImage.Selection != nil {
+		mapImage.Selection = nil
+		ConsumeMouseInput(rl.MouseRightButton)
+	}
+
+	c := rl.Color{127, 127, 127, 255}
+	f := uint8(((math.Sin(float64(rl.GetTime())*math.Pi) + 1) / 2) * 128)
+	c.R += f
+	c.G += f
+	c.B += f
+
+	if len(mapImage.RectangleStart) > 0 {
+
+		region := mapRegionBetween(mapImage.RectangleStart[0], mapImage.RectangleStart[1], clampedX, clampedY)
+		rl.DrawRectangleLinesEx(mapImage.regionRect(region), 2, c)
+
+		if MouseReleased(rl.MouseLeftButton) {
+			mapImage.Selection = &region
+			mapImage.RectangleStart = []int{}
+		}
+
+	} else if mapImage.Selection != nil {
+
+		offsetX, offsetY := 0, 0
+
+		if len(mapImage.moveStart) > 0 {
+
+			offsetX = clampedX - mapImage.moveStart[0]
+			offsetY = clampedY - mapImage.moveStart[1]
+
+			// Preview the cells being moved
+			moved := *mapImage.Selection
+			moved.X += offsetX
+			moved.Y += offsetY
+			preview := getThemeColor(GUI_OUTLINE_HIGHLIGHTED)
+			preview.A = 128
+			tileset, tilesetReady := mapImage.TilesetTexture()
+
+			for y, row := range mapImage.regionData(*mapImage.Selection) {
+				for x, value := range row {
+					if value == 0 {
+						continue
+					}
+					dst := rl.Rectangle{mapImage.Task.Rect.X + float32(moved.X+x)*gs, mapImage.Task.Rect.Y + float32(moved.Y+y)*gs + gs, gs, gs}
+					if tilesetReady {
+						rl.DrawTexturePro(tileset, mapImage.TileSource(value), dst, rl.Vector2{}, 0, rl.Color{255, 255, 255, 192})
+					} else {
+						rl.DrawRectangleRec(dst, preview)
+					}
+				}
+			}
+
+			if MouseReleased(rl.MouseLeftButton) {
+				mapImage.MoveSelection(offsetX, offsetY)
+				mapImage.moveStart = nil
+				offsetX, offsetY = 0, 0
+			}
+
+		}
+
+		moved := *mapImage.Selection
+		moved.X += offsetX
+		moved.Y += offsetY
+		rl.DrawRectangleLinesEx(mapImage.regionRect(moved), 2, c)
+
+	}
+
+}
+
+// regionRect returns the world-space rectangle of the region of cells given.
+func (mapImage *MapImage) regionRect(region MapRegion) rl.Rectangle {
+	gs := float32(mapImage.Task.Board.Project.GridSize)
+	return rl.Rectangle{mapImage.Task.Rect.X + float32(region.X)*gs, mapImage.Task.Rect.Y + float32(region.Y)*gs + gs, float32(region.W) * gs, float32(region.H) * gs}
+}
+
+// mapRegionBetween returns the region spanning two cells, inclusive.
+func mapRegionBetween(x, y, x2, y2 int) MapRegion {
+	if x2 < x {
+		x, x2 = x2, x
+	}
+	if y2 < y {
+		y, y2 = y2, y
+	}
+	return MapRegion{x, y, x2 - x + 1, y2 - y + 1}
+}
+
+// mapLineCells returns the cells along a straight line between two cells, using Bresenham's algorithm.
+func mapLineCells(x, y, x2, y2 int) [][2]int {
+
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+
+	dx := abs(x2 - x)
+	dy := -abs(y2 - y)
+	sx, sy := 1, 1
+
+	if x > x2 {
+		sx = -1
+	}
+
+	if y > y2 {
+		sy = -1
+	}
+
+	cells := [][2]int{}
+	err := dx + dy
+
+	for {
+
+		cells = append(cells, [2]int{x, y})
+
+		if x == x2 && y == y2 {
+			break
+		}
+
+		e2 := 2 * err
+
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+
+	}
+
+	return cells
+
+}
+
+// regionData returns a copy of the cells within the region given on the current layer.
+func (mapImage *MapImage) regionData(region MapRegion) [][]int32 {
+
+	data := [][]int32{}
+
+	for y := region.Y; y < region.Y+region.H; y++ {
+		row := []int32{}
+		for x := region.X; x < region.X+region.W; x++ {
+			row = append(row, mapImage.Data[y][x])
+		}
+		data = append(data, row)
+	}
+
+	return data
+
+}
+
+// fillRegion sets the cells within the region on the current layer to the value given.
+func (mapImage *MapImage) fillRegion(region MapRegion, value int32) {
+	for y := region.Y; y < region.Y+region.H; y++ {
+		for x := region.X; x < region.X+region.W; x++ {
+			mapImage.Data[y][x] = value
+		}
+	}
+}
+
+// stamp writes the cells given onto the current layer with their top-left corner at x, y, and returns the region written
+// to. Cells that fall outside of the Map are cut off.
+func (mapImage *MapImage) stamp(data [][]int32, x, y int) MapRegion {
+
+	region := MapRegion{X: x, Y: y}
+
+	for dy, row := range data {
+		for dx, value := range row {
+			if x+dx >= 0 && x+dx < mapImage.cellWidth && y+dy >= 0 && y+dy < mapImage.cellHeight {
+				mapImage.Data[y+dy][x+dx] = value
+			}
+		}
+	}
+
+	if len(data) > 0 {
+		region.W = len(data[0])
+		region.H = len(data)
+	}
+
+	return mapImage.clipRegion(region)
+
+}
+
+// clipRegion cuts the region given down to the area of the Map.
+func (mapImage *MapImage) clipRegion(region MapRegion) MapRegion {
+
+	if region.X < 0 {
+		region.W += region.X
+		region.X = 0
+	}
+
+	if region.Y < 0 {
+		region.H += region.Y
+		region.Y = 0
+	}
+
+	if region.X+region.W > mapImage.cellWidth {
+		region.W = mapImage.cellWidth - region.X
+	}
+
+	if region.Y+region.H > mapImage.cellHeight {
+		region.H = mapImage.cellHeight - region.Y
+	}
+
+	return region
+
+}
+
+// CopySelection copies the selected cells to the Map clipboard.
+func (mapImage *MapImage) CopySelection() {
+
+	if mapImage.Selection == nil {
+		return
+	}
+
+	mapClipboard = mapImage.regionData(*mapImage.Selection)
+
+	mapImage.Task.Board.Project.Log("Copied %dx%d cells.", mapImage.Selection.W, mapImage.Selection.H)
+
+}
+
+// CutSelection copies the selected cells to the Map clipboard and then clears them.
+func (mapImage *MapImage) CutSelection() {
+
+	if mapImage.Selection == nil {
+		return
+	}
+
+	mapImage.CopySelection()
+	mapImage.DeleteSelection()
+
+}
+
+// DeleteSelection clears the selected cells.
+func (mapImage *MapImage) DeleteSelection() {
+
+	if mapImage.Selection == nil {
+		return
+	}
+
+	mapImage.fillRegion(*mapImage.Selection, 0)
+	mapImage.Changed = true
+
+}
+
+// Paste writes the Map clipboard's cells at the top-left of the selection (or of the Map, if nothing is selected),
+// and selects them, so they can be moved into place.
+func (mapImage *MapImage) Paste() {
+
+	if len(mapClipboard) == 0 {
+		return
+	}
+
+	x, y := 0, 0
+
+	if mapImage.Selection != nil {
+		x, y = mapImage.Selection.X, mapImage.Selection.Y
+	}
+
+	region := mapImage.stamp(mapClipboard, x, y)
+	mapImage.Selection = &region
+	mapImage.Changed = true
+
+}
+
+// MoveSelection moves the selected cells by the number of cells given, leaving empty cells behind.
+func (mapImage *MapImage) MoveSelection(dx, dy int) {
+
+	if mapImage.Selection == nil || (dx == 0 && dy == 0) {
+		return
+	}
+
+	data := mapImage.regionData(*mapImage.Selection)
+	mapImage.fillRegion(*mapImage.Selection, 0)
+
+	region := mapImage.stamp(data, mapImage.Selection.X+dx, mapImage.Selection.Y+dy)
+	mapImage.Selection = &region
+	mapImage.Changed = true
+
+}
+
+// FlipSelection mirrors the selected cells horizontally or vertically.
+func (mapImage *MapImage) FlipSelection(horizontal bool) {
+
+	if mapImage.Selection == nil {
+		return
+	}
+
+	data := mapImage.regionData(*mapImage.Selection)
+	flipped := [][]int32{}
+
+	for y := range data {
+		row := []int32{}
+		for x := range data[y] {
+			if horizontal {
+				row = append(row, data[y][len(data[y])-1-x])
+			} else {
+				row = append(row, data[len(data)-1-y][x])
+			}
+		}
+		flipped = append(flipped, row)
+	}
+
+	mapImage.stamp(flipped, mapImage.Selection.X, mapImage.Selection.Y)
+	mapImage.Changed = true
+
+}
+
+// RotateSelection rotates the selected cells 90 degrees clockwise around the selection's top-left corner.
+func (mapImage *MapImage) RotateSelection() {
+
+	if mapImage.Selection == nil {
+		return
+	}
+
+	data := mapImage.regionData(*mapImage.Selection)
+	rotated := [][]int32{}
+
+	for x := 0; x < mapImage.Selection.W; x++ {
+		row := []int32{}
+		for y := mapImage.Selection.H - 1; y >= 0; y-- {
+			row = append(row, data[y][x])
+		}
+		rotated = append(rotated, row)
+	}
+
+	mapImage.fillRegion(*mapImage.Selection, 0)
+	region := mapImage.stamp(rotated, mapImage.Selection.X, mapImage.Selection.Y)
+	mapImage.Selection = &region
+	mapImage.Changed = true
+
+}
+
+// DrawTilePalette draws the tileset to the right of the Map while it's being edited, so tiles can be picked to paint with.
+func (mapImage *MapImage) DrawTilePalette() {
+
+	tileset, _ := mapImage.TilesetTexture()
+	count, columns := mapImage.TileCount()
+
+	if count <= 0 {
+		return
+	}
+
+	gs := float32(mapImage.Task.Board.Project.GridSize)
+	origin := rl.Vector2{mapImage.Task.Rect.X + mapImage.Task.Rect.Width + gs/2, mapImage.Task.Rect.Y + gs}
+	rows := (count + columns - 1) / columns
+
+	bg := rl.Rectangle{origin.X, origin.Y, float32(columns) * gs, float32(rows) * gs}
+	DrawRectExpanded(bg, 2, getThemeColor(GUI_OUTLINE))
+	rl.DrawRectangleRec(bg, getThemeColor(GUI_INSIDE))
+
+	mp := GetWorldMousePosition()
+
+	for i := int32(0); i < count; i++ {
+
+		dst := rl.Rectangle{origin.X + float32(i%columns)*gs, origin.Y + float32(i/columns)*gs, gs, gs}
+		rl.DrawTexturePro(tileset, mapImage.TileSource(i+1), dst, rl.Vector2{}, 0, rl.White)
+
+		if rl.CheckCollisionPointRec(mp, dst) && MousePressed(rl.MouseLeftButton) {
+			mapImage.SelectedTile = i + 1
+			ConsumeMouseInput(rl.MouseLeftButton)
+		}
+
+		if mapImage.SelectedTile == i+1 {
+			rl.DrawRectangleLinesEx(dst, 2, getThemeColor(GUI_OUTLINE_HIGHLIGHTED))
+		}
+
+	}
+
+}
+
+// FloodFill replaces the region of identical cells connected to the given cell on the current layer with the value given.
+func (mapImage *MapImage) FloodFill(x, y int, value int32) {
+
+	target := mapImage.Data[y][x]
+
+	if target == value {
+		return
+	}
+
+	queue := [][2]int{{x, y}}
+
+	for len(queue) > 0 {
+
+		cell := queue[0]
+		queue = queue[1:]
+
+		cx, cy := cell[0], cell[1]
+
+		if cx < 0 || cy < 0 || cx >= mapImage.cellWidth || cy >= mapImage.cellHeight || mapImage.Data[cy][cx] != target {
+			continue
+		}
+
+		mapImage.Data[cy][cx] = value
+
+		queue = append(queue, [2]int{cx + 1, cy}, [2]int{cx - 1, cy}, [2]int{cx, cy + 1}, [2]int{cx, cy - 1})
+
+	}
+
+	mapImage.Changed = true
+
+}
+
 func (mapImage *MapImage) TogglePencil() {
 
 	if mapImage.EditTool != MapEditToolPencil {
@@ -333,6 +1154,21 @@ func (mapImage *MapImage) ToggleRectangleTool() {
 	mapImage.Changed = true
 }
 
+// SetTool switches the Map's editing tool.
+func (mapImage *MapImage) SetTool(tool int) {
+	mapImage.EditTool = tool
+	mapImage.RectangleStart = []int{}
+}
+
+// ToggleTool switches to the editing tool given, or back to no tool if it was already active.
+func (mapImage *MapImage) ToggleTool(tool int) {
+	if mapImage.EditTool != tool {
+		mapImage.SetTool(tool)
+	} else {
+		mapImage.SetTool(MapEditToolNone)
+	}
+}
+
 func (mapImage *MapImage) Resize(w, h float32) {
 
 	ogW, ogH := mapImage.cellWidth, mapImage.cellHeight
@@ -370,12 +1206,22 @@ func (mapImage *MapImage) Copy(otherMapImage *MapImage) {
 
 	mapImage.Resize(otherMapImage.Width(), otherMapImage.Height())
 
-	for y := 0; y < len(mapImage.Data); y++ {
-		for x := 0; x < len(mapImage.Data[y]); x++ {
-			mapImage.Data[y][x] = otherMapImage.Data[y][x]
+	mapImage.Layers = []*MapLayer{}
+
+	for _, otherLayer := range otherMapImage.Layers {
+		layer := &MapLayer{Name: otherLayer.Name, Visible: otherLayer.Visible, Data: mapImage.newLayerData()}
+		for y := 0; y < len(layer.Data); y++ {
+			for x := 0; x < len(layer.Data[y]); x++ {
+				layer.Data[y][x] = otherLayer.Data[y][x]
+			}
 		}
+		mapImage.Layers = append(mapImage.Layers, layer)
 	}
 
+	mapImage.SetLayer(otherMapImage.LayerIndex)
+	mapImage.SetTileset(otherMapImage.TilesetPath, otherMapImage.TileSize)
+	mapImage.SelectedTile = otherMapImage.SelectedTile
+
 	mapImage.Changed = true
 
 }
@@ -414,6 +1260,7 @@ func (mapImage *MapImage) Shift(shiftX, shiftY int) {
 		}
 	}
 
+	mapImage.CurrentLayer().Data = newData
 	mapImage.Data = newData
 
 	mapImage.Changed = true
@@ -432,6 +1279,71 @@ func (mapImage *MapImage) Clear() {
 
 }
 
+// SerializeLayers returns the Map's layers as a JSON array, cropped to the Map's current size, with each layer's cells
+// packed (see packCells()). As Tasks are serialized constantly for undo, the result is cached until the Map changes.
+func (mapImage *MapImage) SerializeLayers() string {
+
+	if mapImage.serializedLayers != "" && !mapImage.Changed {
+		return mapImage.serializedLayers
+	}
+
+	layers := []packedMapLayer{}
+
+	for _, layer := range mapImage.Layers {
+
+		data := make([][]int32, mapImage.cellHeight)
+		for y := range data {
+			data[y] = layer.Data[y][:mapImage.cellWidth]
+		}
+
+		layers = append(layers, packedMapLayer{
+			Name:    layer.Name,
+			Visible: layer.Visible,
+			Width:   mapImage.cellWidth,
+			Height:  mapImage.cellHeight,
+			Cells:   packCells(data),
+		})
+
+	}
+
+	serialized, _ := json.Marshal(layers)
+	mapImage.serializedLayers = string(serialized)
+
+	return mapImage.serializedLayers
+
+}
+
+// DeserializeLayers replaces the Map's layers with the ones given, which may be smaller than the Map's full data size.
+func (mapImage *MapImage) DeserializeLayers(layers []*MapLayer, layerIndex int) {
+
+	mapImage.Layers = []*MapLayer{}
+
+	for _, serialized := range layers {
+
+		layer := &MapLayer{Name: serialized.Name, Visible: serialized.Visible, Data: mapImage.newLayerData()}
+
+		for y, row := range serialized.Data {
+			for x, value := range row {
+				if y < len(layer.Data) && x < len(layer.Data[y]) {
+					layer.Data[y][x] = value
+				}
+			}
+		}
+
+		mapImage.Layers = append(mapImage.Layers, layer)
+
+	}
+
+	if len(mapImage.Layers) == 0 {
+		mapImage.AddLayer()
+	}
+
+	mapImage.SetLayer(layerIndex)
+
+	mapImage.Changed = true
+
+}
+
 func (mapImage *MapImage) Width() float32 {
 	return float32(int32(mapImage.cellWidth) * mapImage.Task.Board.Project.GridSize)
 }
@@ -447,3 +1359,67 @@ func (mapImage *MapImage) CellWidth() int {
 func (mapImage *MapImage) CellHeight() int {
 	return mapImage.cellHeight
 }
+
+// mapLayersFromData reads the layers of a serialized Map Task in any version of the Map format (see MapFormat). Maps saved
+// before layers were added have a single layer of walls.
+func mapLayersFromData(taskData gjson.Result) ([]*MapLayer, error) {
+
+	readData := func(data gjson.Result) [][]int32 {
+		rows := [][]int32{}
+		for _, row := range data.Array() {
+			cells := []int32{}
+			for _, value := range row.Array() {
+				cells = append(cells, int32(value.Int()))
+			}
+			rows = append(rows, cells)
+		}
+		return rows
+	}
+
+	layers := []*MapLayer{}
+
+	// Maps saved before MapFormat was written are either version 0 or 1, depending on whether they have layers.
+	format := taskData.Get(`MapFormat`).Int()
+
+	if !taskData.Get(`MapFormat`).Exists() && taskData.Get(`MapLayers`).Exists() {
+		format = 1
+	}
+
+	if format > MapFormat {
+		return layers, fmt.Errorf("the Map was saved in a newer format (%d) than this version of MasterPlan can read", format)
+	}
+
+	if format == 0 {
+
+		if taskData.Get(`MapData`).Exists() {
+			layers = append(layers, &MapLayer{Name: "Layer 1", Visible: true, Data: readData(taskData.Get(`MapData`))})
+		}
+
+		return layers, nil
+
+	}
+
+	for _, layerData := range taskData.Get(`MapLayers`).Array() {
+
+		layer := &MapLayer{
+			Name:    layerData.Get(`Name`).String(),
+			Visible: layerData.Get(`Visible`).Bool(),
+		}
+
+		if format == 1 {
+			layer.Data = readData(layerData.Get(`Data`))
+		} else {
+			cells, err := unpackCells(layerData.Get(`Cells`).String(), int(layerData.Get(`Width`).Int()), int(layerData.Get(`Height`).Int()))
+			if err != nil {
+				return layers, fmt.Errorf("couldn't read layer %s: %s", layer.Name, err.Error())
+			}
+			layer.Data = cells
+		}
+
+		layers = append(layers, layer)
+
+	}
+
+	return layers, nil
+
+}
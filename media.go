@@ -0,0 +1,398 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goware/urlx"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Media files that Tasks refer to (Image Task images and Map tilesets) can be copied into a media folder beside the plan
+// file. Copies are named after a hash of their contents, so the same file is only stored once, and plans in the same
+// directory can share the folder. As local paths are saved relative to the plan file, a plan and its media folder can be
+// moved or shared together, and images that were downloaded keep working offline.
+
+const MediaFolderName = "media"
+
+// isRemotePath returns if the path given is a URL, rather than a local file path.
+func isRemotePath(path string) bool {
+	url, err := urlx.Parse(path)
+	return err == nil && url.Host != "" && url.Scheme != ""
+}
+
+// mediaDirectory returns the media folder for the plan file given.
+func mediaDirectory(planPath string) string {
+	return filepath.Join(filepath.Dir(planPath), MediaFolderName)
+}
+
+// inMediaDirectory returns if the file at the path given is already in the plan's media folder.
+func inMediaDirectory(planPath, path string) bool {
+	relative, err := filepath.Rel(mediaDirectory(planPath), path)
+	return err == nil && !strings.HasPrefix(relative, "..") && !strings.ContainsRune(relative, filepath.Separator)
+}
+
+// storeMedia copies the file at the path given into the plan's media folder, returning the path of the copy.
+func storeMedia(planPath, path string) (string, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return storeMediaData(planPath, data, filepath.Ext(path))
+
+}
+
+// storeMediaData writes the data given into the plan's media folder as a file with the given extension, returning its path.
+func storeMediaData(planPath string, data []byte, ext string) (string, error) {
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:16]) + strings.ToLower(ext)
+
+	dir := mediaDirectory(planPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	stored := filepath.Join(dir, name)
+
+	if !FileExists(stored) {
+		if err := ioutil.WriteFile(stored, data, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	return stored, nil
+
+}
+
+// mediaPathToData returns how a media path is saved in a plan; local files are saved relative to the plan file as an array
+// of path components (so the plan works across operating systems), while URLs are saved as-is.
+func mediaPathToData(planPath, path string) interface{} {
+
+	if path != "" && planPath != "" && !isRemotePath(path) {
+		if relative, err := filepath.Rel(filepath.Dir(planPath), path); err == nil {
+			return strings.Split(relative, string(filepath.Separator))
+		}
+	}
+
+	return path
+
+}
+
+// mediaPathFromData reads a media path saved with mediaPathToData, turning relative paths absolute.
+func mediaPathFromData(planPath string, data gjson.Result) string {
+
+	if !data.IsArray() {
+		return data.String()
+	}
+
+	// We need to go from the project file as the "root", as otherwise it will be relative
+	// to the current working directory (which is not ideal).
+	components := []string{filepath.Dir(planPath)}
+	for _, component := range data.Array() {
+		components = append(components, component.String())
+	}
+
+	abs, _ := filepath.Abs(strings.Join(components, string(filepath.Separator)))
+	return abs
+
+}
+
+// mediaKeys are the keys of serialized Task data that hold media paths.
+var mediaKeys = []string{`FilePath`, `MapTileset`}
+
+// resolvePlanMedia returns the plan data given with all media paths made absolute, so the plan's Tasks can be used outside
+// of a Project (for exporting, for example) without having to know where the plan file was.
+func resolvePlanMedia(planPath string, planData gjson.Result) gjson.Result {
+
+	raw := planData.Raw
+
+	for i, taskData := range planData.Get(`Tasks`).Array() {
+		for _, key := range mediaKeys {
+			if value := taskData.Get(key); value.Exists() {
+				raw, _ = sjson.Set(raw, fmt.Sprintf(`Tasks.%d.%s`, i, key), mediaPathFromData(planPath, value))
+			}
+		}
+	}
+
+	return gjson.Parse(raw)
+
+}
+
+// MediaPathToData returns how a media path should be saved in the Project.
+func (project *Project) MediaPathToData(path string) interface{} {
+	return mediaPathToData(project.FilePath, path)
+}
+
+// MediaPathFromData reads a media path saved in the Project.
+func (project *Project) MediaPathFromData(data gjson.Result) string {
+	return mediaPathFromData(project.FilePath, data)
+}
+
+// MediaReference is a media file that a Task refers to, along with a function to point the Task to another path.
+type MediaReference struct {
+	Task *Task
+	Path string
+	Key  string // The key the path is saved under in the Task's data (see mediaKeys)
+	Set  func(path string)
+}
+
+// MediaReferences returns the media files that the Project's Tasks refer to.
+func (project *Project) MediaReferences() []MediaReference {
+
+	references := []MediaReference{}
+
+	for _, task := range project.GetAllTasks() {
+
+		task := task
+
+		if task.UsesMedia() && task.FilePathTextbox.Text() != "" {
+			references = append(references, MediaReference{
+				Task: task,
+				Path: task.FilePathTextbox.Text(),
+				Key:  `FilePath`,
+				Set:  func(path string) { task.FilePathTextbox.SetText(path) },
+			})
+		} else if task.Is(TASK_TYPE_MAP) && task.MapImage != nil && task.MapImage.TilesetPath != "" {
+			references = append(references, MediaReference{
+				Task: task,
+				Path: task.MapImage.TilesetPath,
+				Key:  `MapTileset`,
+				Set: func(path string) {
+					task.MapImage.SetTileset(path, task.MapImage.TileSize)
+					task.MapTileset.SetText(path)
+				},
+			})
+		}
+
+	}
+
+	return references
+
+}
+
+// StoreMedia copies a file being added to the Project into its media folder if the Project is set to do so, returning the
+// path that should be used for it.
+func (project *Project) StoreMedia(path string) string {
+
+	if !project.StoreMediaInProject.Checked || project.FilePath == "" || isRemotePath(path) {
+		return path
+	}
+
+	stored, err := storeMedia(project.FilePath, path)
+
+	if err != nil {
+		project.Log("ERROR: Couldn't copy [%s] into the media folder: %s", path, err.Error())
+		return path
+	}
+
+	return stored
+
+}
+
+// CollectMedia copies every media file the Project's Tasks refer to, including downloaded images, into the media folder
+// and points the Tasks to the copies, returning how many were collected. If quiet is true, nothing is logged unless there's
+// something to report.
+func (project *Project) CollectMedia(quiet bool) int {
+	return project.collectMedia(quiet, nil)
+}
+
+// CollectTemporaryMedia moves media that only exists in the Project's temporary directory (like images pasted before the
+// Project was saved) into the media folder, as it would otherwise be lost when MasterPlan closes.
+func (project *Project) CollectTemporaryMedia() {
+	project.collectMedia(true, func(path string) bool {
+		relative, err := filepath.Rel(project.TempDir, path)
+		return !isRemotePath(path) && err == nil && !strings.HasPrefix(relative, "..")
+	})
+}
+
+func (project *Project) collectMedia(quiet bool, filter func(path string) bool) int {
+
+	if project.FilePath == "" {
+		project.Log("The Project has to be saved before its media can be collected.")
+		return 0
+	}
+
+	collected := 0
+	missing := []string{}
+	pending := 0
+
+	for _, reference := range project.MediaReferences() {
+
+		source := reference.Path
+
+		if filter != nil && !filter(source) {
+			continue
+		}
+
+		if isRemotePath(source) {
+			// Downloaded files can only be collected once they've finished downloading
+			resource := project.RetrieveResource(source)
+			if resource == nil || resource.State() != RESOURCE_STATE_READY {
+				pending++
+				continue
+			}
+			source = resource.LocalFilepath
+		}
+
+		if inMediaDirectory(project.FilePath, source) {
+			continue
+		}
+
+		if !FileExists(source) {
+			missing = append(missing, source)
+			continue
+		}
+
+		stored, err := storeMedia(project.FilePath, source)
+		if err != nil {
+			project.Log("ERROR: Couldn't copy [%s] into the media folder: %s", source, err.Error())
+			continue
+		}
+
+		reference.Set(stored)
+		project.repointUndoStates(reference, stored)
+		collected++
+
+	}
+
+	if collected > 0 || !quiet {
+		project.Log("Collected %d media file(s) into %s.", collected, mediaDirectory(project.FilePath))
+	}
+
+	if pending > 0 {
+		project.Log("%d media file(s) are still downloading and couldn't be collected yet.", pending)
+	}
+
+	if len(missing) > 0 {
+		project.Log("WARNING: %d media file(s) couldn't be found:\n%s", len(missing), strings.Join(missing, "\n"))
+	}
+
+	return collected
+
+}
+
+// repointUndoStates points the Task's undo states that refer to the media file given to where it's been collected to. As
+// the Task still shows the same thing, this isn't an undo step of its own (and so doesn't modify the Project, which matters
+// as media is collected while saving), and undoing other changes doesn't point the Task back to the original file.
+func (project *Project) repointUndoStates(reference MediaReference, path string) {
+
+	encode := func(value interface{}) string {
+		data, _ := sjson.Set(`{}`, `Path`, value)
+		return gjson.Get(data, `Path`).Raw
+	}
+
+	// Downloaded files are saved by their URL, rather than relative to the Project.
+	from := map[string]bool{encode(project.MediaPathToData(reference.Path)): true, encode(reference.Path): true}
+	to := project.MediaPathToData(path)
+
+	for _, board := range project.Boards {
+
+		frames := append([]*UndoFrame{board.UndoHistory.CurrentFrame}, board.UndoHistory.Frames...)
+
+		for _, frame := range frames {
+			if state, exists := frame.States[reference.Task]; exists && from[gjson.Get(state.Serialized, reference.Key).Raw] {
+				state.Serialized, _ = sjson.Set(state.Serialized, reference.Key, to)
+			}
+		}
+
+	}
+
+}
+
+// MissingMedia returns the local media files the Project's Tasks refer to that don't exist.
+func (project *Project) MissingMedia() []string {
+
+	missing := []string{}
+
+	for _, reference := range project.MediaReferences() {
+		if !isRemotePath(reference.Path) && !FileExists(reference.Path) {
+			missing = append(missing, reference.Path)
+		}
+	}
+
+	return missing
+
+}
+
+// collectPlanMedia lists the media files a plan refers to, noting missing ones, and collects them into the plan's media
+// folder if asked to.
+func collectPlanMedia(args []string) error {
+
+	if len(args) < 1 || (len(args) > 1 && args[1] != "collect") {
+		return fmt.Errorf("usage: masterplan media <plan file> [collect]")
+	}
+
+	planPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	planFile, err := ioutil.ReadFile(planPath)
+	if err != nil {
+		return err
+	}
+
+	collect := len(args) > 1
+	raw := string(planFile)
+	missing, collected := 0, 0
+
+	for i, taskData := range gjson.Parse(raw).Get(`Tasks`).Array() {
+
+		for _, key := range mediaKeys {
+
+			value := taskData.Get(key)
+			if !value.Exists() || value.String() == "" {
+				continue
+			}
+
+			path := mediaPathFromData(planPath, value)
+
+			status := "ok"
+			if isRemotePath(path) {
+				status = "remote"
+			} else if !FileExists(path) {
+				status = "missing"
+				missing++
+			} else if collect && !inMediaDirectory(planPath, path) {
+
+				stored, err := storeMedia(planPath, path)
+				if err != nil {
+					return err
+				}
+
+				raw, _ = sjson.Set(raw, fmt.Sprintf(`Tasks.%d.%s`, i, key), mediaPathToData(planPath, stored))
+				status = "collected"
+				collected++
+
+			}
+
+			fmt.Fprintf(terminalOutput, "%-9s %s\n", status, path)
+
+		}
+
+	}
+
+	if collected > 0 {
+		raw = gjson.Parse(raw).Get("@pretty").String()
+		if err := ioutil.WriteFile(planPath, []byte(raw), 0666); err != nil {
+			return err
+		}
+		fmt.Fprintf(terminalOutput, "Collected %d media file(s) into %s.\n", collected, mediaDirectory(planPath))
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("%d media file(s) are missing", missing)
+	}
+
+	return nil
+
+}
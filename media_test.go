@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestCollectMediaKeepsUndoHistory(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "masterplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	image := filepath.Join(dir, "picture.png")
+
+	if err := ioutil.WriteFile(image, []byte("not really a png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project := newTestProject()
+	project.FilePath = filepath.Join(dir, "plan", "test.plan")
+
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	task := newTestTask(board, TASK_TYPE_IMAGE, "", 0, 0, 4, 4)
+	task.FilePathTextbox.SetText(image)
+
+	board.UndoHistory.Capture(NewUndoState(task), false)
+	board.UndoHistory.Update()
+
+	if collected := project.CollectMedia(true); collected != 1 {
+		t.Fatalf("expected the image to be collected, but %d files were", collected)
+	}
+
+	stored := task.FilePathTextbox.Text()
+
+	if !inMediaDirectory(project.FilePath, stored) {
+		t.Fatalf("expected the Task to point to the collected image, got %s", stored)
+	}
+
+	// Collecting media (which happens when saving) isn't a change to the Task of its own.
+	if task.UndoChange || board.UndoHistory.Changed || len(board.UndoHistory.Frames) != 1 {
+		t.Fatal("expected collecting media not to add an undo step")
+	}
+
+	if path := project.MediaPathFromData(gjson.Get(board.UndoHistory.Frames[0].States[task].Serialized, `FilePath`)); path != stored {
+		t.Fatalf("expected the Task's undo state to point to the collected image, got %s", path)
+	}
+
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// The minimap is an overview of the current Board, drawn in the bottom-right corner above the status bar. It shows every
+// Task as a small block (with search matches and overdue Tasks picked out) and the area the camera can currently see;
+// clicking or dragging on it pans the camera there.
+
+const (
+	minimapMaxWidth  = 240
+	minimapMaxHeight = 160
+)
+
+var minimapOverdueColor = rl.Color{225, 70, 70, 255}
+
+// boardBounds returns the area the Tasks on the Board take up.
+func (board *Board) boardBounds() (rl.Rectangle, bool) {
+
+	var bounds rl.Rectangle
+	found := false
+
+	for _, task := range board.Tasks {
+
+		if !task.Valid {
+			continue
+		}
+
+		if !found {
+			bounds = task.Rect
+			found = true
+		} else {
+			bounds = exportUnion(bounds, task.Rect)
+		}
+
+	}
+
+	return bounds, found
+
+}
+
+// cameraViewRect returns the area of the Board the camera can currently see.
+func cameraViewRect() rl.Rectangle {
+	w := float32(rl.GetScreenWidth()) / camera.Zoom
+	h := float32(rl.GetScreenHeight()) / camera.Zoom
+	return rl.Rectangle{camera.Target.X - w/2, camera.Target.Y - h/2, w, h}
+}
+
+// IsOverdue returns if the Task has a deadline that has passed without it being completed.
+func (task *Task) IsOverdue() bool {
+	return task.DeadlineOn.Checked && !task.IsComplete() && deadlineAlignment(task) < 0
+}
+
+// DrawMinimap draws the minimap if it's open, and pans the camera when it's clicked or dragged on.
+func (project *Project) DrawMinimap() {
+
+	project.MinimapRect = rl.Rectangle{}
+
+	if !project.MinimapOpen {
+		project.minimapDragging = false
+		return
+	}
+
+	board := project.CurrentBoard()
+	view := cameraViewRect()
+
+	// The minimap covers both the Tasks and what the camera can see, so the viewport never leaves it
+	area, hasTasks := board.boardBounds()
+	if hasTasks {
+		area = exportUnion(area, view)
+	} else {
+		area = view
+	}
+
+	margin := float32(project.GridSize) * 4
+	area.X -= margin
+	area.Y -= margin
+	area.Width += margin * 2
+	area.Height += margin * 2
+
+	// Panning changes what the camera can see, and so the area the minimap covers; it's held as it was when the drag
+	// started, so the point under the mouse doesn't shift (and the minimap doesn't resize) while dragging
+	if project.minimapDragging {
+		area = project.minimapArea
+	}
+
+	scale := float32(math.Min(minimapMaxWidth/float64(area.Width), minimapMaxHeight/float64(area.Height)))
+
+	rect := rl.Rectangle{0, 0, area.Width * scale, area.Height * scale}
+	rect.X = float32(rl.GetScreenWidth()) - rect.Width - 16
+	rect.Y = project.StatusBar.Y - rect.Height - 8
+
+	project.MinimapRect = rect
+
+	toMinimap := func(r rl.Rectangle) rl.Rectangle {
+		mapped := rl.Rectangle{rect.X + (r.X-area.X)*scale, rect.Y + (r.Y-area.Y)*scale, r.Width * scale, r.Height * scale}
+		// Tasks should still show up, however small they get
+		if mapped.Width < 1 {
+			mapped.Width = 1
+		}
+		if mapped.Height < 1 {
+			mapped.Height = 1
+		}
+		return mapped
+	}
+
+	bgColor := getThemeColor(GUI_INSIDE_DISABLED)
+	bgColor.A = 224
+	rl.DrawRectangleRec(rect, bgColor)
+
+	searched := map[*Task]bool{}
+	for _, task := range project.SearchedTasks {
+		searched[task] = true
+	}
+
+	// Search matches and overdue Tasks are drawn last, so they aren't hidden underneath other Tasks
+	highlighted := []*Task{}
+
+	for _, task := range board.Tasks {
+
+		if !task.Valid || task.Is(TASK_TYPE_LINE) || (task.FilteredOut && project.FilterHides()) {
+			continue
+		}
+
+		if searched[task] || task.IsOverdue() {
+			highlighted = append(highlighted, task)
+			continue
+		}
+
+		color := getThemeColor(GUI_INSIDE)
+		if task.IsCompletable() && task.IsComplete() {
+			color = getThemeColor(GUI_INSIDE_HIGHLIGHTED)
+		} else if task.Is(TASK_TYPE_NOTE) {
+			color = getThemeColor(GUI_NOTE_COLOR)
+		}
+
+		if task.FilteredOut {
+			color.A = 64
+		}
+
+		rl.DrawRectangleRec(toMinimap(task.Rect), color)
+
+	}
+
+	for _, task := range highlighted {
+
+		r := toMinimap(task.Rect)
+
+		if task.IsOverdue() {
+			rl.DrawRectangleRec(r, minimapOverdueColor)
+		} else {
+			rl.DrawRectangleRec(r, getThemeColor(GUI_INSIDE))
+		}
+
+		if searched[task] {
+			// Search matches get an outline a little larger than themselves, so even tiny ones stand out
+			r.X -= 2
+			r.Y -= 2
+			r.Width += 4
+			r.Height += 4
+			rl.DrawRectangleLinesEx(r, 1, getThemeColor(GUI_OUTLINE_HIGHLIGHTED))
+		}
+
+	}
+
+	rl.DrawRectangleLinesEx(toMinimap(view), 1, getThemeColor(GUI_FONT_COLOR))
+	rl.DrawRectangleLinesEx(rect, 1, getThemeColor(GUI_OUTLINE))
+
+	if project.TaskOpen || project.PopupAction != "" {
+		project.minimapDragging = false
+		return
+	}
+
+	if MousePressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(GetMousePosition(), rect) {
+		project.minimapDragging = true
+		project.minimapArea = area
+	}
+
+	if !MouseDown(rl.MouseLeftButton) && !MousePressed(rl.MouseLeftButton) {
+		project.minimapDragging = false
+	}
+
+	if project.minimapDragging {
+
+		mouse := GetMousePosition()
+
+		// The camera's pan is the negative of the point it's centered on
+		project.CameraPan.X = -(area.X + (mouse.X-rect.X)/scale)
+		project.CameraPan.Y = -(area.Y + (mouse.Y-rect.Y)/scale)
+
+		ConsumeMouseInput(rl.MouseLeftButton)
+
+	}
+
+}
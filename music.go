@@ -0,0 +1,179 @@
+package main
+
+/*
+#include <stdbool.h>
+#include <stdint.h>
+
+// raylib's Music, as it's laid out in C; the Go binding's fields don't line up with it (its SampleCount covers looping,
+// and its LoopCount is really sampleCount), so the fields needed are set through this instead.
+typedef struct {
+	int ctxType;
+	void *ctxData;
+	bool looping;
+	unsigned int sampleCount;
+	unsigned int sampleRate;
+	unsigned int sampleSize;
+	unsigned int channels;
+	void *buffer;
+} music;
+
+// raylib can't seek music streams, but the decoders it streams them with can; they're compiled into raylib's audio module.
+uint32_t drwav_seek_to_pcm_frame(void *wav, uint64_t frame);
+uint32_t drmp3_seek_to_pcm_frame(void *mp3, uint64_t frame);
+int stb_vorbis_seek_frame(void *vorbis, unsigned int frame);
+
+static bool seekMusic(music *m, unsigned int frame) {
+	switch (m->ctxType) {
+		case 0: return drwav_seek_to_pcm_frame(m->ctxData, frame);
+		case 1: return stb_vorbis_seek_frame(m->ctxData, frame);
+		case 3: return drmp3_seek_to_pcm_frame(m->ctxData, frame);
+	}
+	return false;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// MusicStream is a Media Task's own stream of an audio file, so that Tasks playing the same file don't share (and fight
+// over) a single stream. Unlike raylib's music streams, it can start playing from any point in the file.
+type MusicStream struct {
+	Music   rl.Music
+	Playing bool
+
+	samples uint32  // The length of the file in samples; the stream's own count is shortened when starting partway through
+	offset  float32 // Where in the file the stream started playing from, in seconds
+	played  float32
+}
+
+func NewMusicStream(path string) (*MusicStream, error) {
+
+	if !rl.IsAudioDeviceReady() {
+		return nil, errors.New("no audio device available")
+	}
+
+	stream := &MusicStream{Music: rl.LoadMusicStream(path)}
+
+	if stream.Music.CtxData == nil {
+		return nil, errors.New("unsupported audio format")
+	}
+
+	stream.samples = uint32(stream.c().sampleCount)
+
+	return stream, nil
+
+}
+
+func (stream *MusicStream) c() *C.music {
+	return (*C.music)(unsafe.Pointer(&stream.Music))
+}
+
+// musicLayout returns the size of the music struct above, and the offsets of its fields, in the order of the fields of
+// rl.Music they're read through; as streams are cast from one to the other, the two have to line up.
+func musicLayout() (uintptr, []uintptr) {
+	m := C.music{}
+	return unsafe.Sizeof(m), []uintptr{
+		unsafe.Offsetof(m.ctxType),
+		unsafe.Offsetof(m.ctxData),
+		unsafe.Offsetof(m.looping),
+		unsafe.Offsetof(m.sampleCount),
+		unsafe.Offsetof(m.sampleRate),
+		unsafe.Offsetof(m.sampleSize),
+		unsafe.Offsetof(m.channels),
+		unsafe.Offsetof(m.buffer),
+	}
+}
+
+// CanSeek returns if the stream can start playing partway through the file. Only WAV, OGG and MP3 files can (see
+// seekMusic() above); others, like FLAC files, always play from the start.
+func (stream *MusicStream) CanSeek() bool {
+	ctxType := stream.c().ctxType
+	return ctxType == 0 || ctxType == 1 || ctxType == 3
+}
+
+// Length returns the length of the file in seconds.
+func (stream *MusicStream) Length() float32 {
+	return float32(stream.samples) / float32(stream.Music.Stream.Channels) / float32(stream.Music.Stream.SampleRate)
+}
+
+// Position returns how far into the file the stream has played, in seconds.
+func (stream *MusicStream) Position() float32 {
+	if !stream.Playing {
+		return stream.offset
+	}
+	return stream.offset + rl.GetMusicTimePlayed(stream.Music)
+}
+
+// Play starts playing the file from the position given, in seconds. As it can start anywhere, pausing the stream is just
+// stopping it and playing it again from where it was.
+func (stream *MusicStream) Play(position float32) {
+
+	stream.Stop()
+
+	frame := uint32(position * float32(stream.Music.Stream.SampleRate))
+	skipped := frame * stream.Music.Stream.Channels
+
+	if position <= 0 || skipped >= stream.samples || !C.seekMusic(stream.c(), C.uint(frame)) {
+		frame, skipped = 0, 0
+	}
+
+	// Playing restarts the stream's count of samples played, so the samples skipped are taken off the stream's length,
+	// and added on when working out its position.
+	stream.c().sampleCount = C.uint(stream.samples - skipped)
+	stream.offset = float32(frame) / float32(stream.Music.Stream.SampleRate)
+	stream.played = 0
+
+	rl.PlayMusicStream(stream.Music)
+
+	stream.Playing = true
+
+}
+
+// Stop stops the stream; raylib seeks back to the start of the file when stopping, so it plays from there next time.
+func (stream *MusicStream) Stop() {
+
+	if stream.Playing {
+		rl.StopMusicStream(stream.Music)
+	}
+
+	stream.c().sampleCount = C.uint(stream.samples)
+	stream.offset = 0
+	stream.Playing = false
+
+}
+
+// Update streams the file, looping back to the start when it's done if loop is true. It has to be called every frame
+// while the stream is playing.
+func (stream *MusicStream) Update(loop bool) {
+
+	if !stream.Playing {
+		return
+	}
+
+	stream.c().looping = C.bool(loop)
+
+	rl.UpdateMusicStream(stream.Music)
+
+	played := rl.GetMusicTimePlayed(stream.Music)
+
+	if !rl.IsMusicPlaying(stream.Music) {
+		stream.Stop()
+	} else if played < stream.played {
+		// The stream looped back to the start of the file, so it's the whole file again from here.
+		stream.c().sampleCount = C.uint(stream.samples)
+		stream.offset = 0
+	}
+
+	stream.played = played
+
+}
+
+func (stream *MusicStream) Destroy() {
+	stream.Stop()
+	rl.UnloadMusicStream(stream.Music)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"unsafe"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestMusicLayout(t *testing.T) {
+
+	size, offsets := musicLayout()
+
+	music := rl.Music{}
+
+	// The binding's names for the fields are off (see the music struct in music.go), so they're listed by position.
+	fields := []uintptr{
+		unsafe.Offsetof(music.CtxType),
+		unsafe.Offsetof(music.CtxData),
+		unsafe.Offsetof(music.SampleCount),
+		unsafe.Offsetof(music.LoopCount),
+		unsafe.Offsetof(music.Stream) + unsafe.Offsetof(music.Stream.SampleRate),
+		unsafe.Offsetof(music.Stream) + unsafe.Offsetof(music.Stream.SampleSize),
+		unsafe.Offsetof(music.Stream) + unsafe.Offsetof(music.Stream.Channels),
+		unsafe.Offsetof(music.Stream) + unsafe.Offsetof(music.Stream.Buffer),
+	}
+
+	if unsafe.Sizeof(music) != size {
+		t.Fatalf("expected rl.Music to be %d bytes, like raylib's Music, got %d", size, unsafe.Sizeof(music))
+	}
+
+	for i, offset := range fields {
+		if offset != offsets[i] {
+			t.Errorf("expected field %d of rl.Music to be at %d, like raylib's Music, got %d", i, offsets[i], offset)
+		}
+	}
+
+}
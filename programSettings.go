@@ -41,8 +41,14 @@ type ProgramSettings struct {
 	Theme                     string
 	DrawWindowBorder          bool
 	DownloadTimeout           int
+	ResourceCacheLimit        int
 	CopyTasksToClipboard      bool
 	DoubleClickRate           int
+	SyncName                  string
+	SyncPort                  int
+	AutomationAPI             bool
+	AutomationAPIPort         int
+	TrustedPlans              []TrustedPlan
 }
 
 func NewProgramSettings() ProgramSettings {
@@ -61,8 +67,12 @@ func NewProgramSettings() ProgramSettings {
 		ScrollwheelSensitivity: 1,
 		Theme:                  "Sunlight", // Default theme
 		DownloadTimeout:        4,
+		ResourceCacheLimit:     256,
 		CopyTasksToClipboard:   true,
 		DoubleClickRate:        500,
+		SyncPort:               8765,
+		AutomationAPIPort:      8766,
+		TrustedPlans:           []TrustedPlan{},
 	}
 
 	return ps
@@ -70,11 +70,17 @@ const (
 
 	// Project actions
 
-	ActionNewProject    = "new"
-	ActionLoadProject   = "load"
-	ActionSaveAsProject = "save as"
-	ActionRenameBoard   = "rename"
-	ActionQuit          = "quit"
+	ActionNewProject      = "new"
+	ActionLoadProject     = "load"
+	ActionSaveAsProject   = "save as"
+	ActionRenameBoard     = "rename"
+	ActionQuit            = "quit"
+	ActionJoinSession     = "join session"
+	ActionJoinSessionCode = "join session code"
+	ActionTrustCommands   = "trust commands"
+	ActionWhiteboardLabel = "whiteboard label"
+	ActionSaveFilter      = "save filter"
+	ActionAddBookmark     = "add bookmark"
 
 	BackupDelineator = "_bak_"
 	FileTimeFormat   = "01_02_06_15_04_05"
@@ -88,11 +94,13 @@ type Project struct {
 	TaskShadowSpinner           *Spinner
 	GridVisible                 *Checkbox
 	ShowIcons                   *Checkbox
+	ShowTagChips                *Checkbox
 	PulsingTaskSelection        *Checkbox
 	AutoSave                    *Checkbox
 	OutlineTasks                *Checkbox
 	BracketSubtasks             *Checkbox
 	LockProject                 *Checkbox
+	TrustCommands               *Checkbox
 	NumberingSequence           *Spinner
 	NumberTopLevel              *Checkbox
 	AutomaticBackupInterval     *NumberSpinner
@@ -100,6 +108,8 @@ type Project struct {
 	MaxUndoSteps                *NumberSpinner
 	TaskTransparency            *NumberSpinner
 	AlwaysShowURLButtons        *Checkbox
+	StoreMediaInProject         *Checkbox
+	TeamRoster                  *Textbox
 	SettingsSection             *ButtonGroup
 	IncompleteTasksGlow         *Checkbox
 	CompleteTasksGlow           *Checkbox
@@ -139,8 +149,15 @@ type Project struct {
 	GUIFontSizeMultiplier     *ButtonGroup
 	DisableAboutDialogOnStart *Checkbox
 	DownloadTimeout           *NumberSpinner
+	ResourceCacheLimit        *NumberSpinner
+	ResourceCacheUsage        *Label
+	ClearResourceCacheButton  *Button
 	CopyTasksToClipboard      *Checkbox
 	DoubleClickRate           *NumberSpinner
+	SyncName                  *Textbox
+	SyncPort                  *NumberSpinner
+	AutomationAPI             *Checkbox
+	AutomationAPIPort         *NumberSpinner
 
 	// Internal data to make stuff work
 	FilePath            string
@@ -176,6 +193,34 @@ type Project struct {
 	SearchedTasks        []*Task
 	FocusedSearchTask    int
 	Searchbar            *Textbox
+	SearchError          error
+	SearchIndex          *SearchIndex
+	SearchResultsOpen    bool
+	SearchResultsPanel   rl.Rectangle
+	SavedFilters         []*SavedFilter
+	ActiveFilter         *SavedFilter
+	FilterDropdown       *DropdownMenu
+	FilterMenuPanel      rl.Rectangle
+	TagSummaryOpen       bool
+	TagSummaryPanel      rl.Rectangle
+	MinimapOpen          bool
+	MinimapRect          rl.Rectangle
+	minimapDragging      bool
+	minimapArea          rl.Rectangle
+	boardParentCache     map[*Board]*Board
+	BookmarkListOpen     bool
+	ShowArchivedBoards   bool
+	draggingBoard        *Board
+	SendToBoardOpen      bool
+	SendToBoardPanel     rl.Rectangle
+	BookmarkPanel        rl.Rectangle
+	Presenting           bool
+	PresentationIndex    int
+	presentationStepTime float32
+	// presentingFromPan and presentingFromZoom are where the camera was before presenting.
+	presentingFromPan    rl.Vector2
+	presentingFromZoom   int
+	activeFilterQuery    *Query
 	StatusBar            rl.Rectangle
 	GUI_Icons            rl.Texture2D
 	Patterns             rl.Texture2D
@@ -183,6 +228,8 @@ type Project struct {
 	PreviousTaskType     int
 	Resources            map[string]*Resource
 	DownloadingResources map[string]*Resource
+	RefreshedResources   chan string
+	Exported             chan string
 	Modified             bool
 	Locked               bool
 
@@ -191,6 +238,11 @@ type Project struct {
 	PopupPanel                 *Panel
 	PopupAction                string
 	PopupArgument              string
+	joinAddress                string
+	trustListed                string
+	trustScroll                int
+	trustSeen                  int
+	WhiteboardLabelTarget      *Whiteboard
 	SettingsPanel              *Panel
 	BackupTimer                time.Time
 	UndoFade                   *gween.Sequence
@@ -201,6 +253,12 @@ type Project struct {
 	Time                       float32
 	firstFreeTaskID            int
 	ScreenSize                 rl.Vector2
+	Sync                       *SyncClient
+	Hooks                      *ScriptHooks
+	PendingCommands            []PendingCommand
+	CommandsDenied             bool
+	TrustedCommands            string
+	SyncServer                 *SyncServer
 }
 
 func NewProject() *Project {
@@ -220,7 +278,11 @@ func NewProject() *Project {
 		Patterns:             rl.LoadTexture(LocalPath("assets", "patterns.png")),
 		Resources:            map[string]*Resource{},
 		DownloadingResources: map[string]*Resource{},
+		RefreshedResources:   make(chan string, 64),
+		Exported:             make(chan string, 16),
 		LoadRecentDropdown:   NewDropdown(0, 0, 0, 0, "Load Recent..."), // Position and size is set below in the context menu handling
+		FilterDropdown:       &DropdownMenu{Name: "Filter", ChoiceIndex: -1, Upward: true},
+		SearchIndex:          NewSearchIndex(),
 		UndoFade:             gween.NewSequence(gween.New(0, 192, 0.25, ease.InOutExpo), gween.New(192, 0, 0.25, ease.InOutExpo)),
 
 		PopupPanel:    NewPanel(0, 0, 800, 640),
@@ -231,18 +293,22 @@ func NewProject() *Project {
 		OutlineTasks:                NewCheckbox(0, 0, 32, 32),
 		GridVisible:                 NewCheckbox(0, 0, 32, 32),
 		ShowIcons:                   NewCheckbox(0, 0, 32, 32),
+		ShowTagChips:                NewCheckbox(0, 0, 32, 32),
 		NumberingSequence:           NewSpinner(0, 0, 192, 32, "1.1.", "1-1)", "I.I.", "Bullets", "Off"),
 		NumberTopLevel:              NewCheckbox(0, 0, 32, 32),
 		PulsingTaskSelection:        NewCheckbox(0, 0, 32, 32),
 		AutoSave:                    NewCheckbox(0, 0, 32, 32),
 		BracketSubtasks:             NewCheckbox(0, 0, 32, 32),
 		LockProject:                 NewCheckbox(0, 0, 32, 32),
+		TrustCommands:               NewCheckbox(0, 0, 32, 32),
 		AutomaticBackupInterval:     NewNumberSpinner(0, 0, 128, 40),
 		AutomaticBackupKeepCount:    NewNumberSpinner(0, 0, 128, 40),
 		MaxUndoSteps:                NewNumberSpinner(0, 0, 192, 40),
 		DoubleClickRate:             NewNumberSpinner(0, 0, 192, 40),
 		TaskTransparency:            NewNumberSpinner(0, 0, 128, 40),
 		AlwaysShowURLButtons:        NewCheckbox(0, 0, 32, 32),
+		StoreMediaInProject:         NewCheckbox(0, 0, 32, 32),
+		TeamRoster:                  NewTextbox(0, 0, 400, 32),
 		SettingsSection:             NewButtonGroup(0, 0, 700, 32, 1, "General", "Tasks", "Global", "Shortcuts", "About"),
 		RebindingButtons:            []*Button{},
 		DefaultRebindingButtons:     []*Button{},
@@ -286,13 +352,22 @@ func NewProject() *Project {
 		DrawWindowBorder:          NewCheckbox(0, 0, 32, 32),
 		SaveWindowPosition:        NewCheckbox(0, 0, 32, 32),
 		DownloadTimeout:           NewNumberSpinner(0, 0, 128, 40),
+		ResourceCacheLimit:        NewNumberSpinner(0, 0, 160, 40),
+		ResourceCacheUsage:        NewLabel(""),
+		ClearResourceCacheButton:  NewButton(0, 0, 192, 32, "Clear Image Cache", false),
 		CopyTasksToClipboard:      NewCheckbox(0, 0, 32, 32),
+		SyncName:                  NewTextbox(0, 0, 256, 32),
+		SyncPort:                  NewNumberSpinner(0, 0, 192, 40),
+		AutomationAPI:             NewCheckbox(0, 0, 32, 32),
+		AutomationAPIPort:         NewNumberSpinner(0, 0, 192, 40),
 		GrabClient:                grab.NewClient(),
 		LogOn:                     true,
 	}
 
 	project.PopupPanel.Resizeable = false
 
+	project.Hooks = NewScriptHooks(project)
+
 	project.AutomaticBackupInterval.Textbox.SpecialZero = "Off"
 	project.MaxUndoSteps.Textbox.SpecialZero = "Unlimited"
 
@@ -321,6 +396,8 @@ func NewProject() *Project {
 
 	project.CustomFontPath.VerticalAlignment = ALIGN_CENTER
 	project.ScreenshotsPath.VerticalAlignment = ALIGN_CENTER
+	project.TeamRoster.VerticalAlignment = ALIGN_CENTER
+	project.TeamRoster.AllowNewlines = false
 
 	column = project.SettingsPanel.AddColumn()
 	row = column.Row()
@@ -332,11 +409,21 @@ func NewProject() *Project {
 	project.TableColumnVerticalSpacing.Maximum = 1000
 	project.TableColumnVerticalSpacing.Step = 10
 	project.DownloadTimeout.Minimum = 1
+	project.ResourceCacheLimit.Minimum = 0
+	project.ResourceCacheLimit.Step = 32
+	project.ResourceCacheLimit.Textbox.SpecialZero = "Off"
 
 	project.DoubleClickRate.Minimum = 100
 	project.DoubleClickRate.Maximum = 10000
 	project.DoubleClickRate.Step = 50
 
+	project.SyncName.AllowNewlines = false
+	project.SyncName.VerticalAlignment = ALIGN_CENTER
+	project.SyncPort.Minimum = 1024
+	project.SyncPort.Maximum = 65535
+	project.AutomationAPIPort.Minimum = 1024
+	project.AutomationAPIPort.Maximum = 65535
+
 	// General settings
 
 	column.DefaultVerticalSpacing = 24
@@ -357,6 +444,14 @@ func NewProject() *Project {
 	row.Item(NewLabel("Lock Project:"), SETTINGS_GENERAL)
 	row.Item(project.LockProject, SETTINGS_GENERAL)
 
+	row = column.Row()
+	row.Item(NewLabel("Trust Project to Run\nTask Commands:"), SETTINGS_GENERAL)
+	row.Item(project.TrustCommands, SETTINGS_GENERAL)
+
+	row = column.Row()
+	row.Item(NewLabel("Copy Media Into Project's\nMedia Folder:"), SETTINGS_GENERAL)
+	row.Item(project.StoreMediaInProject, SETTINGS_GENERAL)
+
 	row = column.Row()
 	row.Item(NewLabel("Maximum Undo Steps:"), SETTINGS_GENERAL)
 	row.Item(project.MaxUndoSteps, SETTINGS_GENERAL)
@@ -381,6 +476,11 @@ func NewProject() *Project {
 	row.Item(project.ScreenshotsPath, SETTINGS_GENERAL)
 	row.Item(project.ScreenshotsPathBrowseButton, SETTINGS_GENERAL)
 
+	row = column.Row()
+	row.Item(NewLabel("Team Roster (names Tasks can be assigned to, separated by commas):"), SETTINGS_GENERAL)
+	row = column.Row()
+	row.Item(project.TeamRoster, SETTINGS_GENERAL)
+
 	// Tasks
 
 	row = column.Row()
@@ -404,6 +504,10 @@ func NewProject() *Project {
 	row.Item(NewLabel("Show Icons:"), SETTINGS_TASKS)
 	row.Item(project.ShowIcons, SETTINGS_TASKS)
 
+	row = column.Row()
+	row.Item(NewLabel("Show Tags Beside Tasks:"), SETTINGS_TASKS)
+	row.Item(project.ShowTagChips, SETTINGS_TASKS)
+
 	row = column.Row()
 	row.Item(NewLabel("Numbering Style:"), SETTINGS_TASKS)
 	row.Item(project.NumberingSequence, SETTINGS_TASKS)
@@ -531,6 +635,21 @@ func NewProject() *Project {
 	row.Item(NewLabel("Download Time-out (In Seconds):"), SETTINGS_GLOBAL)
 	row.Item(project.DownloadTimeout, SETTINGS_GLOBAL)
 
+	row = column.Row()
+	row.Item(NewLabel("Downloaded Image Cache\nSize Limit (In MB):"), SETTINGS_GLOBAL)
+	row.Item(project.ResourceCacheLimit, SETTINGS_GLOBAL)
+
+	row = column.Row()
+	row.Item(project.ResourceCacheUsage, SETTINGS_GLOBAL)
+	row.Item(project.ClearResourceCacheButton, SETTINGS_GLOBAL)
+
+	row = column.Row()
+	row.Item(NewLabel("Enable Automation API\n(Local Connections Only):"), SETTINGS_GLOBAL)
+	row.Item(project.AutomationAPI, SETTINGS_GLOBAL)
+
+	row.Item(NewLabel("Automation API Port:"), SETTINGS_GLOBAL)
+	row.Item(project.AutomationAPIPort, SETTINGS_GLOBAL)
+
 	row = column.Row()
 	row.Item(NewLabel("Pan to Cursor When\nZooming In:"), SETTINGS_GLOBAL)
 	row.Item(project.PanToFocusOnZoom, SETTINGS_GLOBAL)
@@ -538,6 +657,21 @@ func NewProject() *Project {
 	row.Item(NewLabel("Save Window\nPosition On Exit:"), SETTINGS_GLOBAL)
 	row.Item(project.SaveWindowPosition, SETTINGS_GLOBAL)
 
+	// LAN Sessions
+
+	row = column.Row()
+	label = NewLabel("LAN Sessions")
+	label.Underline = true
+	row.Item(label, SETTINGS_GLOBAL)
+
+	row = column.Row()
+	row.Item(NewLabel("Name Shown to Others:"), SETTINGS_GLOBAL)
+	row.Item(project.SyncName, SETTINGS_GLOBAL)
+
+	row = column.Row()
+	row.Item(NewLabel("Port to Host Sessions On:"), SETTINGS_GLOBAL)
+	row.Item(project.SyncPort, SETTINGS_GLOBAL)
+
 	row = column.Row()
 	label = NewLabel("Window Alterations (Requires Restart)")
 	label.Underline = true
@@ -654,6 +788,7 @@ func NewProject() *Project {
 	project.TaskShadowSpinner.CurrentChoice = 2
 	project.GridVisible.Checked = true
 	project.ShowIcons.Checked = true
+	project.ShowTagChips.Checked = true
 	project.DoubleClickTimer = -1
 	project.PreviousTaskType = TASK_TYPE_BOOLEAN
 	project.NumberTopLevel.Checked = true
@@ -664,6 +799,7 @@ func NewProject() *Project {
 	project.IncompleteTasksGlow.Checked = true
 	project.CompleteTasksGlow.Checked = true
 	project.SelectedTasksGlow.Checked = true
+	project.StoreMediaInProject.Checked = true
 
 	project.FontSize.Minimum = 5
 
@@ -743,6 +879,13 @@ func (project *Project) Save(backup bool) {
 
 		if project.FilePath != "" {
 
+			// Media is collected on manual saves, so downloaded images and files from elsewhere end up alongside the project
+			if !backup && project.StoreMediaInProject.Checked {
+				project.CollectMedia(true)
+			} else if !backup {
+				project.CollectTemporaryMedia()
+			}
+
 			// Sort the Tasks by their ID, then loop through them using that slice. This way,
 			// They store data according to their creation ID, not according to their position
 			// in the world.
@@ -761,7 +904,7 @@ func (project *Project) Save(backup bool) {
 					taskData += ","
 				}
 				if task.Serializable() {
-					taskData += task.Serialize()
+					taskData += task.saveData()
 				}
 			}
 			taskData += "]"
@@ -783,6 +926,7 @@ func (project *Project) Save(backup bool) {
 			data, _ = sjson.Set(data, `BracketSubtasks`, project.BracketSubtasks.Checked)
 			data, _ = sjson.Set(data, `TaskShadow`, project.TaskShadowSpinner.CurrentChoice)
 			data, _ = sjson.Set(data, `ShowIcons`, project.ShowIcons.Checked)
+			data, _ = sjson.Set(data, `ShowTagChips`, project.ShowTagChips.Checked)
 			data, _ = sjson.Set(data, `NumberTopLevel`, project.NumberTopLevel.Checked)
 			data, _ = sjson.Set(data, `NumberingSequence`, project.NumberingSequence.CurrentChoice)
 			data, _ = sjson.Set(data, `PulsingTaskSelection`, project.PulsingTaskSelection.Checked)
@@ -792,10 +936,12 @@ func (project *Project) Save(backup bool) {
 			data, _ = sjson.Set(data, `BackupKeepCount`, project.AutomaticBackupKeepCount.Number())
 			data, _ = sjson.Set(data, `UndoMaxSteps`, project.MaxUndoSteps.Number())
 			data, _ = sjson.Set(data, `AlwaysShowURLButtons`, project.AlwaysShowURLButtons.Checked)
+			data, _ = sjson.Set(data, `StoreMedia`, project.StoreMediaInProject.Checked)
 			data, _ = sjson.Set(data, `IncompleteTasksGlow`, project.IncompleteTasksGlow.Checked)
 			data, _ = sjson.Set(data, `CompleteTasksGlow`, project.CompleteTasksGlow.Checked)
 			data, _ = sjson.Set(data, `SelectedTasksGlow`, project.SelectedTasksGlow.Checked)
 			data, _ = sjson.Set(data, `ScreenshotsPath`, project.ScreenshotsPath.Text())
+			data, _ = sjson.Set(data, `TeamRoster`, project.Roster())
 			data, _ = sjson.Set(data, `GraphicalTasksTransparent`, project.GraphicalTasksTransparent.Checked)
 			data, _ = sjson.Set(data, `DeadlineAnimation`, project.DeadlineAnimation.CurrentChoice)
 			data, _ = sjson.Set(data, `TableColumnsRotatedVertical`, project.TableColumnsRotatedVertical.Checked)
@@ -807,6 +953,10 @@ func (project *Project) Save(backup bool) {
 			}
 			data, _ = sjson.Set(data, `BoardNames`, boardNames)
 
+			data = project.serializeFilters(data)
+			data = project.serializeBoardList(data)
+			data = project.serializeBookmarks(data)
+
 			if !backup && project.LockProject.Checked {
 				project.Log("Project lock engaged.")
 				project.Locked = true
@@ -905,6 +1055,10 @@ func LoadProject(filepath string) *Project {
 			project.BracketSubtasks.Checked = getBool(`BracketSubtasks`)
 			project.GridVisible.Checked = getBool(`GridVisible`)
 			project.ShowIcons.Checked = getBool(`ShowIcons`)
+
+			if data.Get(`ShowTagChips`).Exists() {
+				project.ShowTagChips.Checked = getBool(`ShowTagChips`)
+			}
 			project.NumberingSequence.CurrentChoice = getInt(`NumberingSequence`)
 			project.NumberTopLevel.Checked = getBool(`NumberTopLevel`)
 			project.PulsingTaskSelection.Checked = getBool(`PulsingTaskSelection`)
@@ -915,9 +1069,16 @@ func LoadProject(filepath string) *Project {
 			project.AutomaticBackupKeepCount.SetNumber(getInt(`BackupKeepCount`))
 			project.MaxUndoSteps.SetNumber(getInt(`UndoMaxSteps`))
 			project.AlwaysShowURLButtons.Checked = getBool(`AlwaysShowURLButtons`)
+			project.StoreMediaInProject.Checked = getBool(`StoreMedia`)
 			project.GraphicalTasksTransparent.Checked = getBool(`GraphicalTasksTransparent`)
 			project.DeadlineAnimation.CurrentChoice = getInt(`DeadlineAnimation`)
 
+			roster := []string{}
+			for _, name := range data.Get(`TeamRoster`).Array() {
+				roster = append(roster, name.String())
+			}
+			project.TeamRoster.SetText(strings.Join(roster, ", "))
+
 			if data.Get(`TableColumnsRotatedVertical`).Exists() {
 				project.TableColumnsRotatedVertical.Checked = getBool(`TableColumnsRotatedVertical`)
 				project.TableColumnVerticalSpacing.SetNumber(getInt(`TableColumnVerticalSpacing`))
@@ -937,6 +1098,8 @@ func LoadProject(filepath string) *Project {
 				project.Locked = true
 			}
 
+			project.loadFilters(data)
+
 			project.LogOn = false
 
 			boardNames := []string{}
@@ -954,14 +1117,20 @@ func LoadProject(filepath string) *Project {
 				}
 			}
 
+			project.loadBoardList(data)
+			project.loadBookmarks(data)
+
 			log.Println("total number of tasks to deserialize: ", len(data.Get(`Tasks`).Array()))
 
 			for i, taskData := range data.Get(`Tasks`).Array() {
 
-				boardIndex := 0
+				board := project.BoardByID(taskData.Get(`BoardID`).String())
 
-				if taskData.Get(`BoardIndex`).Exists() {
-					boardIndex = int(taskData.Get(`BoardIndex`).Int())
+				if board == nil {
+					board = project.Boards[0]
+					if taskData.Get(`BoardIndex`).Exists() {
+						board = project.Boards[int(taskData.Get(`BoardIndex`).Int())]
+					}
 				}
 
 				taskType, ok := ParseTaskType(taskData)
@@ -969,7 +1138,7 @@ func LoadProject(filepath string) *Project {
 					continue
 				}
 
-				task := project.Boards[boardIndex].CreateNewTask()
+				task := board.CreateNewTask()
 				task.Deserialize(taskData, taskType)
 
 				task.Rect.X = task.Position.X
@@ -993,6 +1162,10 @@ func LoadProject(filepath string) *Project {
 
 			project.LogOn = true
 
+			if missing := project.MissingMedia(); len(missing) > 0 {
+				project.Log("WARNING: %d media file(s) used in this project couldn't be found:\n%s", len(missing), strings.Join(missing, "\n"))
+			}
+
 			list := []string{}
 
 			existsInList := func(value string) bool {
@@ -1088,7 +1261,7 @@ func (project *Project) HandleCamera() {
 
 	wheel := rl.GetMouseWheelMove()
 
-	if !project.ContextMenuOpen && !project.TaskOpen && project.PopupAction == "" && !project.ProjectSettingsOpen {
+	if !project.ContextMenuOpen && !project.TaskOpen && project.PopupAction == "" && !project.ProjectSettingsOpen && !project.Presenting {
 		if wheel > 0 {
 			project.ZoomLevel++
 		} else if wheel < 0 {
@@ -1108,13 +1281,20 @@ func (project *Project) HandleCamera() {
 
 	targetZoom := zoomLevels[project.ZoomLevel]
 
-	if programSettings.PanToFocusOnZoom && project.ZoomLevel != project.CurrentZoomLevel && !math.Signbit(float64(project.ZoomLevel-project.CurrentZoomLevel)) {
+	if programSettings.PanToFocusOnZoom && !project.Presenting && project.ZoomLevel != project.CurrentZoomLevel && !math.Signbit(float64(project.ZoomLevel-project.CurrentZoomLevel)) {
 		mousePos := GetWorldMousePosition()
 		project.CameraPan.X += (-mousePos.X - project.CameraPan.X) * 0.5
 		project.CameraPan.Y += (-mousePos.Y - project.CameraPan.Y) * 0.5
 	}
 
-	camera.Zoom += (targetZoom - camera.Zoom) * (project.AdjustedFrameTime() * 12)
+	zoomSpeed := project.AdjustedFrameTime() * 12
+
+	// Presentations glide from one bookmark to the next, rather than jumping
+	if project.Presenting {
+		zoomSpeed = project.AdjustedFrameTime() * 4
+	}
+
+	camera.Zoom += (targetZoom - camera.Zoom) * zoomSpeed
 
 	if math.Abs(float64(targetZoom-camera.Zoom)) < 0.001 {
 		camera.Zoom = targetZoom
@@ -1128,7 +1308,9 @@ func (project *Project) HandleCamera() {
 
 	smoothing := float32(1)
 
-	if programSettings.SmoothPanning {
+	if project.Presenting {
+		smoothing = project.AdjustedFrameTime() * 4
+	} else if programSettings.SmoothPanning {
 		smoothing = project.AdjustedFrameTime() * 12
 	}
 
@@ -1152,6 +1334,18 @@ func (project *Project) MousingOver() string {
 		return "StatusBar"
 	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.BoardPanel) {
 		return "Boards"
+	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.SearchResultsPanel) {
+		return "SearchResults"
+	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.FilterMenuPanel) {
+		return "Filters"
+	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.TagSummaryPanel) {
+		return "Tags"
+	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.MinimapRect) || project.minimapDragging {
+		return "Minimap"
+	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.BookmarkPanel) {
+		return "Bookmarks"
+	} else if rl.CheckCollisionPointRec(GetMousePosition(), project.SendToBoardPanel) {
+		return "SendToBoard"
 	} else if project.TaskOpen {
 		return "TaskOpen"
 	} else {
@@ -1168,10 +1362,33 @@ func (project *Project) Update() {
 		if resource.DownloadResponse.IsComplete() {
 			resource.ParseData()
 			delete(project.DownloadingResources, key)
+
+			if response := resource.DownloadResponse; response.Err() == nil && response.HTTPResponse != nil && response.HTTPResponse.StatusCode == 200 {
+				if err := resourceCache.Store(key, resource.LocalFilepath, response.HTTPResponse.Header); err != nil {
+					project.Log("WARNING: Couldn't cache the image downloaded from [%s]: %s", key, err.Error())
+				}
+			}
+
 			break
 		}
 	}
 
+	// Cached images that turned out to be outdated have been downloaded again, so LoadResource() will see they've changed and reload them.
+	select {
+	case key := <-project.RefreshedResources:
+		if _, exists := project.Resources[key]; exists {
+			project.LoadResource(key)
+		}
+	default:
+	}
+
+	// Images exported in the background report back here, as the log can only be written to from the main thread.
+	select {
+	case message := <-project.Exported:
+		project.Log(message)
+	default:
+	}
+
 	// If auto-reload resources is checked, then we can loop through each resource and attempt to load it; LoadResource() will reload the resource if it
 	// has changed.
 	if project.AutoReloadResources.Checked {
@@ -1185,6 +1402,16 @@ func (project *Project) Update() {
 
 	project.AutoBackup()
 
+	if project.Sync != nil {
+		project.Sync.Update()
+	}
+
+	if automationAPI != nil {
+		automationAPI.Update(project)
+	}
+
+	project.Hooks.Update()
+
 	project.Shortcuts()
 
 	if project.AutoReloadThemes.Checked {
@@ -1223,9 +1450,15 @@ func (project *Project) Update() {
 
 	project.CurrentBoard().Draw()
 
+	if project.Sync != nil {
+		project.Sync.DrawCursors()
+	}
+
 	project.HandleCamera()
 
-	if !project.TaskOpen {
+	// Tasks can't be clicked on, dragged, or double-clicked while presenting, so clicking around doesn't change the Board
+	// being presented
+	if !project.TaskOpen && !project.Presenting {
 
 		project.CurrentBoard().HandleDroppedFiles()
 
@@ -1248,7 +1481,7 @@ func (project *Project) Update() {
 
 				task := project.CurrentBoard().Tasks[i]
 
-				if rl.CheckCollisionPointRec(GetWorldMousePosition(), task.Rect) && clickedTask == nil {
+				if rl.CheckCollisionPointRec(GetWorldMousePosition(), task.Rect) && clickedTask == nil && !(task.FilteredOut && project.FilterHides()) {
 					clickedTask = task
 				}
 
@@ -1310,7 +1543,18 @@ func (project *Project) Update() {
 					}
 
 					if clickedTask.ID == project.DoubleClickTaskID && project.DoubleClickTimer >= 0 && clickedTask.Selected {
-						clickedTask.ReceiveMessage(MessageDoubleClick, nil)
+						// Double-clicking a Document Task's preview opens the document itself, and double-clicking a Board link goes to
+						// its Board, rather than editing the Task
+						opened := false
+						switch contents := clickedTask.Contents.(type) {
+						case *DocumentContents:
+							opened = contents.Open()
+						case *BoardLinkContents:
+							opened = contents.Open()
+						}
+						if !opened {
+							clickedTask.ReceiveMessage(MessageDoubleClick, nil)
+						}
 						project.DoubleClickTimer = -1
 					} else if !clickedTask.Locked {
 						project.DoubleClickTimer = project.Time
@@ -1510,6 +1754,20 @@ func (project *Project) SendMessage(message string, data map[string]interface{})
 
 }
 
+// MapSelectionTool returns the MapImage of the selected Map Task that's using the selection tool, if any, so that copying,
+// pasting, and deleting affect its cells rather than Tasks.
+func (project *Project) MapSelectionTool() *MapImage {
+
+	for _, task := range project.CurrentBoard().SelectedTasks(false) {
+		if task.Is(TASK_TYPE_MAP) && task.MapImage != nil && task.MapImage.EditTool == MapEditToolSelect {
+			return task.MapImage
+		}
+	}
+
+	return nil
+
+}
+
 func (project *Project) Shortcuts() {
 
 	keybindings := programSettings.Keybindings
@@ -1522,7 +1780,12 @@ func (project *Project) Shortcuts() {
 		clash.Enabled = false
 	}
 
-	if !project.ProjectSettingsOpen && project.PopupAction == "" {
+	if project.Presenting {
+		project.PresentationShortcuts()
+		return
+	}
+
+	if !project.ProjectSettingsOpen && project.PopupAction == "" && !project.SendToBoardOpen {
 
 		if !project.TaskOpen {
 
@@ -1550,60 +1813,27 @@ func (project *Project) Shortcuts() {
 				}
 
 				if keybindings.On(KBBoard1) {
-					if len(project.Boards) > 0 {
-						project.BoardIndex = 0
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(0)
 				} else if keybindings.On(KBBoard2) {
-					if len(project.Boards) > 1 {
-						project.BoardIndex = 1
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(1)
 				} else if keybindings.On(KBBoard2) {
-					if len(project.Boards) > 1 {
-						project.BoardIndex = 1
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(1)
 				} else if keybindings.On(KBBoard3) {
-					if len(project.Boards) > 2 {
-						project.BoardIndex = 2
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(2)
 				} else if keybindings.On(KBBoard4) {
-					if len(project.Boards) > 3 {
-						project.BoardIndex = 3
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(3)
 				} else if keybindings.On(KBBoard5) {
-					if len(project.Boards) > 4 {
-						project.BoardIndex = 4
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(4)
 				} else if keybindings.On(KBBoard6) {
-					if len(project.Boards) > 5 {
-						project.BoardIndex = 5
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(5)
 				} else if keybindings.On(KBBoard7) {
-					if len(project.Boards) > 6 {
-						project.BoardIndex = 6
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(6)
 				} else if keybindings.On(KBBoard8) {
-					if len(project.Boards) > 7 {
-						project.BoardIndex = 7
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(7)
 				} else if keybindings.On(KBBoard9) {
-					if len(project.Boards) > 8 {
-						project.BoardIndex = 8
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(8)
 				} else if keybindings.On(KBBoard10) {
-					if len(project.Boards) > 9 {
-						project.BoardIndex = 9
-						project.Log("Switched to Board: %s.", project.CurrentBoard().Name)
-					}
+					project.SwitchToListedBoard(9)
 				} else if keybindings.On(KBZoomLevel10) {
 					project.ZoomLevel = 0
 				} else if keybindings.On(KBZoomLevel25) {
@@ -1625,6 +1855,31 @@ func (project *Project) Shortcuts() {
 				} else if keybindings.On(KBCenterView) {
 					project.CameraPan.X = 0
 					project.CameraPan.Y = 0
+				} else if keybindings.On(KBToggleMinimap) {
+					project.MinimapOpen = !project.MinimapOpen
+				} else if keybindings.On(KBAddBookmark) {
+					project.PopupAction = ActionAddBookmark
+					project.PopupArgument = fmt.Sprintf("Bookmark %d", len(project.CurrentBoard().Bookmarks)+1)
+				} else if keybindings.On(KBBookmark1) {
+					project.GoToCameraBookmark(0)
+				} else if keybindings.On(KBBookmark2) {
+					project.GoToCameraBookmark(1)
+				} else if keybindings.On(KBBookmark3) {
+					project.GoToCameraBookmark(2)
+				} else if keybindings.On(KBBookmark4) {
+					project.GoToCameraBookmark(3)
+				} else if keybindings.On(KBBookmark5) {
+					project.GoToCameraBookmark(4)
+				} else if keybindings.On(KBBookmark6) {
+					project.GoToCameraBookmark(5)
+				} else if keybindings.On(KBBookmark7) {
+					project.GoToCameraBookmark(6)
+				} else if keybindings.On(KBBookmark8) {
+					project.GoToCameraBookmark(7)
+				} else if keybindings.On(KBBookmark9) {
+					project.GoToCameraBookmark(8)
+				} else if keybindings.On(KBTogglePresentation) {
+					project.StartPresentation()
 				} else if keybindings.On(KBSelectAllTasks) {
 
 					for _, task := range project.CurrentBoard().Tasks {
@@ -1633,6 +1888,14 @@ func (project *Project) Shortcuts() {
 
 					project.Log("Selected all %d Task(s).", len(project.CurrentBoard().Tasks))
 
+				} else if mapImage := project.MapSelectionTool(); mapImage != nil && keybindings.On(KBCopyTasks) {
+					mapImage.CopySelection()
+				} else if mapImage != nil && keybindings.On(KBCutTasks) {
+					mapImage.CutSelection()
+				} else if mapImage != nil && keybindings.On(KBPasteTasks) {
+					mapImage.Paste()
+				} else if mapImage != nil && keybindings.On(KBDeleteTasks) {
+					mapImage.DeleteSelection()
 				} else if keybindings.On(KBCopyTasks) {
 					project.CurrentBoard().CopySelectedTasks()
 				} else if keybindings.On(KBCutTasks) {
@@ -1901,6 +2164,16 @@ func (project *Project) Shortcuts() {
 
 					}
 
+				} else if keybindings.On(KBSortStackByPriority) {
+					project.CurrentBoard().SortSelectedStacks()
+				} else if keybindings.On(KBParentBoard) {
+					project.GoToParentBoard()
+				} else if keybindings.On(KBSendToBoard) {
+					if len(selectedTasks) > 0 {
+						project.SendToBoardOpen = true
+					} else {
+						project.Log("Select Tasks to send them to another Board.")
+					}
 				} else if keybindings.On(KBEditTasks) {
 					for _, task := range project.CurrentBoard().SelectedTasks(true) {
 						task.ReceiveMessage(MessageDoubleClick, nil)
@@ -1989,6 +2262,8 @@ func (project *Project) Shortcuts() {
 						setChoice = TASK_TYPE_NOTE
 					} else if keybindings.On(KBCreateImageTask) {
 						setChoice = TASK_TYPE_IMAGE
+					} else if keybindings.On(KBCreateMediaTask) {
+						setChoice = TASK_TYPE_MEDIA
 					} else if keybindings.On(KBCreateTimerTask) {
 						setChoice = TASK_TYPE_TIMER
 					} else if keybindings.On(KBCreateLinetask) {
@@ -2045,19 +2320,34 @@ func (project *Project) GUI() {
 
 	project.CurrentBoard().PostDraw()
 
-	if project.PopupAction != "" {
+	if project.Presenting {
+
+		project.DrawPresentation()
+
+	} else if project.PopupAction != "" {
 
 		textboxElement := project.PopupPanel.FindItems("rename textbox")[0]
 		textbox := textboxElement.Element.(*Textbox)
 		labelElement := project.PopupPanel.FindItems("label")[0]
 		label := labelElement.Element.(*Label)
 
+		acceptButton := project.PopupPanel.FindItems("accept button")[0].Element.(*Button)
+		if project.PopupAction != ActionTrustCommands {
+			acceptButton.Disabled = false
+		}
+
 		project.PopupPanel.Update()
 
-		accept := project.PopupPanel.FindItems("accept button")[0].Element.(*Button).Clicked
+		accept := acceptButton.Clicked
 		cancel := project.PopupPanel.FindItems("cancel button")[0].Element.(*Button).Clicked
 
 		if project.PopupPanel.Exited || cancel {
+			if project.PopupAction == ActionTrustCommands {
+				project.SetCommandsTrusted(false)
+			} else if project.PopupAction == ActionWhiteboardLabel {
+				project.WhiteboardLabelTarget.Label = nil
+				project.WhiteboardLabelTarget = nil
+			}
 			project.PopupAction = ""
 
 		}
@@ -2084,6 +2374,151 @@ func (project *Project) GUI() {
 				project.PopupAction = ""
 			}
 
+		} else if project.PopupAction == ActionTrustCommands {
+
+			// Trusting the plan trusts all of its commands, so they're all shown, not just the one that's waiting to run. If there
+			// are too many to fit, they're scrolled through, and the plan can't be trusted until every one of them has been seen.
+			commands := project.Commands()
+
+			// If the commands change while the popup's open, they have to be looked through again.
+			if listed := strings.Join(commands, "\n"); listed != project.trustListed {
+				project.trustListed = listed
+				project.trustScroll = 0
+				project.trustSeen = 0
+			}
+
+			visible := 8
+
+			if wheel := rl.GetMouseWheelMove(); wheel < 0 || rl.IsKeyPressed(rl.KeyDown) {
+				project.trustScroll++
+			} else if wheel > 0 || rl.IsKeyPressed(rl.KeyUp) {
+				project.trustScroll--
+			}
+
+			if project.trustScroll > len(commands)-visible {
+				project.trustScroll = len(commands) - visible
+			}
+
+			if project.trustScroll < 0 {
+				project.trustScroll = 0
+			}
+
+			end := project.trustScroll + visible
+			if end > len(commands) {
+				end = len(commands)
+			}
+
+			if end > project.trustSeen {
+				project.trustSeen = end
+			}
+
+			label.Text = "This plan wants to run commands that haven't been trusted yet:\n\n"
+
+			for _, command := range commands[project.trustScroll:end] {
+				label.Text += command + "\n"
+			}
+
+			if len(commands) > visible {
+				label.Text += fmt.Sprintf("\n(Commands %d-%d of %d; scroll to see them all.)\n", project.trustScroll+1, end, len(commands))
+			}
+
+			label.Text += "\nTrust this plan to run these commands?"
+
+			textboxElement.On = false
+
+			acceptButton.Disabled = project.trustSeen < len(commands)
+
+			if accept && !acceptButton.Disabled {
+				project.PopupAction = ""
+				project.SetCommandsTrusted(true)
+			}
+
+		} else if project.PopupAction == ActionWhiteboardLabel {
+
+			label.Text = "Whiteboard Label:"
+
+			textboxElement.On = true
+
+			if project.PopupArgument != "" {
+				textbox.SetText(project.PopupArgument)
+				project.PopupArgument = ""
+				textbox.SetFocused(true)
+				textbox.SelectAllText()
+			}
+
+			if accept {
+				project.PopupAction = ""
+				project.WhiteboardLabelTarget.SetLabel(textbox.Text())
+				project.WhiteboardLabelTarget = nil
+			}
+
+		} else if project.PopupAction == ActionSaveFilter {
+
+			label.Text = "Save Search as Filter Named:"
+
+			textboxElement.On = true
+
+			if project.PopupArgument != "" {
+				textbox.SetText(project.PopupArgument)
+				project.PopupArgument = ""
+				textbox.SetFocused(true)
+				textbox.SelectAllText()
+			}
+
+			if accept && textbox.Text() != "" {
+				project.PopupAction = ""
+				project.SaveSearchAsFilter(textbox.Text())
+			}
+
+		} else if project.PopupAction == ActionAddBookmark {
+
+			label.Text = "Bookmark Current View As:"
+
+			textboxElement.On = true
+
+			if project.PopupArgument != "" {
+				textbox.SetText(project.PopupArgument)
+				project.PopupArgument = ""
+				textbox.SetFocused(true)
+				textbox.SelectAllText()
+			}
+
+			if accept && textbox.Text() != "" {
+				project.PopupAction = ""
+				project.AddCameraBookmark(textbox.Text())
+			}
+
+		} else if project.PopupAction == ActionJoinSession {
+
+			label.Text = "Join LAN Session At Address:"
+
+			textboxElement.On = true
+
+			if project.PopupArgument != "" {
+				textbox.SetText(project.PopupArgument)
+				project.PopupArgument = ""
+				textbox.SetFocused(true)
+				textbox.SelectAllText()
+			}
+
+			// The session's join code is asked for next
+			if accept {
+				project.joinAddress = textbox.Text()
+				project.PopupAction = ActionJoinSessionCode
+				textbox.SetText("")
+			}
+
+		} else if project.PopupAction == ActionJoinSessionCode {
+
+			label.Text = "Join Code (Shown To The Host):"
+
+			textboxElement.On = true
+
+			if accept {
+				project.PopupAction = ""
+				project.JoinSession(project.joinAddress, textbox.Text())
+			}
+
 		} else {
 
 			if project.Modified {
@@ -2115,12 +2550,18 @@ func (project *Project) GUI() {
 
 			pos := project.ContextMenuPosition
 
+			archiveOption := "Archive Board"
+			if project.CurrentBoard().Archived {
+				archiveOption = "Unarchive Board"
+			}
+
 			menuOptions := []string{
 				"New Project",
 				"Load Project",
 				"Load Recent...",
 				"Save Project",
 				"Save Project As...",
+				"Collect All Media",
 				"Settings",
 				"New Task",
 				"Delete Tasks",
@@ -2128,7 +2569,20 @@ func (project *Project) GUI() {
 				"Copy Tasks",
 				"Paste Tasks",
 				"Paste Content",
+				"Sort Stack by Priority",
+				"Send to Board...",
+				"Tag Summary",
+				"Minimap",
+				"Camera Bookmarks",
+				"Start Presentation",
+				"Go to Parent Board",
+				"Duplicate Board",
+				archiveOption,
 				"Take Screenshot",
+				"Export Board Image...",
+				"Host LAN Session",
+				"Join LAN Session...",
+				"Leave LAN Session",
 				"Open Tutorial",
 				"Quit MasterPlan",
 			}
@@ -2174,11 +2628,20 @@ func (project *Project) GUI() {
 
 				if option == "Copy Tasks" && selectedCount == 0 ||
 					option == "Delete Tasks" && selectedCount == 0 ||
+					option == "Sort Stack by Priority" && selectedCount == 0 ||
+					option == "Send to Board..." && (selectedCount == 0 || len(project.Boards) < 2) ||
+					option == "Go to Parent Board" && project.ParentBoard(project.CurrentBoard()) == nil ||
+					option == "Duplicate Board" && len(project.CurrentBoard().Tasks) == 0 ||
 					option == "Paste Tasks" && len(project.CopyBuffer) == 0 {
 					disabled = true
 				}
 
-				if option == "Save Project" && project.FilePath == "" {
+				if (option == "Save Project" || option == "Collect All Media") && project.FilePath == "" {
+					disabled = true
+				}
+
+				if (option == "Host LAN Session" || option == "Join LAN Session...") && project.Sync != nil ||
+					option == "Leave LAN Session" && project.Sync == nil {
 					disabled = true
 				}
 
@@ -2225,6 +2688,12 @@ func (project *Project) GUI() {
 					case "Save Project As...":
 						project.SaveAs()
 
+					case "Collect All Media":
+						// Collecting media isn't an undo step, but the Project still needs saving to keep the new paths.
+						if project.CollectMedia(false) > 0 {
+							project.Modified = true
+						}
+
 					case "Load Project":
 						if project.Modified {
 							project.PopupAction = ActionLoadProject
@@ -2257,9 +2726,55 @@ func (project *Project) GUI() {
 					case "Paste Content":
 						project.CurrentBoard().PasteContent()
 
+					case "Sort Stack by Priority":
+						project.CurrentBoard().SortSelectedStacks()
+
+					case "Send to Board...":
+						project.SendToBoardOpen = true
+						// The click that chose this option shouldn't also close the menu it opens
+						ConsumeMouseInput(rl.MouseLeftButton)
+
+					case "Tag Summary":
+						project.TagSummaryOpen = !project.TagSummaryOpen
+
+					case "Minimap":
+						project.MinimapOpen = !project.MinimapOpen
+
+					case "Camera Bookmarks":
+						project.BookmarkListOpen = !project.BookmarkListOpen
+
+					case "Start Presentation":
+						project.StartPresentation()
+
+					case "Go to Parent Board":
+						project.GoToParentBoard()
+
+					case "Duplicate Board":
+						project.DuplicateBoard(project.CurrentBoard())
+
+					case "Archive Board":
+						project.SetBoardArchived(project.CurrentBoard(), true)
+
+					case "Unarchive Board":
+						project.SetBoardArchived(project.CurrentBoard(), false)
+
 					case "Take Screenshot":
 						takeScreenshot = true
 
+					case "Export Board Image...":
+						project.ExportBoardImage()
+
+					case "Host LAN Session":
+						project.HostSession()
+
+					case "Join LAN Session...":
+						project.PopupAction = ActionJoinSession
+						project.PopupArgument = fmt.Sprintf("127.0.0.1:%d", programSettings.SyncPort)
+
+					case "Leave LAN Session":
+						project.LeaveSession()
+						project.Log("Left session.")
+
 					case "Open Tutorial":
 						startingPlanPath := LocalPath("assets", "help_manual.plan")
 						if project.Modified {
@@ -2390,6 +2905,21 @@ func (project *Project) GUI() {
 
 			}
 
+			if project.ClearResourceCacheButton.Clicked {
+				if err := resourceCache.Clear(); err != nil {
+					project.Log("ERROR: Couldn't clear the image cache: %s", err.Error())
+				} else {
+					project.Log("Image cache cleared.")
+				}
+				project.ResourceCacheUsage.Text = resourceCache.UsageText()
+			}
+
+			if project.ResourceCacheLimit.Changed {
+				programSettings.ResourceCacheLimit = project.ResourceCacheLimit.Number()
+				resourceCache.Prune()
+				project.ResourceCacheUsage.Text = resourceCache.UsageText()
+			}
+
 			if project.DefaultFontButton.Clicked {
 				project.CustomFontPath.SetText("")
 				project.FontSize.SetNumber(15)
@@ -2424,6 +2954,14 @@ func (project *Project) GUI() {
 				programSettings.DownloadTimeout = project.DownloadTimeout.Number()
 				programSettings.CopyTasksToClipboard = project.CopyTasksToClipboard.Checked
 				programSettings.DoubleClickRate = project.DoubleClickRate.Number()
+				programSettings.SyncName = project.SyncName.Text()
+
+				if project.TrustCommands.Checked != project.CommandsTrusted() {
+					project.SetCommandsTrusted(project.TrustCommands.Checked)
+				}
+				programSettings.SyncPort = project.SyncPort.Number()
+				programSettings.AutomationAPI = project.AutomationAPI.Checked
+				programSettings.AutomationAPIPort = project.AutomationAPIPort.Number()
 
 				if project.AutoSave.Checked {
 					project.LogOn = false
@@ -2437,6 +2975,8 @@ func (project *Project) GUI() {
 
 				project.SendMessage(MessageSettingsChange, nil)
 
+				UpdateAutomationAPI()
+
 			}
 
 			if project.GridVisible.Changed {
@@ -2484,35 +3024,20 @@ func (project *Project) GUI() {
 			rl.DrawRectangleRec(project.StatusBar, getThemeColor(GUI_INSIDE))
 			rl.DrawLine(int32(project.StatusBar.X), int32(project.StatusBar.Y-1), int32(project.StatusBar.X+project.StatusBar.Width), int32(project.StatusBar.Y-1), getThemeColor(GUI_OUTLINE))
 
-			taskCount := 0
-			completionCount := 0
-
-			for _, t := range project.CurrentBoard().Tasks {
-
-				if t.IsCompletable() {
-
-					if t.Is(TASK_TYPE_TABLE) && t.TableData != nil {
-
-						taskCount += t.TableData.CompletionMax()
-						completionCount += t.TableData.CompletionCount()
-
-					} else {
-						taskCount++
-						if t.IsComplete() {
-							completionCount++
-						}
-					}
-
-				}
-
-			}
+			completionCount, taskCount := project.CurrentBoard().CompletionTotals()
 
 			percentage := int32(0)
 			if taskCount > 0 && completionCount > 0 {
 				percentage = int32(float32(completionCount) / float32(taskCount) * 100)
 			}
 
-			DrawGUIText(rl.Vector2{6, project.StatusBar.Y - 2}, "%d / %d ☑ (%d%%)", completionCount, taskCount, percentage)
+			completionText := fmt.Sprintf("%d / %d ☑ (%d%%)", completionCount, taskCount, percentage)
+			DrawGUIText(rl.Vector2{6, project.StatusBar.Y - 2}, completionText)
+
+			completionSize, _ := TextSize(completionText, true)
+			project.DrawBoardTrail(completionSize.X + 32)
+
+			project.DrawMinimap()
 
 			// Search bar
 
@@ -2538,7 +3063,9 @@ func (project *Project) GUI() {
 
 				searchTextPosX := project.Searchbar.Rect.X - 96
 				searchCount := "0/0"
-				if len(project.SearchedTasks) > 0 {
+				if project.SearchError != nil {
+					searchCount = "?"
+				} else if len(project.SearchedTasks) > 0 {
 					searchCount = fmt.Sprintf("%d / %d", project.FocusedSearchTask+1, len(project.SearchedTasks))
 				}
 				textMeasure := rl.MeasureTextEx(font, searchCount, float32(GUIFontSize()), spacing)
@@ -2557,10 +3084,37 @@ func (project *Project) GUI() {
 					project.SearchForTasks()
 				}
 
+				listButton := rl.Rectangle{searchTextPosX - textMeasure.X - 96, project.Searchbar.Rect.Y, 48, project.Searchbar.Rect.Height}
+
+				if ImmediateButton(listButton, "List", len(project.SearchedTasks) == 0 && project.SearchError == nil) {
+					project.SearchResultsOpen = !project.SearchResultsOpen
+				}
+
+				project.DrawSearchResults()
+
+			} else {
+				project.SearchResultsPanel = rl.Rectangle{}
 			}
 
+			project.UpdateFilterDropdown(rl.Rectangle{project.Searchbar.Rect.X - 400, project.Searchbar.Rect.Y, 160, project.Searchbar.Rect.Height})
+
+			project.DrawTagSummary()
+
 			// Boards
 
+			listed := project.ListedBoards()
+
+			// Archived Boards nested inside of others aren't listed on their own, either
+			archived := []*Board{}
+			for _, board := range project.ArchivedBoards() {
+				if project.ParentBoard(board) == nil {
+					archived = append(archived, board)
+				}
+			}
+
+			// If the current Board is nested inside of others, the Boards leading down to it are shown under the top-level one
+			trail := project.BoardTrail(project.CurrentBoard())
+
 			w := float32(0)
 			for _, b := range project.Boards {
 				textSize, _ := TextSize(b.Name, true)
@@ -2582,45 +3136,58 @@ func (project *Project) GUI() {
 			h := float32(24)
 			iconSrcRect := rl.Rectangle{96, 16, 16, 16}
 
-			project.BoardPanel = rl.Rectangle{x, y, w + 100, h * float32(len(project.Boards)+1)}
+			// The archived Boards are shown if the current Board is one of them (as when a search result on one is chosen)
+			showArchived := project.ShowArchivedBoards || project.CurrentBoard().Archived || trail[0].Archived
 
-			if !project.TaskOpen {
+			// The Boards leading down to the current one are drawn under the top-level one, in whichever section it's in
+			nestedRows := len(trail) - 1
 
-				for boardIndex, board := range project.Boards {
-
-					disabled := boardIndex == project.BoardIndex
+			rowCount := len(listed) + 1
+			if !trail[0].Archived {
+				rowCount += nestedRows
+			}
 
-					if len(project.Boards[boardIndex].Tasks) == 0 {
-						iconSrcRect.X += iconSrcRect.Width
+			if len(archived) > 0 {
+				rowCount++
+				if showArchived {
+					rowCount += len(archived)
+					if trail[0].Archived {
+						rowCount += nestedRows
 					}
+				}
+			}
+
+			project.BoardPanel = rl.Rectangle{x, y, w + 100, h * float32(rowCount)}
 
-					if ImmediateIconButton(rl.Rectangle{x + buttonRange, y, w, h}, iconSrcRect, 0, board.Name, disabled) {
+			if !project.TaskOpen {
+
+				var boardButton func(board *Board, siblings []*Board, indent float32)
+
+				boardButton = func(board *Board, siblings []*Board, indent float32) {
 
-						project.BoardIndex = boardIndex
-						project.Log("Switched to Board: %s.", board.Name)
+					disabled := board == project.CurrentBoard()
+
+					icon := iconSrcRect
+					if len(board.Tasks) == 0 {
+						icon.X += icon.Width
+					}
 
+					if ImmediateIconButton(rl.Rectangle{x + buttonRange + indent, y, w - indent, h}, icon, 0, board.Name, disabled) {
+						project.SwitchToBoard(board)
 					}
 
 					if disabled {
 
 						bx := x + buttonRange - h
 
-						if ImmediateIconButton(rl.Rectangle{bx, y, h, h}, rl.Rectangle{176, 16, 12, 12}, 90, "", boardIndex == len(project.Boards)-1) {
+						if ImmediateIconButton(rl.Rectangle{bx, y, h, h}, rl.Rectangle{176, 16, 12, 12}, 90, "", board == siblings[len(siblings)-1]) {
 							// Move board down
-							b := project.Boards[boardIndex+1]
-							project.Boards[boardIndex] = b
-							project.Boards[boardIndex+1] = board
-							project.BoardIndex++
-							project.Log("Moved Board %s down.", board.Name)
+							project.MoveBoardInList(board, 1)
 						}
 						bx -= h
-						if ImmediateIconButton(rl.Rectangle{bx, y, h, h}, rl.Rectangle{176, 16, 12, 12}, -90, "", boardIndex == 0) {
+						if ImmediateIconButton(rl.Rectangle{bx, y, h, h}, rl.Rectangle{176, 16, 12, 12}, -90, "", board == siblings[0]) {
 							// Move board Up
-							b := project.Boards[boardIndex-1]
-							project.Boards[boardIndex] = b
-							project.Boards[boardIndex-1] = board
-							project.BoardIndex--
-							project.Log("Moved Board %s up.", board.Name)
+							project.MoveBoardInList(board, -1)
 						}
 						bx -= h
 						if ImmediateIconButton(rl.Rectangle{bx, y, h, h}, rl.Rectangle{160, 16, 12, 12}, 0, "", false) {
@@ -2633,6 +3200,30 @@ func (project *Project) GUI() {
 
 					y += float32(h)
 
+					if board == trail[0] {
+						// Nested Boards can't be moved in the list, as they aren't listed on their own
+						for i, nested := range trail[1:] {
+							boardButton(nested, []*Board{nested}, float32(i+1)*h/2)
+						}
+					}
+
+				}
+
+				// Dragging is handled before the buttons are drawn, so the Boards are drawn where they've been dragged to
+				rows := []rl.Rectangle{}
+				rowY := y
+				for _, board := range listed {
+					rows = append(rows, rl.Rectangle{x + buttonRange, rowY, w, h})
+					rowY += h
+					if board == trail[0] {
+						rowY += h * float32(nestedRows)
+					}
+				}
+
+				project.UpdateBoardDragging(listed, rows)
+
+				for _, board := range listed {
+					boardButton(board, listed, 0)
 				}
 
 				if ImmediateButton(rl.Rectangle{x + buttonRange, y, w, h}, "+", false) {
@@ -2645,6 +3236,24 @@ func (project *Project) GUI() {
 					}
 				}
 
+				if len(archived) > 0 {
+
+					y += h
+
+					if ImmediateButton(rl.Rectangle{x + buttonRange, y, w, h}, fmt.Sprintf("Archived (%d)", len(archived)), false) {
+						project.ShowArchivedBoards = !project.ShowArchivedBoards
+					}
+
+					y += h
+
+					if showArchived {
+						for _, board := range archived {
+							boardButton(board, archived, 0)
+						}
+					}
+
+				}
+
 				empty := project.GetEmptyBoard()
 				if empty != nil && empty != project.CurrentBoard() {
 					project.RemoveBoard(empty)
@@ -2656,6 +3265,10 @@ func (project *Project) GUI() {
 
 			}
 
+			project.DrawBookmarkList()
+
+			project.DrawSendToBoardMenu()
+
 		}
 
 	}
@@ -2692,9 +3305,11 @@ func (project *Project) GUI() {
 
 }
 
+// GetEmptyBoard returns the first Board without any Tasks on it. Empty Boards that a Board link leads to are still in use,
+// so they're skipped.
 func (project *Project) GetEmptyBoard() *Board {
 	for _, b := range project.Boards {
-		if len(b.Tasks) == 0 {
+		if len(b.Tasks) == 0 && !project.IsBoardLinked(b) {
 			return b
 		}
 	}
@@ -2710,6 +3325,7 @@ func (project *Project) RemoveBoard(board *Board) {
 		if b == board {
 			b.Destroy()
 			project.Boards = append(project.Boards[:index], project.Boards[index+1:]...)
+			project.InvalidateBoardParents()
 			project.Log("Deleted empty Board: %s", b.Name)
 			break
 		}
@@ -2725,16 +3341,24 @@ func (project *Project) SearchForTasks() {
 		project.FocusedSearchTask = 0
 	}
 
-	for _, task := range project.GetAllTasks() {
+	query, err := ParseQuery(project.Searchbar.Text())
+	project.SearchError = err
+
+	if err == nil && !query.Empty() {
+
+		tasks := []*Task{}
 
-		searchText := strings.ToLower(project.Searchbar.Text())
+		for _, task := range project.GetAllTasks() {
+
+			// Line Tasks have nothing to search for, but they'd still match queries made up of only negated terms
+			if !task.Is(TASK_TYPE_LINE) {
+				tasks = append(tasks, task)
+			}
 
-		if searchText != "" && (strings.Contains(strings.ToLower(task.Description.Text()), searchText) ||
-			(task.UsesMedia() && strings.Contains(strings.ToLower(task.FilePathTextbox.Text()), searchText)) ||
-			(task.Is(TASK_TYPE_TIMER) && strings.Contains(strings.ToLower(task.TimerName.Text()), searchText))) {
-			project.SearchedTasks = append(project.SearchedTasks, task)
 		}
 
+		project.SearchedTasks = project.SearchIndex.Search(query, tasks)
+
 	}
 
 	if len(project.SearchedTasks) == 0 {
@@ -2756,6 +3380,74 @@ func (project *Project) SearchForTasks() {
 
 }
 
+// DrawSearchResults draws the list of Tasks matching the current search above the search bar, if it's open; clicking on
+// a result jumps to it, wherever it is. If the search couldn't be understood, the error is shown instead.
+func (project *Project) DrawSearchResults() {
+
+	project.SearchResultsPanel = rl.Rectangle{}
+
+	if !project.SearchResultsOpen && project.SearchError == nil {
+		return
+	}
+
+	w := float32(360)
+	h := float32(24)
+	x := float32(rl.GetScreenWidth()) - w - 16
+
+	// The results go above the minimap, if it's open
+	bottom := project.StatusBar.Y
+	if project.MinimapOpen {
+		bottom = project.MinimapRect.Y - 4
+	}
+
+	if project.SearchError != nil {
+		textSize, _ := TextSize(project.SearchError.Error(), true)
+		rect := rl.Rectangle{float32(rl.GetScreenWidth()) - textSize.X - 32, bottom - h - 4, textSize.X + 16, h}
+		rl.DrawRectangleRec(rect, getThemeColor(GUI_INSIDE))
+		rl.DrawRectangleLinesEx(rect, 1, getThemeColor(GUI_OUTLINE))
+		DrawGUIText(rl.Vector2{rect.X + 8, rect.Y + 2}, project.SearchError.Error())
+		return
+	}
+
+	// Only so many results fit, so we show the ones around the focused result
+	maxShown := 12
+	start := project.FocusedSearchTask - maxShown/2
+	if start > len(project.SearchedTasks)-maxShown {
+		start = len(project.SearchedTasks) - maxShown
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + maxShown
+	if end > len(project.SearchedTasks) {
+		end = len(project.SearchedTasks)
+	}
+
+	y := bottom - float32(end-start)*h - 4
+
+	project.SearchResultsPanel = rl.Rectangle{x, y, w, float32(end-start) * h}
+
+	for i := start; i < end; i++ {
+
+		task := project.SearchedTasks[i]
+
+		boardName := task.Board.Name
+		if task.Board.Archived {
+			boardName += " (Archived)"
+		}
+
+		if ImmediateButton(rl.Rectangle{x, y, w, h}, fmt.Sprintf("%s : %s", boardName, task.SearchTitle()), i == project.FocusedSearchTask) {
+			project.FocusedSearchTask = i
+			project.SearchForTasks()
+		}
+
+		y += h
+
+	}
+
+}
+
 func (project *Project) FirstFreeID() int {
 
 	usedIDs := map[int]bool{}
@@ -2893,6 +3585,10 @@ func (project *Project) Destroy() {
 
 	os.RemoveAll(project.TempDir)
 
+	project.LeaveSession()
+
+	project.Hooks.Close()
+
 }
 
 func (project *Project) RetrieveResource(resourcePath string) *Resource {
@@ -2938,27 +3634,46 @@ func (project *Project) LoadResource(resourcePath string) *Resource {
 		// Attempt downloading it if it's an online resource (e.g. "https://solarlune.com/media/bartender.png")
 		if url, err := urlx.Parse(resourcePath); err == nil && url.Host != "" && url.Scheme != "" {
 
-			filename := filepath.Join(project.TempDir, filepath.FromSlash(url.Hostname()+"/"+url.Path))
+			if cachedFile, cached := resourceCache.Lookup(resourcePath); cached {
 
-			req, err := grab.NewRequest(filename, url.String())
+				// Previously downloaded, so we can use the cached copy straight away, and check if it's outdated in the background.
+				loadedResource = project.RegisterResource(resourcePath, cachedFile, nil)
+				loadedResource.ParseData()
+
+				go func() {
+					if resourceCache.Revalidate(resourcePath) {
+						select {
+						case project.RefreshedResources <- resourcePath:
+						default:
+						}
+					}
+				}()
 
-			if err != nil {
-				project.Log("ERROR: Could not initiate download for [%s]\nError : [%s]", url.String(), err.Error())
 			} else {
 
-				resp := project.GrabClient.Do(req)
+				filename := filepath.Join(project.TempDir, filepath.FromSlash(url.Hostname()+"/"+url.Path))
 
-				var possibleError error
+				req, err := grab.NewRequest(filename, url.String())
 
-				// response.Err() blocks until complete, so we want to see if the response is instantly complete, and if so, see if there's any error.
-				if resp.IsComplete() {
-					possibleError = resp.Err()
-				}
-
-				if possibleError != nil {
-					project.Log("ERROR: Could not initiate download for [%s]\nError : [%s]\nAre you sure the path or URL is correct?", url.String(), possibleError.Error())
+				if err != nil {
+					project.Log("ERROR: Could not initiate download for [%s]\nError : [%s]", url.String(), err.Error())
 				} else {
-					loadedResource = project.RegisterResource(resourcePath, filename, resp)
+
+					resp := project.GrabClient.Do(req)
+
+					var possibleError error
+
+					// response.Err() blocks until complete, so we want to see if the response is instantly complete, and if so, see if there's any error.
+					if resp.IsComplete() {
+						possibleError = resp.Err()
+					}
+
+					if possibleError != nil {
+						project.Log("ERROR: Could not initiate download for [%s]\nError : [%s]\nAre you sure the path or URL is correct?", url.String(), possibleError.Error())
+					} else {
+						loadedResource = project.RegisterResource(resourcePath, filename, resp)
+					}
+
 				}
 
 			}
@@ -3039,9 +3754,16 @@ func (project *Project) OpenSettings() {
 	project.ColorThemeSpinner.SetChoice(programSettings.Theme)
 	project.DrawWindowBorder.Checked = programSettings.DrawWindowBorder
 	project.DownloadTimeout.SetNumber(programSettings.DownloadTimeout)
+	project.ResourceCacheLimit.SetNumber(programSettings.ResourceCacheLimit)
+	project.ResourceCacheUsage.Text = resourceCache.UsageText()
 	project.CopyTasksToClipboard.Checked = programSettings.CopyTasksToClipboard
 
 	project.DoubleClickRate.SetNumber(programSettings.DoubleClickRate)
+	project.SyncName.SetText(programSettings.SyncName)
+	project.SyncPort.SetNumber(programSettings.SyncPort)
+	project.AutomationAPI.Checked = programSettings.AutomationAPI
+	project.TrustCommands.Checked = project.CommandsTrusted()
+	project.AutomationAPIPort.SetNumber(programSettings.AutomationAPIPort)
 }
 
 func (project *Project) PromptQuit() {
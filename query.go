@@ -0,0 +1,593 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+)
+
+// Searches are made up of terms separated by spaces, all of which a Task has to match. Plain words (or "quoted phrases")
+// match against a Task's text, while filters narrow results down by a Task's other properties:
+//
+//   type:checkbox           Task type; several can be given, separated by commas (type:image,media)
+//   is:incomplete           complete, incomplete, completable, overdue, or due (has an unfinished deadline)
+//   due:<7d                 deadline compared (<, <=, >, >=, or =) to a date, like today, tomorrow, 3d, 2w, or 2021-05-01;
+//                           due:none matches Tasks without deadlines
+//   board:Art               Board name
+//   tag:art or #art         tag; several can be given, separated by commas (tag:art,sound)
+//   priority:p0,p1          priority (p0 to p3, or none); priority:<=p1 matches P0 and P1
+//   assignee:sam            who the Task is assigned to (or none)
+//
+// Terms can be negated by starting them with "-", and OR matches either the terms before it or the terms after it, so
+// `type:checkbox is:incomplete due:<7d board:Art "boss fight" OR -board:Art` is a valid query. Filters with
+// unknown names are searched for as plain text, so searching for URLs and the like still works.
+
+type QueryFields struct {
+	Type        int
+	Board       string
+	Text        []string
+	Completable bool
+	Complete    bool
+	Deadline    time.Time
+	Tags        []string
+	Priority    int
+	Assignee    string
+	// MatchText, if set, is used to match plain text terms (already lowercased) instead of looking through Text; the
+	// SearchIndex sets it, as it has already worked out which Tasks each term matches. Negated terms are always looked
+	// for in Text, so that excluding a word doesn't also exclude words that are only close to it.
+	MatchText func(needle string) bool
+}
+
+type queryTerm struct {
+	Negate bool
+	Match  func(fields QueryFields) bool
+	// Needle is the lowercased text to search for, if the term is plain text rather than a filter.
+	Needle string
+}
+
+type Query struct {
+	Text string
+	// groups are sets of terms that all have to match; a Task only has to match one group.
+	groups [][]*queryTerm
+}
+
+type queryToken struct {
+	Text   string
+	Quoted bool
+}
+
+// tokenizeQuery splits the query given into words, keeping quoted text (including quoted filter values, like
+// board:"Level Design") together.
+func tokenizeQuery(text string) []queryToken {
+
+	tokens := []queryToken{}
+	current := queryToken{}
+	inQuote := false
+	started := false
+
+	for _, r := range text {
+
+		if r == '"' {
+			if !started {
+				current.Quoted = true
+			}
+			inQuote = !inQuote
+			started = true
+		} else if unicode.IsSpace(r) && !inQuote {
+			if started {
+				tokens = append(tokens, current)
+			}
+			current = queryToken{}
+			started = false
+		} else {
+			current.Text += string(r)
+			started = true
+		}
+
+	}
+
+	if started {
+		tokens = append(tokens, current)
+	}
+
+	return tokens
+
+}
+
+// ParseQuery parses a search query, returning an error if a filter's value can't be understood.
+func ParseQuery(text string) (*Query, error) {
+
+	query := &Query{Text: text, groups: [][]*queryTerm{{}}}
+
+	for _, token := range tokenizeQuery(text) {
+
+		if !token.Quoted && token.Text == "OR" {
+			query.groups = append(query.groups, []*queryTerm{})
+			continue
+		}
+
+		term := &queryTerm{}
+		value := token.Text
+
+		if !token.Quoted && len(value) > 1 && value[0] == '-' {
+			term.Negate = true
+			value = value[1:]
+		}
+
+		if value == "" {
+			continue
+		}
+
+		var err error
+
+		if !token.Quoted && len(value) > 1 && value[0] == '#' {
+			term.Match, err = parseQueryFilter("tag", value[1:])
+		} else if !token.Quoted && strings.Contains(value, ":") {
+			parts := strings.SplitN(value, ":", 2)
+			term.Match, err = parseQueryFilter(strings.ToLower(parts[0]), parts[1])
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if term.Match == nil {
+			needle := strings.ToLower(value)
+			negate := term.Negate
+			term.Needle = needle
+			term.Match = func(fields QueryFields) bool {
+				if fields.MatchText != nil && !negate {
+					return fields.MatchText(needle)
+				}
+				for _, text := range fields.Text {
+					if strings.Contains(strings.ToLower(text), needle) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+
+		group := len(query.groups) - 1
+		query.groups[group] = append(query.groups[group], term)
+
+	}
+
+	return query, nil
+
+}
+
+// queryTaskTypes are the names Task types can be searched for by, in addition to their usual names.
+var queryTaskTypes = map[string]int{
+	"checkbox": TASK_TYPE_BOOLEAN,
+	"progress": TASK_TYPE_PROGRESSION,
+	"sound":    TASK_TYPE_MEDIA,
+	"audio":    TASK_TYPE_MEDIA,
+	"video":    TASK_TYPE_MEDIA,
+	"pdf":      TASK_TYPE_DOCUMENT,
+	"link":     TASK_TYPE_BOARD,
+}
+
+// parseQueryFilter returns a function to match Tasks against the filter given, or nil if the filter isn't a known one.
+func parseQueryFilter(key, value string) (func(fields QueryFields) bool, error) {
+
+	lowerValue := strings.ToLower(value)
+
+	switch key {
+
+	case "type":
+
+		types := map[int]bool{}
+
+		for _, name := range strings.Split(lowerValue, ",") {
+
+			taskType, ok := queryTaskTypes[name]
+
+			for t := TASK_TYPE_BOOLEAN; t <= TASK_TYPE_BOARD && !ok; t++ {
+				if strings.ToLower(TaskTypeStr(t)) == name {
+					taskType, ok = t, true
+				}
+			}
+
+			if !ok {
+				return nil, fmt.Errorf("unknown Task type: %s", name)
+			}
+
+			types[taskType] = true
+
+		}
+
+		return func(fields QueryFields) bool { return types[fields.Type] }, nil
+
+	case "is":
+
+		switch lowerValue {
+		case "complete", "completed", "done":
+			return func(fields QueryFields) bool { return fields.Completable && fields.Complete }, nil
+		case "incomplete", "todo":
+			return func(fields QueryFields) bool { return fields.Completable && !fields.Complete }, nil
+		case "completable":
+			return func(fields QueryFields) bool { return fields.Completable }, nil
+		case "due":
+			return func(fields QueryFields) bool { return !fields.Deadline.IsZero() && !fields.Complete }, nil
+		case "overdue":
+			return func(fields QueryFields) bool {
+				return !fields.Deadline.IsZero() && !fields.Complete && fields.Deadline.Before(queryToday())
+			}, nil
+		}
+
+		return nil, fmt.Errorf("unknown state: is:%s (try complete, incomplete, completable, due, or overdue)", value)
+
+	case "due":
+
+		if lowerValue == "none" {
+			return func(fields QueryFields) bool { return fields.Deadline.IsZero() }, nil
+		}
+
+		compare, date, err := parseQueryDate(lowerValue)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(fields QueryFields) bool { return !fields.Deadline.IsZero() && compare(fields.Deadline, date) }, nil
+
+	case "tag":
+
+		tags := map[string]bool{}
+		for _, tag := range splitTags(lowerValue) {
+			tags[tag] = true
+		}
+
+		return func(fields QueryFields) bool {
+			for _, tag := range fields.Tags {
+				if tags[tag] {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case "board":
+
+		return func(fields QueryFields) bool { return strings.Contains(strings.ToLower(fields.Board), lowerValue) }, nil
+
+	case "priority":
+
+		if strings.HasPrefix(lowerValue, "<=") {
+
+			priority := parsePriority(lowerValue[2:])
+			if priority == PRIORITY_NONE {
+				return nil, fmt.Errorf("unknown priority: %s (try p0, p1, p2, or p3)", value[2:])
+			}
+
+			return func(fields QueryFields) bool { return fields.Priority != PRIORITY_NONE && fields.Priority <= priority }, nil
+
+		}
+
+		priorities := map[int]bool{}
+
+		for _, name := range strings.Split(lowerValue, ",") {
+
+			priority := parsePriority(name)
+			if priority == PRIORITY_NONE && name != "none" {
+				return nil, fmt.Errorf("unknown priority: %s (try p0, p1, p2, p3, or none)", name)
+			}
+
+			priorities[priority] = true
+
+		}
+
+		// Only completable Tasks can have priorities, so priority:none doesn't match Notes, Images, and so on
+		return func(fields QueryFields) bool {
+			return priorities[fields.Priority] && (fields.Priority != PRIORITY_NONE || fields.Completable)
+		}, nil
+
+	case "assignee":
+
+		if lowerValue == "none" {
+			return func(fields QueryFields) bool { return fields.Completable && fields.Assignee == "" }, nil
+		}
+
+		return func(fields QueryFields) bool { return strings.Contains(strings.ToLower(fields.Assignee), lowerValue) }, nil
+
+	}
+
+	return nil, nil
+
+}
+
+var queryRelativeDate = regexp.MustCompile(`^(-?\d+)([dw])$`)
+
+// queryToday returns the start of today, which deadlines are compared against.
+func queryToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// parseQueryDate parses a due: filter's value into a comparison and the date to compare against.
+func parseQueryDate(value string) (func(a, b time.Time) bool, time.Time, error) {
+
+	compare := func(a, b time.Time) bool { return a.Equal(b) }
+
+	for _, op := range []string{"<=", ">=", "<", ">", "="} {
+
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+
+		value = value[len(op):]
+
+		switch op {
+		case "<=":
+			compare = func(a, b time.Time) bool { return !a.After(b) }
+		case ">=":
+			compare = func(a, b time.Time) bool { return !a.Before(b) }
+		case "<":
+			compare = func(a, b time.Time) bool { return a.Before(b) }
+		case ">":
+			compare = func(a, b time.Time) bool { return a.After(b) }
+		}
+
+		break
+
+	}
+
+	today := queryToday()
+
+	switch value {
+	case "today":
+		return compare, today, nil
+	case "tomorrow":
+		return compare, today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return compare, today.AddDate(0, 0, -1), nil
+	}
+
+	if match := queryRelativeDate.FindStringSubmatch(value); match != nil {
+		count, _ := strconv.Atoi(match[1])
+		if match[2] == "w" {
+			count *= 7
+		}
+		return compare, today.AddDate(0, 0, count), nil
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, today.Location()); err == nil {
+		return compare, date, nil
+	}
+
+	return nil, time.Time{}, fmt.Errorf("unknown date: %s (try today, tomorrow, 3d, 2w, or 2021-05-01)", value)
+
+}
+
+// Empty returns if the query has no terms, and so matches nothing.
+func (query *Query) Empty() bool {
+	for _, group := range query.groups {
+		if len(group) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Needles returns the plain text terms in the query that Tasks are searched for by; negated terms are left out, as they
+// only exclude Tasks.
+func (query *Query) Needles() []string {
+
+	needles := []string{}
+
+	for _, group := range query.groups {
+		for _, term := range group {
+			if term.Needle != "" && !term.Negate {
+				needles = append(needles, term.Needle)
+			}
+		}
+	}
+
+	return needles
+
+}
+
+// RequiredNeedles returns, for each group of terms in the query, a plain text term that Tasks matching that group must
+// contain; ok is false if any group can match Tasks without containing some text (if it's made up of only filters, for
+// example).
+func (query *Query) RequiredNeedles() (needles [][]string, ok bool) {
+
+	for _, group := range query.groups {
+
+		if len(group) == 0 {
+			continue
+		}
+
+		required := []string{}
+
+		for _, term := range group {
+			if term.Needle != "" && !term.Negate {
+				required = append(required, term.Needle)
+			}
+		}
+
+		if len(required) == 0 {
+			return nil, false
+		}
+
+		needles = append(needles, required)
+
+	}
+
+	return needles, true
+
+}
+
+// Match returns if a Task with the fields given matches the query.
+func (query *Query) Match(fields QueryFields) bool {
+
+	for _, group := range query.groups {
+
+		if len(group) == 0 {
+			continue
+		}
+
+		matched := true
+
+		for _, term := range group {
+			if term.Match(fields) == term.Negate {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
+// QueryFields returns the parts of the Task that searches can match against.
+func (task *Task) QueryFields() QueryFields {
+
+	fields := QueryFields{
+		Type:        task.TaskType.CurrentChoice,
+		Board:       task.Board.Name,
+		Text:        []string{task.Description.Text()},
+		Completable: task.IsCompletable(),
+		Complete:    task.IsComplete(),
+		Tags:        task.Tags(),
+		Priority:    task.Priority(),
+		Assignee:    task.Assignee(),
+	}
+
+	if task.UsesMedia() {
+		fields.Text = append(fields.Text, task.FilePathTextbox.Text())
+	}
+
+	if task.Is(TASK_TYPE_TIMER) {
+		fields.Text = append(fields.Text, task.TimerName.Text())
+	}
+
+	if task.Is(TASK_TYPE_TABLE) && task.TableData != nil {
+		for _, element := range task.TableData.Elements() {
+			fields.Text = append(fields.Text, element.Textbox.Text())
+		}
+	}
+
+	if task.DeadlineOn.Checked {
+		fields.Deadline = time.Date(task.DeadlineYear.Number(), time.Month(task.DeadlineMonth.CurrentChoice+1), task.DeadlineDay.Number(), 0, 0, 0, 0, time.Now().Location())
+	}
+
+	return fields
+
+}
+
+// queryFields returns the parts of the summarized Task that searches can match against; it mirrors Task.QueryFields().
+func (ts *TaskSummary) queryFields(board string) QueryFields {
+	return QueryFields{
+		Type:        ts.taskType,
+		Board:       board,
+		Text:        append([]string{ts.Description, ts.Title}, ts.tableNames...),
+		Completable: ts.IsCompletable(),
+		Complete:    ts.IsComplete(),
+		Deadline:    ts.deadline,
+		Tags:        ts.Tags,
+		Priority:    parsePriority(ts.Priority),
+		Assignee:    ts.Assignee,
+	}
+}
+
+// SearchTitle returns a single line describing the Task for search results.
+func (task *Task) SearchTitle() string {
+
+	title := strings.TrimSpace(strings.Split(task.Description.Text(), "\n")[0])
+
+	if task.UsesMedia() {
+		title = filepath.Base(task.FilePathTextbox.Text())
+	} else if task.Is(TASK_TYPE_TIMER) {
+		title = task.TimerName.Text()
+	}
+
+	if title == "" {
+		title = fmt.Sprintf("(%s Task)", TaskTypeStr(task.TaskType.CurrentChoice))
+	}
+
+	if runes := []rune(title); len(runes) > 40 {
+		title = string(runes[:40]) + "..."
+	}
+
+	return title
+
+}
+
+// queryPlan prints the Tasks in a plan that match a search query.
+func queryPlan(args []string) error {
+
+	if len(args) < 2 {
+		return errors.New("usage: masterplan query <plan file> <query>")
+	}
+
+	planFile, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	query, err := ParseQuery(strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+
+	summary := SummarizePlan(filepath.Base(args[0]), gjson.Parse(string(planFile)))
+
+	matches := []*TaskSummary{}
+
+	for _, ts := range summary.tasks {
+		if query.Match(ts.queryFields(summary.Boards[ts.Board].Name)) {
+			matches = append(matches, ts)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Board != b.Board {
+			return a.Board < b.Board
+		} else if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+
+	for _, ts := range matches {
+
+		line := fmt.Sprintf("%s : [%s] %s", summary.Boards[ts.Board].Name, ts.Type, ts.Title)
+
+		if ts.Completable {
+			if ts.Complete {
+				line += " (complete)"
+			} else {
+				line += " (incomplete)"
+			}
+		}
+
+		if ts.Deadline != "" {
+			line += " due " + ts.Deadline
+		}
+
+		fmt.Fprintln(terminalOutput, line)
+
+	}
+
+	fmt.Fprintf(terminalOutput, "%d matching Task(s).\n", len(matches))
+
+	return nil
+
+}
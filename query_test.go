@@ -0,0 +1,188 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+
+	today := queryToday()
+
+	boss := QueryFields{
+		Type:        TASK_TYPE_BOOLEAN,
+		Board:       "Art",
+		Text:        []string{"Draw the boss fight", "https://example.com/boss"},
+		Completable: true,
+		Deadline:    today.AddDate(0, 0, 3),
+		Tags:        []string{"art", "sprites"},
+		Priority:    PRIORITY_P1,
+		Assignee:    "Sam",
+	}
+
+	notes := QueryFields{
+		Type:  TASK_TYPE_NOTE,
+		Board: "Design",
+		Text:  []string{"Boss ideas"},
+	}
+
+	late := QueryFields{
+		Type:        TASK_TYPE_PROGRESSION,
+		Board:       "Level Design",
+		Text:        []string{"Tile the castle"},
+		Completable: true,
+		Deadline:    today.AddDate(0, 0, -2),
+	}
+
+	done := QueryFields{
+		Type:        TASK_TYPE_BOOLEAN,
+		Board:       "Art",
+		Text:        []string{"Title screen"},
+		Completable: true,
+		Complete:    true,
+		Deadline:    today.AddDate(0, 0, -2),
+		Priority:    PRIORITY_P3,
+	}
+
+	all := map[string]QueryFields{"boss": boss, "notes": notes, "late": late, "done": done}
+
+	queries := []struct {
+		query   string
+		matches []string
+	}{
+		{`boss`, []string{"boss", "notes"}},
+		{`BOSS fight`, []string{"boss"}},
+		{`"boss fight"`, []string{"boss"}},
+		{`"fight boss"`, nil},
+		{`-boss`, []string{"late", "done"}},
+		{`"-boss"`, nil},
+		{`type:checkbox`, []string{"boss", "done"}},
+		{`type:note,progress`, []string{"notes", "late"}},
+		{`is:complete`, []string{"done"}},
+		{`is:incomplete`, []string{"boss", "late"}},
+		{`is:completable`, []string{"boss", "late", "done"}},
+		{`is:due`, []string{"boss", "late"}},
+		{`is:overdue`, []string{"late"}},
+		{`due:<today`, []string{"late", "done"}},
+		{`due:<=3d`, []string{"boss", "late", "done"}},
+		{`due:>1d`, []string{"boss"}},
+		{`due:none`, []string{"notes"}},
+		{`board:art`, []string{"boss", "done"}},
+		{`board:"level design"`, []string{"late"}},
+		{`#sprites`, []string{"boss"}},
+		{`tag:sound,art`, []string{"boss"}},
+		{`priority:p1,p3`, []string{"boss", "done"}},
+		{`priority:<=p1`, []string{"boss"}},
+		{`priority:none`, []string{"late"}},
+		{`assignee:sam`, []string{"boss"}},
+		{`assignee:none`, []string{"late", "done"}},
+		{`type:checkbox is:incomplete OR board:design`, []string{"boss", "notes", "late"}},
+		{`board:art -is:complete OR is:overdue`, []string{"boss", "late"}},
+		// Filters that aren't known are searched for as text, so URLs can still be found.
+		{`https://example.com/boss`, []string{"boss"}},
+	}
+
+	for _, q := range queries {
+
+		query, err := ParseQuery(q.query)
+		if err != nil {
+			t.Errorf("expected %s to parse, got %s", q.query, err.Error())
+			continue
+		}
+
+		expected := map[string]bool{}
+		for _, name := range q.matches {
+			expected[name] = true
+		}
+
+		for name, fields := range all {
+			if query.Match(fields) != expected[name] {
+				t.Errorf("expected %s to match %s: %t", q.query, name, expected[name])
+			}
+		}
+
+	}
+
+	for _, invalid := range []string{`type:spreadsheet`, `is:sideways`, `due:<someday`, `priority:p9`, `priority:<=none`} {
+		if _, err := ParseQuery(invalid); err == nil {
+			t.Errorf("expected %s not to parse", invalid)
+		}
+	}
+
+	if query, _ := ParseQuery(`  `); !query.Empty() || query.Match(boss) {
+		t.Error("expected a blank query to be empty and match nothing")
+	}
+
+	query, _ := ParseQuery(`boss "title screen" -draft OR type:note`)
+
+	if needles := query.Needles(); len(needles) != 2 || needles[0] != "boss" || needles[1] != "title screen" {
+		t.Errorf("expected the query's text to be boss and title screen, got %v", needles)
+	}
+
+	// The second group can match without containing any text, so the search index can't narrow the query down.
+	if _, ok := query.RequiredNeedles(); ok {
+		t.Error("expected a query with a group of only filters to have no required text")
+	}
+
+}
+
+func TestParseQueryDate(t *testing.T) {
+
+	today := queryToday()
+
+	dates := map[string]time.Time{
+		"today":      today,
+		"tomorrow":   today.AddDate(0, 0, 1),
+		"yesterday":  today.AddDate(0, 0, -1),
+		"3d":         today.AddDate(0, 0, 3),
+		"-2d":        today.AddDate(0, 0, -2),
+		"2w":         today.AddDate(0, 0, 14),
+		"2021-05-01": time.Date(2021, 5, 1, 0, 0, 0, 0, today.Location()),
+	}
+
+	for value, expected := range dates {
+
+		_, date, err := parseQueryDate(value)
+
+		if err != nil {
+			t.Errorf("expected %s to parse, got %s", value, err.Error())
+		} else if !date.Equal(expected) {
+			t.Errorf("expected %s to be %s, got %s", value, expected, date)
+		}
+
+	}
+
+	before, after := today.AddDate(0, 0, -1), today.AddDate(0, 0, 1)
+
+	comparisons := []struct {
+		op                  string
+		before, same, after bool
+	}{
+		{"", false, true, false},
+		{"=", false, true, false},
+		{"<", true, false, false},
+		{"<=", true, true, false},
+		{">", false, false, true},
+		{">=", false, true, true},
+	}
+
+	for _, c := range comparisons {
+
+		compare, date, err := parseQueryDate(c.op + "today")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if compare(before, date) != c.before || compare(today, date) != c.same || compare(after, date) != c.after {
+			t.Errorf("expected %stoday to match yesterday: %t, today: %t, tomorrow: %t", c.op, c.before, c.same, c.after)
+		}
+
+	}
+
+	for _, invalid := range []string{"someday", "3y", "2021-13-01", "<", "d"} {
+		if _, _, err := parseQueryDate(invalid); err == nil {
+			t.Errorf("expected %s not to parse", invalid)
+		}
+	}
+
+}
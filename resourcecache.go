@@ -0,0 +1,316 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// Images downloaded from URLs are kept in a cache in the user's cache directory, so that plans with remote images load
+// instantly (and work offline) after the images have been seen once. Cached images are revalidated with the server once
+// a session using their ETag or Last-Modified headers, and the least recently used images are removed when the cache
+// grows past its size limit.
+
+const RESOURCE_CACHE_PATH = "MasterPlan/resources"
+
+type CachedResource struct {
+	URL          string
+	File         string
+	ETag         string
+	LastModified string
+	Size         int64
+	LastUsed     time.Time
+}
+
+type ResourceCache struct {
+	Entries map[string]*CachedResource
+
+	loaded      bool
+	used        bool // Whether any entries have been used since the index was last saved
+	revalidated map[string]bool
+	mutex       sync.Mutex
+}
+
+var resourceCache = &ResourceCache{
+	Entries:     map[string]*CachedResource{},
+	revalidated: map[string]bool{},
+}
+
+func resourceCacheDirectory() string {
+	return filepath.Join(xdg.CacheHome, filepath.FromSlash(RESOURCE_CACHE_PATH))
+}
+
+// load reads the cache's index from disk, if it hasn't been read already. The mutex should be locked by the caller.
+func (cache *ResourceCache) load() {
+
+	if cache.loaded {
+		return
+	}
+
+	cache.loaded = true
+
+	if data, err := ioutil.ReadFile(filepath.Join(resourceCacheDirectory(), "index.json")); err == nil {
+		json.Unmarshal(data, &cache.Entries)
+	}
+
+	// Drop entries whose files have been removed from under us
+	for url, entry := range cache.Entries {
+		if !FileExists(filepath.Join(resourceCacheDirectory(), entry.File)) {
+			delete(cache.Entries, url)
+		}
+	}
+
+}
+
+// save writes the cache's index to disk. The mutex should be locked by the caller.
+func (cache *ResourceCache) save() {
+
+	if err := os.MkdirAll(resourceCacheDirectory(), 0755); err != nil {
+		return
+	}
+
+	if data, err := json.MarshalIndent(cache.Entries, "", "  "); err == nil {
+		ioutil.WriteFile(filepath.Join(resourceCacheDirectory(), "index.json"), data, 0644)
+	}
+
+	cache.used = false
+
+}
+
+// Lookup returns the cached file for the URL given, if it's been cached. When the file was last used is only updated in
+// memory, as plans can look up a lot of images while loading; it's written to disk along with the next change to the
+// cache, or when MasterPlan closes (see Flush()).
+func (cache *ResourceCache) Lookup(url string) (string, bool) {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.load()
+
+	entry, exists := cache.Entries[url]
+	if !exists {
+		return "", false
+	}
+
+	entry.LastUsed = time.Now()
+	cache.used = true
+
+	return filepath.Join(resourceCacheDirectory(), entry.File), true
+
+}
+
+// Store copies a file downloaded from the URL given into the cache, along with the headers needed to revalidate it later.
+func (cache *ResourceCache) Store(url, localFilepath string, header http.Header) error {
+
+	if programSettings.ResourceCacheLimit <= 0 {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(localFilepath)
+	if err != nil {
+		return err
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.load()
+
+	sum := sha256.Sum256([]byte(url))
+	entry := &CachedResource{
+		URL:          url,
+		File:         hex.EncodeToString(sum[:16]) + strings.ToLower(filepath.Ext(localFilepath)),
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Size:         int64(len(data)),
+		LastUsed:     time.Now(),
+	}
+
+	if err := os.MkdirAll(resourceCacheDirectory(), 0755); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(resourceCacheDirectory(), entry.File), data, 0644); err != nil {
+		return err
+	}
+
+	cache.Entries[url] = entry
+	cache.revalidated[url] = true // Just downloaded, so it's as fresh as it gets
+	cache.prune()
+	cache.save()
+
+	return nil
+
+}
+
+// Revalidate checks with the server whether the cached copy of the URL given is still current, downloading the new
+// version into the cache if it isn't. Revalidate returns true if the cached file changed. Each URL is only revalidated
+// once per session, and failures (like being offline) simply leave the cached copy as it is.
+func (cache *ResourceCache) Revalidate(url string) bool {
+
+	cache.mutex.Lock()
+
+	entry, exists := cache.Entries[url]
+	if !exists || cache.revalidated[url] {
+		cache.mutex.Unlock()
+		return false
+	}
+
+	cache.revalidated[url] = true
+	etag, lastModified := entry.ETag, entry.LastModified
+	file := filepath.Join(resourceCacheDirectory(), entry.File)
+
+	cache.mutex.Unlock()
+
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return false
+	}
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		request.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	client := &http.Client{Timeout: time.Second * time.Duration(programSettings.DownloadTimeout)}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return false // 304 Not Modified, or an error we can't do anything about
+	}
+
+	// Download to a temporary file first, so the cached copy is never left half-written
+	temp, err := ioutil.TempFile(resourceCacheDirectory(), "download")
+	if err != nil {
+		return false
+	}
+
+	size, err := io.Copy(temp, response.Body)
+	temp.Close()
+
+	if err != nil || os.Rename(temp.Name(), file) != nil {
+		os.Remove(temp.Name())
+		return false
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if entry, exists := cache.Entries[url]; exists {
+		entry.ETag = response.Header.Get("ETag")
+		entry.LastModified = response.Header.Get("Last-Modified")
+		entry.Size = size
+		cache.prune()
+		cache.save()
+	}
+
+	return true
+
+}
+
+// prune removes the least recently used files from the cache until it fits in its size limit. The mutex should be locked
+// by the caller.
+func (cache *ResourceCache) prune() {
+
+	limit := int64(programSettings.ResourceCacheLimit) * 1024 * 1024
+
+	entries := []*CachedResource{}
+	total := int64(0)
+
+	for _, entry := range cache.Entries {
+		entries = append(entries, entry)
+		total += entry.Size
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.Before(entries[j].LastUsed) })
+
+	for _, entry := range entries {
+		if total <= limit {
+			break
+		}
+		os.Remove(filepath.Join(resourceCacheDirectory(), entry.File))
+		delete(cache.Entries, entry.URL)
+		total -= entry.Size
+	}
+
+}
+
+// Flush writes when cached files were last used to disk, if any have been used since the cache's index was last saved.
+func (cache *ResourceCache) Flush() {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.used {
+		cache.save()
+	}
+
+}
+
+// Prune removes files from the cache until it fits in its size limit (for example, after the limit has been lowered).
+func (cache *ResourceCache) Prune() {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.load()
+	cache.prune()
+	cache.save()
+
+}
+
+// Usage returns the number of files in the cache and their total size in bytes.
+func (cache *ResourceCache) Usage() (int, int64) {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.load()
+
+	total := int64(0)
+	for _, entry := range cache.Entries {
+		total += entry.Size
+	}
+
+	return len(cache.Entries), total
+
+}
+
+// Clear removes every file from the cache.
+func (cache *ResourceCache) Clear() error {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.Entries = map[string]*CachedResource{}
+	cache.loaded = true
+
+	return os.RemoveAll(resourceCacheDirectory())
+
+}
+
+// UsageText describes how full the cache is, for the settings panel.
+func (cache *ResourceCache) UsageText() string {
+	count, size := cache.Usage()
+	return fmt.Sprintf("%d image(s) cached, using %.1f of %d MB", count, float64(size)/1024/1024, programSettings.ResourceCacheLimit)
+}
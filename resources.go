@@ -49,6 +49,12 @@ type Resource struct {
 	valid bool
 }
 
+// MediaFile marks a Resource as an audio or video file. Media Tasks open the file themselves (see MusicStream and Video),
+// so that each one plays from its own position; the Resource only records which kind of file it is.
+type MediaFile struct {
+	Video bool
+}
+
 func (project *Project) RegisterResource(resourcePath, localFilepath string, response *grab.Response) *Resource {
 
 	modTime := time.Time{}
@@ -145,6 +151,12 @@ func (res *Resource) ParseData() error {
 				res.Data = rl.LoadTexture(res.LocalFilepath)
 			}
 
+		} else if strings.Contains(res.MimeData.String(), "audio") {
+			res.Data = MediaFile{}
+		} else if strings.Contains(res.MimeData.String(), "video") {
+			res.Data = MediaFile{Video: true}
+		} else if res.MimeIsDocument() {
+			res.Data = NewDocument(res.LocalFilepath, res.MimeData.String(), res.Project.TempDir)
 		} else {
 			err = errors.New("unrecognized resource type")
 			delete(res.Project.Resources, res.ResourcePath) // Delete the resource if it isn't recognized, as it shouldn't be used anyway (hopefully this is OK?)
@@ -166,6 +178,15 @@ func (res *Resource) MimeIsImage() bool {
 	return res.MimeData != nil && strings.Contains(res.MimeData.String(), "image")
 }
 
+func (res *Resource) MimeIsMedia() bool {
+	return res.MimeData != nil && (strings.Contains(res.MimeData.String(), "audio") || strings.Contains(res.MimeData.String(), "video"))
+}
+
+// MimeIsDocument returns if the Resource is a PDF or text file, which can be previewed in a Document Task.
+func (res *Resource) MimeIsDocument() bool {
+	return res.MimeData != nil && (res.MimeData.Is("application/pdf") || strings.HasPrefix(res.MimeData.String(), "text/"))
+}
+
 func (res *Resource) State() int {
 
 	if !res.valid {
@@ -208,6 +229,25 @@ func (res *Resource) Gif() *GifAnimation {
 	return res.Data.(*GifAnimation)
 }
 
+func (res *Resource) IsMusic() bool {
+	media, isMedia := res.Data.(MediaFile)
+	return isMedia && !media.Video
+}
+
+func (res *Resource) IsVideo() bool {
+	media, isMedia := res.Data.(MediaFile)
+	return isMedia && media.Video
+}
+
+func (res *Resource) IsDocument() bool {
+	_, isDocument := res.Data.(*Document)
+	return isDocument
+}
+
+func (res *Resource) Document() *Document {
+	return res.Data.(*Document)
+}
+
 // Progress returns the progress of downloading or loading the resource, as an integer ranging from 0 to 100. If the returned value is less than 0, the progress cannot be determined.
 func (res *Resource) Progress() int {
 	if res.DownloadResponse != nil && !res.DownloadResponse.IsComplete() {
@@ -227,6 +267,8 @@ func (res *Resource) Destroy() {
 		rl.UnloadTexture(res.Texture())
 	} else if res.IsGif() {
 		res.Gif().Destroy()
+	} else if res.IsDocument() {
+		res.Document().Destroy()
 	}
 	// GIFs don't need to be disposed of directly here; the file handle was already Closed.
 
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The SearchIndex keeps track of the words in every Task's text, so searching doesn't have to go through (and lowercase)
+// the text of every Task in the Project each time the search bar changes. Tasks are only indexed again when they change,
+// which is whenever they create an undo state or are deserialized (as they are when loading, pasting, undoing, or
+// receiving changes from a LAN session).
+//
+// Plain text search terms match Tasks with words that contain them; longer terms also match words that are a letter or
+// two off, so typos still find things (though excluding a term, like -boss, only excludes Tasks that contain it).
+// Results are ranked by how closely, and where, each term matched; a match in a Task's first line counts for more than
+// one further down in its description, for example.
+
+const (
+	searchWeightBody  = 1
+	searchWeightName  = 2 // File paths, Timer names, Table rows and columns, and assignees
+	searchWeightTags  = 3
+	searchWeightTitle = 4 // The first line of a Task's description
+)
+
+// How closely a word has to match a term, from best to worst.
+const (
+	searchMatchExact     = 1
+	searchMatchPrefix    = 0.75
+	searchMatchSubstring = 0.5
+	searchMatchFuzzy     = 0.25
+)
+
+// searchFuzzyMinLength is how long a term has to be before words that are only close to it match.
+const searchFuzzyMinLength = 4
+
+type searchField struct {
+	Text   string
+	Weight float32
+}
+
+// A searchSuffix is the end of an indexed word; as every suffix of every word is kept sorted, the words containing a term
+// are the ones with suffixes that start with it, and they're all next to each other.
+type searchSuffix struct {
+	Suffix string
+	Word   string
+}
+
+type searchDocument struct {
+	// text is all of the Task's searchable text, lowercased, for matching terms that span more than one word.
+	text  string
+	words map[string]float32
+}
+
+type SearchIndex struct {
+	documents map[*Task]*searchDocument
+	// postings holds, for each word, the Tasks it appears in, along with the weight of the most important field it
+	// appears in for each Task.
+	postings map[string]map[*Task]float32
+	dirty    map[*Task]bool
+	// suffixes and lengths are built from postings when needed (see buildVocabulary()), and dropped when it changes.
+	suffixes []searchSuffix
+	lengths  map[int][]string
+	// lookups caches the results of Lookup() until the index next changes.
+	lookups map[string]map[*Task]float32
+	// filtered caches whether Tasks match filterQuery (see FilterMatches()).
+	filterQuery *Query
+	filterDay   time.Time
+	filtered    map[*Task]filterMatch
+}
+
+type filterMatch struct {
+	Board   string
+	Matches bool
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		documents: map[*Task]*searchDocument{},
+		postings:  map[string]map[*Task]float32{},
+		dirty:     map[*Task]bool{},
+		lookups:   map[string]map[*Task]float32{},
+		filtered:  map[*Task]filterMatch{},
+	}
+}
+
+// searchWords splits text into lowercased words.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+}
+
+// searchFields returns the Task's searchable text.
+func (task *Task) searchFields() []searchField {
+
+	lines := strings.SplitN(task.Description.Text(), "\n", 2)
+
+	fields := []searchField{{lines[0], searchWeightTitle}}
+
+	if len(lines) > 1 {
+		fields = append(fields, searchField{lines[1], searchWeightBody})
+	}
+
+	if task.UsesMedia() {
+		fields = append(fields, searchField{task.FilePathTextbox.Text(), searchWeightName})
+	}
+
+	if task.Is(TASK_TYPE_TIMER) {
+		fields = append(fields, searchField{task.TimerName.Text(), searchWeightName})
+	}
+
+	if task.Is(TASK_TYPE_TABLE) && task.TableData != nil {
+		for _, element := range task.TableData.Elements() {
+			fields = append(fields, searchField{element.Textbox.Text(), searchWeightName})
+		}
+	}
+
+	if assignee := task.Assignee(); assignee != "" {
+		fields = append(fields, searchField{assignee, searchWeightName})
+	}
+
+	fields = append(fields, searchField{task.TagsTextbox.Text(), searchWeightTags})
+
+	return fields
+
+}
+
+// Invalidate marks the Task to be indexed again before the next search. Whether any Task matches the active filter is
+// worked out again, too, as a change to one Task can change whether others match (parent Tasks are complete when their
+// sub-Tasks are, for example).
+func (index *SearchIndex) Invalidate(task *Task) {
+	index.dirty[task] = true
+	if len(index.filtered) > 0 {
+		index.filtered = map[*Task]filterMatch{}
+	}
+}
+
+// FilterMatches returns if the Task matches the filter query given. As filters are checked for every Task every frame,
+// the result is kept until the Task is invalidated, the query changes, the Task's Board is renamed, or the day changes
+// (as filters can compare deadlines to today).
+func (index *SearchIndex) FilterMatches(query *Query, task *Task) bool {
+
+	if today := queryToday(); query != index.filterQuery || !today.Equal(index.filterDay) {
+		index.filterQuery = query
+		index.filterDay = today
+		index.filtered = map[*Task]filterMatch{}
+	}
+
+	if cached, exists := index.filtered[task]; exists && cached.Board == task.Board.Name {
+		return cached.Matches
+	}
+
+	matches := query.Match(task.QueryFields())
+	index.filtered[task] = filterMatch{Board: task.Board.Name, Matches: matches}
+
+	return matches
+
+}
+
+func (index *SearchIndex) add(task *Task) {
+
+	index.remove(task)
+
+	document := &searchDocument{words: map[string]float32{}}
+	texts := []string{}
+
+	for _, field := range task.searchFields() {
+
+		texts = append(texts, strings.ToLower(field.Text))
+
+		for _, word := range searchWords(field.Text) {
+			if field.Weight > document.words[word] {
+				document.words[word] = field.Weight
+			}
+		}
+
+	}
+
+	document.text = strings.Join(texts, "\n")
+
+	for word, weight := range document.words {
+		if _, exists := index.postings[word]; !exists {
+			index.postings[word] = map[*Task]float32{}
+		}
+		index.postings[word][task] = weight
+	}
+
+	index.documents[task] = document
+
+}
+
+func (index *SearchIndex) remove(task *Task) {
+
+	document, exists := index.documents[task]
+	if !exists {
+		return
+	}
+
+	for word := range document.words {
+		delete(index.postings[word], task)
+		if len(index.postings[word]) == 0 {
+			delete(index.postings, word)
+		}
+	}
+
+	delete(index.documents, task)
+
+}
+
+// Sync brings the index up to date with the Tasks given, indexing new and changed Tasks and dropping ones that have been
+// deleted.
+func (index *SearchIndex) Sync(tasks []*Task) {
+
+	present := map[*Task]bool{}
+	changed := false
+
+	for _, task := range tasks {
+
+		present[task] = true
+
+		if _, indexed := index.documents[task]; !indexed || index.dirty[task] {
+			index.add(task)
+			changed = true
+		}
+
+	}
+
+	for task := range index.documents {
+		if !present[task] {
+			index.remove(task)
+			changed = true
+		}
+	}
+
+	index.dirty = map[*Task]bool{}
+
+	if changed {
+		index.lookups = map[string]map[*Task]float32{}
+		index.suffixes = nil
+		index.lengths = nil
+	}
+
+}
+
+// Lookup returns the indexed Tasks that match the (lowercased) search term given, along with how well they match.
+func (index *SearchIndex) Lookup(needle string) map[*Task]float32 {
+
+	if results, cached := index.lookups[needle]; cached {
+		return results
+	}
+
+	results := map[*Task]float32{}
+	words := searchWords(needle)
+
+	if len(words) == 1 && words[0] == needle {
+
+		for word, closeness := range index.matchingWords(needle) {
+
+			for task, weight := range index.postings[word] {
+				if score := closeness * weight; score > results[task] {
+					results[task] = score
+				}
+			}
+
+		}
+
+	} else {
+
+		// The term spans words (or has punctuation in it, like a URL), so the Tasks with all of its words in them are
+		// checked to see if they actually contain the whole term.
+		var candidates map[*Task]float32
+
+		for i, word := range words {
+
+			best := map[*Task]float32{}
+
+			for indexed := range index.wordsContaining(word) {
+				for task, weight := range index.postings[indexed] {
+					if weight > best[task] {
+						best[task] = weight
+					}
+				}
+			}
+
+			found := map[*Task]float32{}
+
+			for task, weight := range best {
+				if score, ok := candidates[task]; ok || i == 0 {
+					found[task] = score + weight*searchMatchSubstring
+				}
+			}
+
+			candidates = found
+
+		}
+
+		if len(words) == 0 {
+			candidates = map[*Task]float32{}
+			for task := range index.documents {
+				candidates[task] = searchWeightBody
+			}
+		}
+
+		for task, score := range candidates {
+			if strings.Contains(index.documents[task].text, needle) {
+				results[task] = score
+			}
+		}
+
+	}
+
+	index.lookups[needle] = results
+
+	return results
+
+}
+
+// buildVocabulary sorts the suffixes of every indexed word, and groups the words by their length in runes, if the index
+// has changed since they were last built.
+func (index *SearchIndex) buildVocabulary() {
+
+	if index.suffixes != nil {
+		return
+	}
+
+	index.suffixes = []searchSuffix{}
+	index.lengths = map[int][]string{}
+
+	for word := range index.postings {
+
+		for i := range word {
+			index.suffixes = append(index.suffixes, searchSuffix{Suffix: word[i:], Word: word})
+		}
+
+		length := utf8.RuneCountInString(word)
+		index.lengths[length] = append(index.lengths[length], word)
+
+	}
+
+	sort.Slice(index.suffixes, func(i, j int) bool { return index.suffixes[i].Suffix < index.suffixes[j].Suffix })
+
+}
+
+// wordsContaining returns the indexed words that contain the search term, along with how closely they match it.
+func (index *SearchIndex) wordsContaining(needle string) map[string]float32 {
+
+	index.buildVocabulary()
+
+	words := map[string]float32{}
+
+	start := sort.Search(len(index.suffixes), func(i int) bool { return index.suffixes[i].Suffix >= needle })
+
+	for _, suffix := range index.suffixes[start:] {
+
+		if !strings.HasPrefix(suffix.Suffix, needle) {
+			break
+		}
+
+		closeness := float32(searchMatchSubstring)
+
+		if suffix.Word == needle {
+			closeness = searchMatchExact
+		} else if len(suffix.Suffix) == len(suffix.Word) {
+			closeness = searchMatchPrefix
+		}
+
+		if closeness > words[suffix.Word] {
+			words[suffix.Word] = closeness
+		}
+
+	}
+
+	return words
+
+}
+
+// matchingWords returns the indexed words that match the search term, along with how closely they match it; longer terms
+// also match words that are within an edit or two of them.
+func (index *SearchIndex) matchingWords(needle string) map[string]float32 {
+
+	words := index.wordsContaining(needle)
+
+	length := utf8.RuneCountInString(needle)
+
+	if length < searchFuzzyMinLength {
+		return words
+	}
+
+	maxEdits := 1
+	if length >= 8 {
+		maxEdits = 2
+	}
+
+	// Words that are more letters longer or shorter than the term than there are edits allowed can't be close enough
+	for l := length - maxEdits; l <= length+maxEdits; l++ {
+		for _, word := range index.lengths[l] {
+			if _, matched := words[word]; !matched && withinEditDistance(needle, word, maxEdits) {
+				words[word] = searchMatchFuzzy
+			}
+		}
+	}
+
+	return words
+
+}
+
+// withinEditDistance returns if a can be turned into b with no more than max single-character insertions, deletions, or
+// substitutions.
+func withinEditDistance(a, b string, max int) bool {
+
+	ra, rb := []rune(a), []rune(b)
+
+	if diff := len(ra) - len(rb); diff > max || diff < -max {
+		return false
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+
+		current[0] = i
+		rowMin := current[0]
+
+		for j := 1; j <= len(rb); j++ {
+
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+
+			if current[j] < rowMin {
+				rowMin = current[j]
+			}
+
+		}
+
+		// No path through the rest of the table can get back under the limit
+		if rowMin > max {
+			return false
+		}
+
+		previous, current = current, previous
+
+	}
+
+	return previous[len(rb)] <= max
+
+}
+
+// Search returns the Tasks given that match the query, best matches first. Tasks that match equally well (as with queries
+// made up of only filters) keep the order they were given in.
+func (index *SearchIndex) Search(query *Query, tasks []*Task) []*Task {
+
+	index.Sync(tasks)
+
+	scores := map[string]map[*Task]float32{}
+	for _, needle := range query.Needles() {
+		scores[needle] = index.Lookup(needle)
+	}
+
+	// If every way of matching the query requires some text, only the Tasks that contain that text need to be checked
+	var candidates map[*Task]bool
+
+	if required, ok := query.RequiredNeedles(); ok {
+
+		candidates = map[*Task]bool{}
+
+		for _, group := range required {
+
+			smallest := scores[group[0]]
+			for _, needle := range group[1:] {
+				if len(scores[needle]) < len(smallest) {
+					smallest = scores[needle]
+				}
+			}
+
+			for task := range smallest {
+				candidates[task] = true
+			}
+
+		}
+
+	}
+
+	results := []*Task{}
+	ranks := map[*Task]float32{}
+
+	for _, task := range tasks {
+
+		if candidates != nil && !candidates[task] {
+			continue
+		}
+
+		fields := task.QueryFields()
+		fields.MatchText = func(needle string) bool {
+			_, matched := scores[needle][task]
+			return matched
+		}
+
+		if query.Match(fields) {
+
+			results = append(results, task)
+
+			for _, matches := range scores {
+				ranks[task] += matches[task]
+			}
+
+		}
+
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return ranks[results[i]] > ranks[results[j]] })
+
+	return results
+
+}
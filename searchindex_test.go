@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestWithinEditDistance(t *testing.T) {
+
+	pairs := []struct {
+		a, b   string
+		max    int
+		within bool
+	}{
+		{"boss", "boss", 0, true},
+		{"boss", "bass", 1, true},
+		{"boss", "bass", 0, false},
+		{"boss", "bosses", 1, false},
+		{"boss", "bosses", 2, true},
+		{"boss", "bos", 1, true},
+		{"sprite", "spirte", 1, false},
+		{"sprite", "spirte", 2, true},
+		{"", "ab", 2, true},
+		{"", "abc", 2, false},
+		{"café", "cafe", 1, true},
+		{"background", "backrgound", 2, true},
+	}
+
+	for _, pair := range pairs {
+		if within := withinEditDistance(pair.a, pair.b, pair.max); within != pair.within {
+			t.Errorf("expected %s to be within %d edits of %s: %t", pair.a, pair.max, pair.b, pair.within)
+		}
+		if within := withinEditDistance(pair.b, pair.a, pair.max); within != pair.within {
+			t.Errorf("expected %s to be within %d edits of %s: %t", pair.b, pair.max, pair.a, pair.within)
+		}
+	}
+
+}
+
+func TestSearchIndex(t *testing.T) {
+
+	project := newTestProject()
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	boss := newTestTask(board, TASK_TYPE_BOOLEAN, "Boss fight", 0, 0, 4, 1)
+	bass := newTestTask(board, TASK_TYPE_BOOLEAN, "Bass line\nFor the bossanova level", 0, 2, 4, 1)
+	sprites := newTestTask(board, TASK_TYPE_NOTE, "Sprites\nhttps://example.com/sprites", 0, 4, 4, 1)
+	background := newTestTask(board, TASK_TYPE_NOTE, "Draw the background", 0, 6, 4, 1)
+
+	searches := []struct {
+		query   string
+		results []*Task
+	}{
+		// Exact matches rank above prefixes, which rank above substrings, which rank above typos.
+		{`boss`, []*Task{boss, bass}},
+		{`bass`, []*Task{bass, boss}},
+		{`ground`, []*Task{background}},
+		{`backrgound`, []*Task{background}},
+		{`spr`, []*Task{sprites}},
+		// Short terms have to be spelled right.
+		{`bos`, []*Task{boss, bass}},
+		{`bas`, []*Task{bass}},
+		{`"boss fight"`, []*Task{boss}},
+		{`example.com/sprites`, []*Task{sprites}},
+		{`draw background`, []*Task{background}},
+		// Excluding a term only excludes Tasks that contain it, not ones that are close to it.
+		{`-boss`, []*Task{sprites, background}},
+		{`-bass`, []*Task{boss, sprites, background}},
+		{`boss -bossanova`, []*Task{boss}},
+		{`zebra`, []*Task{}},
+	}
+
+	for _, search := range searches {
+
+		query, err := ParseQuery(search.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results := project.SearchIndex.Search(query, board.Tasks)
+
+		if len(results) != len(search.results) {
+			t.Errorf("expected %s to find %d Tasks, got %d", search.query, len(search.results), len(results))
+			continue
+		}
+
+		for i, task := range results {
+			if task != search.results[i] {
+				t.Errorf("expected %s to find %s at %d, got %s", search.query, search.results[i].Description.Text(), i, task.Description.Text())
+			}
+		}
+
+	}
+
+	// Changed Tasks are only indexed again once they're invalidated.
+	query, _ := ParseQuery(`castle`)
+
+	background.Description.SetText("Draw the castle")
+
+	if results := project.SearchIndex.Search(query, board.Tasks); len(results) != 0 {
+		t.Fatal("expected the Task not to be indexed again until it was invalidated")
+	}
+
+	project.SearchIndex.Invalidate(background)
+
+	if results := project.SearchIndex.Search(query, board.Tasks); len(results) != 1 || results[0] != background {
+		t.Fatal("expected the Task to be found once it was invalidated")
+	}
+
+	// Deleted Tasks are dropped from the index.
+	if results := project.SearchIndex.Search(query, []*Task{boss, bass, sprites}); len(results) != 0 {
+		t.Fatal("expected the deleted Task not to be found")
+	}
+
+	if _, indexed := project.SearchIndex.documents[background]; indexed {
+		t.Fatal("expected the deleted Task to be dropped from the index")
+	}
+
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Selected Tasks can be sent to another Board, keeping their layout. Unlike cutting and pasting them, the Tasks themselves
+// move (rather than copies of them), whole Lines go along with their endings, and the move is a single step that can be
+// undone from the Board the Tasks were sent from.
+
+// transferToBoard moves the Task itself from its Board to the target Board, leaving its position alone. Line bases take
+// their endings along with them.
+func (task *Task) transferToBoard(target *Board) {
+
+	source := task.Board
+
+	if source == target {
+		return
+	}
+
+	for i, t := range source.Tasks {
+		if t == task {
+			source.Tasks = append(source.Tasks[:i], source.Tasks[i+1:]...)
+			break
+		}
+	}
+
+	source.RemoveTaskFromGrid(task)
+	source.TaskChanged = true
+
+	task.Board = target
+	task.invalidateBoardLink()
+
+	if task.Valid {
+		target.InsertExistingTask(task)
+	}
+
+	if task.Is(TASK_TYPE_LINE) && task.LineStart == nil {
+		for _, ending := range task.LineEndings {
+			ending.transferToBoard(target)
+		}
+	}
+
+}
+
+// SendSelectedTasks moves the selected Tasks to the target Board.
+func (board *Board) SendSelectedTasks(target *Board) {
+
+	if len(board.SelectedTasks(false)) == 0 {
+		board.Project.Log("Select Tasks to send them to another Board.")
+		return
+	}
+
+	board.SendTasks(board.SelectedTasks(false), target)
+
+}
+
+// SendTasks moves the given Tasks on the Board to the target Board as a single undo step. They keep their positions if
+// they're free on the target Board; otherwise, they're moved down together until they are.
+func (board *Board) SendTasks(tasks []*Task, target *Board) {
+
+	if target == board {
+		return
+	}
+
+	moving := []*Task{}
+	added := map[*Task]bool{}
+
+	for _, task := range tasks {
+
+		// Lines can't span Boards, so sending any part of one sends all of it
+		if task.Is(TASK_TYPE_LINE) && task.LineStart != nil {
+			task = task.LineStart
+		}
+
+		if !added[task] {
+			added[task] = true
+			moving = append(moving, task)
+		}
+
+	}
+
+	if len(moving) == 0 {
+		return
+	}
+
+	rects := []rl.Rectangle{}
+
+	for _, task := range moving {
+		rects = append(rects, task.Rect)
+		if task.Is(TASK_TYPE_LINE) {
+			for _, ending := range task.LineEndings {
+				if ending.Valid {
+					rects = append(rects, ending.Rect)
+				}
+			}
+		}
+	}
+
+	gs := float32(board.Project.GridSize)
+
+	overlapping := func(offset float32) bool {
+		for _, rect := range rects {
+			for _, t := range target.TasksInRect(rect.X, rect.Y+offset, rect.Width, rect.Height) {
+				if t.Valid {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	offset := float32(0)
+	for overlapping(offset) {
+		offset += gs
+	}
+
+	// The Tasks' states from before they were sent go in the previous undo frame, and their states after go in the current
+	// one, so undoing on this Board sends them back (UndoState.Apply() moves Tasks to the Board their state was on). A
+	// Line's state holds the positions of its endings, so only the base needs one; applying it puts the endings back, too.
+	for _, task := range moving {
+		board.UndoHistory.Capture(NewUndoState(task), true)
+	}
+
+	for _, task := range moving {
+
+		pieces := []*Task{task}
+		if task.Is(TASK_TYPE_LINE) {
+			pieces = append(pieces, task.LineEndings...)
+		}
+
+		for _, piece := range pieces {
+			piece.Selected = false
+			piece.Position.Y += offset
+			piece.Rect.Y = piece.Position.Y
+		}
+
+		task.transferToBoard(target)
+
+	}
+
+	for _, task := range moving {
+
+		board.UndoHistory.Capture(NewUndoState(task), false)
+
+		if sync := board.Project.Sync; sync != nil {
+			sync.TaskChanged(task)
+			for _, ending := range task.LineEndings {
+				sync.TaskChanged(ending)
+			}
+		}
+
+	}
+
+	board.Project.Modified = true
+	board.Project.Log("Sent %d Task(s) to Board [%s].", len(moving), target.Name)
+
+}
+
+// DrawSendToBoardMenu draws the menu to pick the Board to send the selected Tasks to, if it's open.
+func (project *Project) DrawSendToBoardMenu() {
+
+	project.SendToBoardPanel = rl.Rectangle{}
+
+	if !project.SendToBoardOpen {
+		return
+	}
+
+	selectedCount := len(project.CurrentBoard().SelectedTasks(false))
+
+	if selectedCount == 0 {
+		project.SendToBoardOpen = false
+		return
+	}
+
+	w := float32(320)
+	h := float32(32)
+
+	// Archived Boards go at the end, as they do in the Boards panel
+	boards := append(project.ListedBoards(), project.NestedBoards()...)
+	boards = append(boards, project.ArchivedBoards()...)
+
+	rect := rl.Rectangle{0, 0, w, h * float32(len(boards)+2)}
+	rect.X = (float32(rl.GetScreenWidth()) - rect.Width) / 2
+	rect.Y = (float32(rl.GetScreenHeight()) - rect.Height) / 2
+
+	project.SendToBoardPanel = rect
+
+	rl.DrawRectangleRec(rect, getThemeColor(GUI_INSIDE))
+	rl.DrawRectangleLinesEx(rect, 1, getThemeColor(GUI_OUTLINE))
+
+	DrawGUIText(rl.Vector2{rect.X + 8, rect.Y + 4}, "Send %d Task(s) to Board:", selectedCount)
+
+	y := rect.Y + h
+
+	for _, board := range boards {
+
+		name := project.BoardPath(board)
+		if board.Archived {
+			name += " (Archived)"
+		}
+
+		if ImmediateButton(rl.Rectangle{rect.X, y, w, h}, name, board == project.CurrentBoard()) {
+			project.CurrentBoard().SendSelectedTasks(board)
+			project.SendToBoardOpen = false
+		}
+
+		y += h
+
+	}
+
+	if ImmediateButton(rl.Rectangle{rect.X, y, w, h}, "Cancel", false) || rl.IsKeyPressed(rl.KeyEscape) ||
+		(MousePressed(rl.MouseLeftButton) && !rl.CheckCollisionPointRec(GetMousePosition(), rect)) {
+		project.SendToBoardOpen = false
+	}
+
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestSendLineToBoard(t *testing.T) {
+
+	project := newTestProject()
+
+	prevProject := currentProject
+	currentProject = project
+	defer func() { currentProject = prevProject }()
+
+	from := NewBoard(project)
+	from.Name = "From"
+	to := NewBoard(project)
+	to.Name = "To"
+	project.Boards = []*Board{from, to}
+
+	gs := float32(project.GridSize)
+
+	line := newTestTask(from, TASK_TYPE_LINE, "", 0, 0, 1, 1)
+	ending := line.CreateLineEnding()
+	ending.Position = rl.Vector2{4 * gs, 2 * gs}
+	ending.Rect.X, ending.Rect.Y = ending.Position.X, ending.Position.Y
+
+	// The spot the Line's base would land in on the other Board is taken, so the Line has to move down to fit.
+	newTestTask(to, TASK_TYPE_NOTE, "In the way", 0, 0, 1, 1)
+
+	from.UndoHistory.Capture(NewUndoState(line), false)
+	from.UndoHistory.Update()
+
+	// Sending the ending sends the whole Line.
+	from.SendTasks([]*Task{ending}, to)
+	from.UndoHistory.Update()
+
+	check := func(step string, board *Board, offset float32) {
+
+		t.Helper()
+
+		if line.Board != board || len(line.LineEndings) != 1 {
+			t.Fatalf("expected the Line to be on %s with one ending after %s", board.Name, step)
+		}
+
+		pieces := map[*Task]rl.Vector2{
+			line:                {0, offset},
+			line.LineEndings[0]: {4 * gs, 2*gs + offset},
+		}
+
+		for piece, position := range pieces {
+
+			if piece.Board != board || piece.Position != position {
+				t.Errorf("expected a piece of the Line to be on %s at %v after %s, but it was on %s at %v", board.Name, position, step, piece.Board.Name, piece.Position)
+			}
+
+			found := false
+			for _, task := range board.Tasks {
+				found = found || task == piece
+			}
+
+			if !found {
+				t.Errorf("expected a piece of the Line to be in %s's Tasks after %s", board.Name, step)
+			}
+
+		}
+
+		// Endings that were replaced when the Line's state was applied shouldn't be left behind, either.
+		for _, other := range project.Boards {
+
+			pieceCount := 0
+			for _, task := range other.Tasks {
+				if task.Valid && task.Is(TASK_TYPE_LINE) {
+					pieceCount++
+				}
+			}
+
+			if other == board && pieceCount != 2 {
+				t.Errorf("expected %s to have the Line's 2 pieces after %s, got %d", other.Name, step, pieceCount)
+			} else if other != board && pieceCount != 0 {
+				t.Errorf("expected no pieces of the Line to be left on %s after %s, got %d", other.Name, step, pieceCount)
+			}
+
+		}
+
+	}
+
+	check("sending it", to, gs)
+
+	if !from.UndoHistory.Undo() {
+		t.Fatal("expected sending the Line to be undoable")
+	}
+
+	check("undoing", from, 0)
+
+	if !from.UndoHistory.Redo() {
+		t.Fatal("expected sending the Line to be redoable")
+	}
+
+	check("redoing", to, gs)
+
+}
@@ -0,0 +1,1010 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// The LAN sync system works by having one MasterPlan instance (or a headless "masterplan host" process) run a SyncServer,
+// and having every participant (including the host itself) connect to it with a SyncClient. Clients exchange whole-Task
+// states as produced by Task.Serialize(), one JSON message per line over plain TCP. The server is authoritative: each Task
+// has a version number, and a change is only accepted if it was made against the latest version the server knows of.
+// Otherwise, the change is rejected and the sender is sent the current state of the Task instead (first writer wins).
+//
+// As the server listens on every network interface, each session has a join code that clients have to say hello with; a
+// client with the wrong code is denied, and nothing else it sends is listened to.
+
+const (
+	SyncMessageHello        = "hello"
+	SyncMessageWelcome      = "welcome"
+	SyncMessageDenied       = "denied"
+	SyncMessageReady        = "ready"
+	SyncMessageTask         = "task"
+	SyncMessageBoards       = "boards"
+	SyncMessageCursor       = "cursor"
+	SyncMessageLeave        = "leave"
+	SyncMessageDisconnected = "disconnected"
+
+	SyncDeletedState = "deleted"
+)
+
+type SyncMessage struct {
+	Type     string
+	Client   string          `json:",omitempty"`
+	Name     string          `json:",omitempty"`
+	Code     string          `json:",omitempty"`
+	SyncID   string          `json:",omitempty"`
+	Base     int             `json:",omitempty"`
+	Version  int             `json:",omitempty"`
+	Deleted  bool            `json:",omitempty"`
+	Rejected bool            `json:",omitempty"`
+	Data     json.RawMessage `json:",omitempty"`
+	Boards   []string        `json:",omitempty"`
+	BoardIDs []string        `json:",omitempty"`
+	Board    int             `json:",omitempty"`
+	X        float32         `json:",omitempty"`
+	Y        float32         `json:",omitempty"`
+}
+
+func newSyncID() string {
+	id := make([]byte, 6)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// writeSyncMessages writes the messages sent through the channel to the connection until the channel is closed or writing fails.
+func writeSyncMessages(conn net.Conn, outgoing chan SyncMessage) {
+
+	writer := bufio.NewWriter(conn)
+
+	for msg := range outgoing {
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+
+		writer.Write(data)
+		writer.WriteByte('\n')
+
+		// Only flush when there's nothing else waiting to be sent, so bursts of Task changes get written out together.
+		if len(outgoing) == 0 {
+			if err := writer.Flush(); err != nil {
+				conn.Close()
+				return
+			}
+		}
+
+	}
+
+}
+
+// readSyncMessages reads messages from the connection, calling handle for each one until the connection is closed.
+func readSyncMessages(conn net.Conn, handle func(SyncMessage)) {
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // Maps and whiteboards can make for very long lines
+
+	for scanner.Scan() {
+		msg := SyncMessage{}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err == nil {
+			handle(msg)
+		}
+	}
+
+}
+
+// Server
+
+type syncEntry struct {
+	Order   int
+	Version int
+	Deleted bool
+	Data    json.RawMessage
+	Client  string
+}
+
+type syncConnection struct {
+	Conn     net.Conn
+	ID       string
+	Name     string
+	Outgoing chan SyncMessage
+}
+
+func (conn *syncConnection) Send(msg SyncMessage) {
+	select {
+	case conn.Outgoing <- msg:
+	default:
+		// If a client can't keep up, it's better to drop it (it can rejoin and get a fresh snapshot) than to stall everyone else.
+		conn.Conn.Close()
+	}
+}
+
+type SyncServer struct {
+	Listener net.Listener
+	Code     string
+	Tasks    map[string]*syncEntry
+	Boards   []string
+	BoardIDs []string
+	Clients  map[*syncConnection]bool
+	Changed  bool
+	Log      func(string, ...interface{})
+
+	nextOrder int
+	mutex     sync.Mutex
+}
+
+func NewSyncServer(port int) (*SyncServer, error) {
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+
+	server := &SyncServer{
+		Listener: listener,
+		Code:     newSyncID(),
+		Tasks:    map[string]*syncEntry{},
+		Boards:   []string{},
+		BoardIDs: []string{},
+		Clients:  map[*syncConnection]bool{},
+		Log:      func(string, ...interface{}) {},
+	}
+
+	return server, nil
+
+}
+
+// Seed fills the server's state from the contents of a .plan file, so that a headless server can host an existing plan.
+func (server *SyncServer) Seed(planData gjson.Result) {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for _, name := range planData.Get(`BoardNames`).Array() {
+		server.Boards = append(server.Boards, name.String())
+	}
+
+	for _, id := range planData.Get(`BoardIDs`).Array() {
+		server.BoardIDs = append(server.BoardIDs, id.String())
+	}
+
+	for i, taskData := range planData.Get(`Tasks`).Array() {
+		server.Tasks[fmt.Sprintf("plan-%d", i)] = &syncEntry{
+			Order:   server.nextOrder,
+			Version: 1,
+			Data:    json.RawMessage(taskData.Raw),
+		}
+		server.nextOrder++
+	}
+
+}
+
+// Serve accepts connections until the server is closed; it blocks, so it should generally be run in a goroutine.
+func (server *SyncServer) Serve() {
+
+	for {
+
+		conn, err := server.Listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go server.handleConnection(conn)
+
+	}
+
+}
+
+func (server *SyncServer) handleConnection(netConn net.Conn) {
+
+	conn := &syncConnection{Conn: netConn, Outgoing: make(chan SyncMessage, 4096)}
+
+	go writeSyncMessages(netConn, conn.Outgoing)
+
+	readSyncMessages(netConn, func(msg SyncMessage) {
+		server.handleMessage(conn, msg)
+	})
+
+	netConn.Close()
+
+	server.mutex.Lock()
+
+	if _, exists := server.Clients[conn]; exists {
+		delete(server.Clients, conn)
+		server.Log("%s left the session.", conn.Name)
+		for other := range server.Clients {
+			other.Send(SyncMessage{Type: SyncMessageLeave, Client: conn.ID})
+		}
+	}
+
+	close(conn.Outgoing)
+
+	server.mutex.Unlock()
+
+}
+
+func (server *SyncServer) handleMessage(conn *syncConnection, msg SyncMessage) {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	// Connections that haven't joined with the session's code can't do anything but try to
+	if msg.Type != SyncMessageHello && !server.Clients[conn] {
+		return
+	}
+
+	switch msg.Type {
+
+	case SyncMessageHello:
+
+		if subtle.ConstantTimeCompare([]byte(msg.Code), []byte(server.Code)) != 1 {
+			server.Log("Someone tried to join the session with the wrong join code.")
+			conn.Send(SyncMessage{Type: SyncMessageDenied})
+			return
+		}
+
+		conn.ID = msg.Client
+		conn.Name = msg.Name
+		server.Clients[conn] = true
+
+		server.Log("%s joined the session.", conn.Name)
+
+		conn.Send(SyncMessage{Type: SyncMessageWelcome, Client: conn.ID})
+
+		if len(server.Boards) > 0 {
+			conn.Send(SyncMessage{Type: SyncMessageBoards, Boards: server.Boards, BoardIDs: server.BoardIDs})
+		}
+
+		ids := []string{}
+		for id := range server.Tasks {
+			ids = append(ids, id)
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return server.Tasks[ids[i]].Order < server.Tasks[ids[j]].Order })
+
+		for _, id := range ids {
+			entry := server.Tasks[id]
+			if !entry.Deleted {
+				conn.Send(SyncMessage{Type: SyncMessageTask, SyncID: id, Version: entry.Version, Data: entry.Data, Client: entry.Client})
+			}
+		}
+
+		conn.Send(SyncMessage{Type: SyncMessageReady})
+
+	case SyncMessageTask:
+
+		entry, exists := server.Tasks[msg.SyncID]
+
+		if exists && entry.Version != msg.Base {
+
+			// The client made this change without having seen the latest version of the Task, so we reject it and send
+			// the current state back to them.
+			conn.Send(SyncMessage{
+				Type:     SyncMessageTask,
+				SyncID:   msg.SyncID,
+				Version:  entry.Version,
+				Deleted:  entry.Deleted,
+				Data:     entry.Data,
+				Client:   entry.Client,
+				Rejected: true,
+			})
+
+			return
+
+		}
+
+		if !exists {
+			entry = &syncEntry{Order: server.nextOrder}
+			server.nextOrder++
+			server.Tasks[msg.SyncID] = entry
+		}
+
+		entry.Version++
+		entry.Deleted = msg.Deleted
+		entry.Client = conn.ID
+
+		if !msg.Deleted {
+			entry.Data = msg.Data
+		}
+
+		server.Changed = true
+
+		for client := range server.Clients {
+			client.Send(SyncMessage{Type: SyncMessageTask, SyncID: msg.SyncID, Version: entry.Version, Deleted: entry.Deleted, Data: entry.Data, Client: conn.ID})
+		}
+
+	case SyncMessageBoards:
+
+		server.Boards = msg.Boards
+		server.BoardIDs = msg.BoardIDs
+		server.Changed = true
+
+		for client := range server.Clients {
+			if client != conn {
+				client.Send(msg)
+			}
+		}
+
+	case SyncMessageCursor:
+
+		msg.Client = conn.ID
+		msg.Name = conn.Name
+
+		for client := range server.Clients {
+			if client != conn {
+				client.Send(msg)
+			}
+		}
+
+	}
+
+}
+
+// PlanData returns the server's current state applied on top of the given .plan file contents, ready to be written to disk.
+func (server *SyncServer) PlanData(original string) string {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	ids := []string{}
+	for id, entry := range server.Tasks {
+		if !entry.Deleted {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return server.Tasks[ids[i]].Order < server.Tasks[ids[j]].Order })
+
+	taskData := []string{}
+	for _, id := range ids {
+		taskData = append(taskData, string(server.Tasks[id].Data))
+	}
+
+	data := original
+	if data == "" {
+		data = "{}"
+		data, _ = sjson.Set(data, `Version`, softwareVersion.String())
+	}
+
+	data, _ = sjson.Set(data, `BoardNames`, server.Boards)
+	data, _ = sjson.Set(data, `BoardIDs`, server.BoardIDs)
+	data, _ = sjson.Set(data, `BoardCount`, len(server.Boards))
+	data, _ = sjson.SetRaw(data, `Tasks`, "["+strings.Join(taskData, ",")+"]")
+
+	server.Changed = false
+
+	return gjson.Parse(data).Get("@pretty").String()
+
+}
+
+func (server *SyncServer) Close() {
+
+	server.Listener.Close()
+
+	server.mutex.Lock()
+	for client := range server.Clients {
+		client.Conn.Close()
+	}
+	server.mutex.Unlock()
+
+}
+
+// Client
+
+type syncCursor struct {
+	Name     string
+	Board    int
+	Position rl.Vector2
+}
+
+type SyncClient struct {
+	Project  *Project
+	ID       string
+	Name     string
+	Address  string
+	Conn     net.Conn
+	Incoming chan SyncMessage
+	Outgoing chan SyncMessage
+	Ready    bool
+	Hosting  bool
+
+	Cursors map[string]*syncCursor
+
+	taskIDs        map[*Task]string
+	tasks          map[string]*Task
+	versions       map[string]int
+	synced         map[*Task]string
+	receivedBoards bool
+	sentBoards     string
+	cursorTimer    time.Time
+	lastCursor     rl.Vector2
+	closed         bool
+}
+
+func NewSyncClient(project *Project, address, code string) (*SyncClient, error) {
+
+	if !strings.Contains(address, ":") {
+		address = fmt.Sprintf("%s:%d", address, programSettings.SyncPort)
+	}
+
+	conn, err := net.DialTimeout("tcp", address, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &SyncClient{
+		Project:  project,
+		ID:       newSyncID(),
+		Name:     programSettings.SyncName,
+		Address:  address,
+		Conn:     conn,
+		Incoming: make(chan SyncMessage, 4096),
+		Outgoing: make(chan SyncMessage, 4096),
+		Cursors:  map[string]*syncCursor{},
+		taskIDs:  map[*Task]string{},
+		tasks:    map[string]*Task{},
+		versions: map[string]int{},
+		synced:   map[*Task]string{},
+	}
+
+	if client.Name == "" {
+		client.Name = "Anonymous"
+	}
+
+	go writeSyncMessages(conn, client.Outgoing)
+
+	go func() {
+		readSyncMessages(conn, func(msg SyncMessage) { client.Incoming <- msg })
+		client.Incoming <- SyncMessage{Type: SyncMessageDisconnected}
+	}()
+
+	client.Send(SyncMessage{Type: SyncMessageHello, Client: client.ID, Name: client.Name, Code: code})
+
+	return client, nil
+
+}
+
+func (client *SyncClient) Send(msg SyncMessage) {
+
+	if client.closed {
+		return
+	}
+
+	select {
+	case client.Outgoing <- msg:
+	default:
+		client.Project.Log("WARNING: Falling behind on sending changes to the session.")
+	}
+
+}
+
+// Update handles any messages received from the server; it should be called from the main thread, as applying changes to Tasks
+// can load textures and the like.
+func (client *SyncClient) Update() {
+
+	project := client.Project
+
+	for len(client.Incoming) > 0 {
+
+		msg := <-client.Incoming
+
+		switch msg.Type {
+
+		case SyncMessageWelcome:
+			project.Log("Connected to session at %s.", client.Address)
+
+		case SyncMessageDenied:
+			project.Log("ERROR: Could not join session at %s: the join code is wrong.", client.Address)
+			project.LeaveSession()
+			return
+
+		case SyncMessageReady:
+
+			client.Ready = true
+
+			if !client.receivedBoards {
+				client.sendBoards()
+			}
+
+			// Anything we have that the session doesn't gets shared now (this is how the host's plan gets into a fresh session).
+			for _, task := range project.GetAllTasks() {
+				if task.Valid && task.Serializable() {
+					client.TaskChanged(task)
+				}
+			}
+
+		case SyncMessageTask:
+			client.applyTask(msg)
+
+		case SyncMessageBoards:
+
+			client.receivedBoards = true
+			client.applyBoards(msg.Boards, msg.BoardIDs)
+			client.sentBoards = strings.Join(append(msg.Boards, msg.BoardIDs...), "\n")
+
+		case SyncMessageCursor:
+
+			cursor, exists := client.Cursors[msg.Client]
+			if !exists {
+				cursor = &syncCursor{}
+				client.Cursors[msg.Client] = cursor
+			}
+
+			cursor.Name = msg.Name
+			cursor.Board = msg.Board
+			cursor.Position = rl.Vector2{msg.X, msg.Y}
+
+		case SyncMessageLeave:
+			delete(client.Cursors, msg.Client)
+
+		case SyncMessageDisconnected:
+			project.Log("WARNING: Disconnected from session at %s.", client.Address)
+			project.LeaveSession()
+			return
+
+		}
+
+	}
+
+	if client.Ready {
+
+		client.sendBoards()
+
+		if time.Since(client.cursorTimer) > time.Millisecond*100 {
+
+			mousePos := GetWorldMousePosition()
+
+			if mousePos != client.lastCursor {
+				client.Send(SyncMessage{Type: SyncMessageCursor, Board: project.BoardIndex, X: mousePos.X, Y: mousePos.Y})
+				client.lastCursor = mousePos
+			}
+
+			client.cursorTimer = time.Now()
+
+		}
+
+	}
+
+}
+
+func (client *SyncClient) sendBoards() {
+
+	names := []string{}
+	ids := []string{}
+	for _, board := range client.Project.Boards {
+		names = append(names, board.Name)
+		ids = append(ids, board.ID)
+	}
+
+	if joined := strings.Join(append(names, ids...), "\n"); joined != client.sentBoards {
+		client.Send(SyncMessage{Type: SyncMessageBoards, Boards: names, BoardIDs: ids})
+		client.sentBoards = joined
+	}
+
+}
+
+// applyBoards brings the Project's Boards in line with the session's, matching them up by ID so that Boards someone else
+// reordered keep their Tasks. Boards the session doesn't have an ID for (like those of a plan that was just opened to join
+// the session with, or any from sessions hosted by older versions) are matched up by position instead.
+func (client *SyncClient) applyBoards(names, ids []string) {
+
+	project := client.Project
+	current := project.CurrentBoard()
+
+	sessionIDs := map[string]bool{}
+	for _, id := range ids {
+		sessionIDs[id] = true
+	}
+
+	boards := []*Board{}
+	used := map[*Board]bool{}
+
+	for i, name := range names {
+
+		var board *Board
+
+		if i < len(ids) {
+			board = project.BoardByID(ids[i])
+		}
+
+		if board == nil && i < len(project.Boards) && !used[project.Boards[i]] && !sessionIDs[project.Boards[i].ID] {
+			board = project.Boards[i]
+		}
+
+		if board == nil {
+			board = NewBoard(project)
+		}
+
+		if i < len(ids) && ids[i] != "" {
+			board.ID = ids[i]
+		}
+
+		board.Name = name
+		boards = append(boards, board)
+		used[board] = true
+
+	}
+
+	// Boards we have that the session doesn't go at the end; they'll be shared with the session from there.
+	for _, board := range project.Boards {
+		if !used[board] {
+			boards = append(boards, board)
+		}
+	}
+
+	project.Boards = boards
+	project.InvalidateBoardParents()
+
+	if project.BoardIndex = current.Index(); project.BoardIndex < 0 {
+		project.BoardIndex = 0
+	}
+
+}
+
+// state returns the part of the Task's serialized state that's shared with the session; selection is local to each user.
+func (client *SyncClient) state(task *Task) string {
+
+	if !task.Valid {
+		return SyncDeletedState
+	}
+
+	state := task.Serialize()
+	state, _ = sjson.Delete(state, "Selected")
+	return state
+
+}
+
+// TaskChanged sends the Task's current state to the session if it differs from the last state that was sent or received.
+func (client *SyncClient) TaskChanged(task *Task) {
+
+	if !client.Ready {
+		return
+	}
+
+	// Line endings are shared as part of their Line's state.
+	if task.Is(TASK_TYPE_LINE) && task.LineStart != nil {
+		task = task.LineStart
+	}
+
+	state := client.state(task)
+
+	if client.synced[task] == state {
+		return
+	}
+
+	id, exists := client.taskIDs[task]
+
+	if !exists {
+
+		if !task.Valid {
+			return // No need to tell anyone about a Task that was deleted before it was ever shared
+		}
+
+		id = client.ID + "-" + newSyncID()
+		client.taskIDs[task] = id
+		client.tasks[id] = task
+
+	}
+
+	msg := SyncMessage{Type: SyncMessageTask, SyncID: id, Base: client.versions[id]}
+
+	if state == SyncDeletedState {
+		msg.Deleted = true
+	} else {
+		msg.Data = json.RawMessage(state)
+	}
+
+	client.Send(msg)
+
+	// We optimistically assume the change will be accepted so that several changes in a row don't reject each other.
+	client.versions[id]++
+	client.synced[task] = state
+
+}
+
+func (client *SyncClient) applyTask(msg SyncMessage) {
+
+	project := client.Project
+
+	if msg.Client == client.ID && !msg.Rejected {
+		// This is just the server confirming a change we made ourselves.
+		if msg.Version > client.versions[msg.SyncID] {
+			client.versions[msg.SyncID] = msg.Version
+		}
+		return
+	}
+
+	if msg.Rejected {
+		project.Log("WARNING: A Task was changed by someone else first; your change to it was discarded.")
+	}
+
+	client.versions[msg.SyncID] = msg.Version
+
+	task := client.tasks[msg.SyncID]
+
+	if msg.Deleted {
+		if task != nil {
+			if task.Valid {
+				task.Board.UndoHistory.On = false
+				task.Board.DeleteTask(task)
+				client.settle(task)
+				task.Board.UndoHistory.On = true
+			}
+			client.synced[task] = SyncDeletedState
+		}
+		return
+	}
+
+	data := gjson.ParseBytes(msg.Data)
+
+	taskType, ok := ParseTaskType(data)
+	if !ok {
+		return
+	}
+
+	board := project.BoardByID(data.Get(`BoardID`).String())
+
+	if board == nil {
+
+		boardIndex := int(data.Get(`BoardIndex`).Int())
+
+		for len(project.Boards) <= boardIndex {
+			project.AddBoard()
+		}
+
+		board = project.Boards[boardIndex]
+
+	}
+
+	// Someone else's changes aren't steps in our own undo history.
+	board.UndoHistory.On = false
+
+	if task != nil && task.Board != board {
+		task.transferToBoard(board)
+	}
+
+	prevLogOn := project.LogOn
+	project.LogOn = false
+
+	if task == nil {
+
+		// We pretend to be loading so that the new Task isn't placed at the mouse cursor or selected.
+		prevLoading := project.Loading
+		project.Loading = true
+		task = board.CreateNewTask()
+		task.UndoCreation = true
+		project.Loading = prevLoading
+
+		client.tasks[msg.SyncID] = task
+		client.taskIDs[task] = msg.SyncID
+
+	} else if !task.Valid {
+		board.RestoreTask(task)
+	}
+
+	selected := task.Selected
+
+	task.Deserialize(data, taskType)
+
+	task.Selected = selected
+	task.Rect.Width = task.DisplaySize.X
+	task.Rect.Height = task.DisplaySize.Y
+
+	client.settle(task)
+
+	project.LogOn = prevLogOn
+
+	board.UndoHistory.On = true
+	board.TaskChanged = true
+
+	client.synced[task] = client.state(task)
+
+}
+
+// settle clears the changes a Task applied from the session has marked itself as having, so that they don't get captured
+// as undo steps (or sent back to the session) later on in the frame, and does what capturing them would have otherwise.
+// That includes telling the script hooks about the change, so the host runs scripts for changes made by anyone in the
+// session (and everyone knows whether the Task was complete before the next change to it).
+func (client *SyncClient) settle(task *Task) {
+
+	client.Project.Hooks.TaskChanged(task, task.UndoCreation, task.UndoDeletion)
+
+	for _, t := range append([]*Task{task}, task.LineEndings...) {
+		t.UndoChange = false
+		t.UndoCreation = false
+		t.UndoDeletion = false
+	}
+
+	client.Project.SearchIndex.Invalidate(task)
+	client.Project.Modified = true
+
+}
+
+// DrawCursors draws the mouse cursors of the other users in the session that are looking at the current Board.
+func (client *SyncClient) DrawCursors() {
+
+	for _, cursor := range client.Cursors {
+
+		if cursor.Board != client.Project.BoardIndex {
+			continue
+		}
+
+		color := getThemeColor(GUI_OUTLINE_HIGHLIGHTED)
+
+		rl.DrawTriangle(
+			cursor.Position,
+			rl.Vector2{cursor.Position.X, cursor.Position.Y + 16},
+			rl.Vector2{cursor.Position.X + 11, cursor.Position.Y + 11},
+			color)
+
+		textSize, _ := TextSize(cursor.Name, false)
+		textPos := rl.Vector2{cursor.Position.X + 12, cursor.Position.Y + 12}
+		rl.DrawRectangleV(textPos, textSize, getThemeColor(GUI_INSIDE))
+		DrawTextColored(textPos, color, cursor.Name, false)
+
+	}
+
+}
+
+func (client *SyncClient) Close() {
+
+	if client.closed {
+		return
+	}
+
+	client.closed = true
+
+	// Closing the outgoing channel stops the goroutine writing to the connection.
+	close(client.Outgoing)
+	client.Conn.Close()
+
+}
+
+// HostSession starts a sync server on the configured port and joins it, sharing the current project with anyone else who joins.
+func (project *Project) HostSession() {
+
+	server, err := NewSyncServer(programSettings.SyncPort)
+	if err != nil {
+		project.Log("ERROR: Could not host session: %s", err.Error())
+		return
+	}
+
+	go server.Serve()
+
+	client, err := NewSyncClient(project, fmt.Sprintf("127.0.0.1:%d", programSettings.SyncPort), server.Code)
+	if err != nil {
+		server.Close()
+		project.Log("ERROR: Could not host session: %s", err.Error())
+		return
+	}
+
+	client.Hosting = true
+
+	project.SyncServer = server
+	project.Sync = client
+
+	addresses := []string{}
+
+	if interfaces, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range interfaces {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+				addresses = append(addresses, fmt.Sprintf("%s:%d", ipNet.IP.String(), programSettings.SyncPort))
+			}
+		}
+	}
+
+	project.Log("Hosting session; others can join at: %s, with the join code: %s", strings.Join(addresses, ", "), server.Code)
+
+}
+
+// JoinSession replaces the current project with a new, empty one that's filled with the contents of the session at the given
+// address, joining it with the session's join code.
+func (project *Project) JoinSession(address, code string) {
+
+	joined := NewProject()
+
+	client, err := NewSyncClient(joined, address, strings.TrimSpace(code))
+	if err != nil {
+		project.Log("ERROR: Could not join session at %s: %s", address, err.Error())
+		joined.Destroy()
+		return
+	}
+
+	joined.Sync = client
+
+	project.Destroy()
+	currentProject = joined
+
+}
+
+func (project *Project) LeaveSession() {
+
+	if project.Sync != nil {
+		project.Sync.Close()
+		project.Sync = nil
+	}
+
+	if project.SyncServer != nil {
+		project.SyncServer.Close()
+		project.SyncServer = nil
+	}
+
+}
+
+// hostPlan runs a headless sync server for the plan at the given path, writing accepted changes back to the file.
+func hostPlan(args []string) error {
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: masterplan host <plan file> [port]")
+	}
+
+	planPath := args[0]
+
+	port := programSettings.SyncPort
+	if len(args) > 1 {
+		if _, err := fmt.Sscanf(args[1], "%d", &port); err != nil {
+			return fmt.Errorf("invalid port: %s", args[1])
+		}
+	}
+
+	original := ""
+
+	if fileData, err := ioutil.ReadFile(planPath); err == nil {
+		original = string(fileData)
+	} else {
+		fmt.Fprintf(terminalOutput, "Plan %s doesn't exist yet; it will be created.\n", planPath)
+	}
+
+	server, err := NewSyncServer(port)
+	if err != nil {
+		return err
+	}
+
+	server.Log = func(text string, values ...interface{}) {
+		fmt.Fprintf(terminalOutput, time.Now().Format("15:04:05")+" : "+text+"\n", values...)
+	}
+
+	server.Seed(gjson.Parse(original))
+
+	go server.Serve()
+
+	server.Log("Hosting %s on port %d, with the join code: %s", planPath, port, server.Code)
+
+	for range time.Tick(time.Second * 5) {
+
+		server.mutex.Lock()
+		changed := server.Changed
+		server.mutex.Unlock()
+
+		if changed {
+
+			original = server.PlanData(original)
+
+			if err := ioutil.WriteFile(planPath, []byte(original), 0666); err != nil {
+				server.Log("ERROR: Could not save plan: %s", err.Error())
+			} else {
+				server.Log("Saved changes to %s.", planPath)
+			}
+
+		}
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func newTestSyncConnection(id string) *syncConnection {
+	conn, _ := net.Pipe()
+	return &syncConnection{Conn: conn, ID: id, Outgoing: make(chan SyncMessage, 64)}
+}
+
+// received returns the messages sent to the connection so far.
+func received(conn *syncConnection) []SyncMessage {
+	messages := []SyncMessage{}
+	for len(conn.Outgoing) > 0 {
+		messages = append(messages, <-conn.Outgoing)
+	}
+	return messages
+}
+
+// newTestSyncServer returns a SyncServer that isn't listening on the network, with the join code "code".
+func newTestSyncServer() *SyncServer {
+	return &SyncServer{Code: "code", Tasks: map[string]*syncEntry{}, Clients: map[*syncConnection]bool{}, Log: func(string, ...interface{}) {}}
+}
+
+func TestSyncServerVersions(t *testing.T) {
+
+	server := newTestSyncServer()
+
+	a := newTestSyncConnection("a")
+	b := newTestSyncConnection("b")
+
+	server.handleMessage(a, SyncMessage{Type: SyncMessageHello, Client: "a", Name: "A", Code: "code"})
+	server.handleMessage(b, SyncMessage{Type: SyncMessageHello, Client: "b", Name: "B", Code: "code"})
+	received(a)
+	received(b)
+
+	// A new Task is accepted as version 1 and sent to everyone.
+	server.handleMessage(a, SyncMessage{Type: SyncMessageTask, SyncID: "task", Data: json.RawMessage(`{"Description":"first"}`)})
+
+	for _, conn := range []*syncConnection{a, b} {
+		messages := received(conn)
+		if len(messages) != 1 || messages[0].Version != 1 || messages[0].Client != "a" || messages[0].Rejected {
+			t.Fatalf("expected %s to be sent version 1 from a, got %+v", conn.ID, messages)
+		}
+	}
+
+	// B changing the Task without having seen version 1 is rejected, and only B is sent the current state.
+	server.handleMessage(b, SyncMessage{Type: SyncMessageTask, SyncID: "task", Data: json.RawMessage(`{"Description":"second"}`)})
+
+	if messages := received(a); len(messages) != 0 {
+		t.Fatalf("expected a rejected change not to be sent to a, got %+v", messages)
+	}
+
+	messages := received(b)
+	if len(messages) != 1 || !messages[0].Rejected || messages[0].Version != 1 || string(messages[0].Data) != `{"Description":"first"}` {
+		t.Fatalf("expected b's change to be rejected with version 1, got %+v", messages)
+	}
+
+	// Once B has seen version 1, its change is accepted as version 2.
+	server.handleMessage(b, SyncMessage{Type: SyncMessageTask, SyncID: "task", Base: 1, Data: json.RawMessage(`{"Description":"second"}`)})
+
+	for _, conn := range []*syncConnection{a, b} {
+		messages := received(conn)
+		if len(messages) != 1 || messages[0].Version != 2 || messages[0].Client != "b" || string(messages[0].Data) != `{"Description":"second"}` {
+			t.Fatalf("expected %s to be sent version 2 from b, got %+v", conn.ID, messages)
+		}
+	}
+
+	// Deleting the Task keeps its version history, but it isn't sent to anyone who joins afterwards.
+	server.handleMessage(a, SyncMessage{Type: SyncMessageTask, SyncID: "task", Base: 2, Deleted: true})
+
+	if entry := server.Tasks["task"]; entry.Version != 3 || !entry.Deleted {
+		t.Fatalf("expected the Task to be deleted as version 3, got %+v", entry)
+	}
+
+	c := newTestSyncConnection("c")
+	server.handleMessage(c, SyncMessage{Type: SyncMessageHello, Client: "c", Name: "C", Code: "code"})
+
+	for _, msg := range received(c) {
+		if msg.Type == SyncMessageTask {
+			t.Fatalf("expected a deleted Task not to be sent to a new client, got %+v", msg)
+		}
+	}
+
+}
+
+func TestSyncServerPlanData(t *testing.T) {
+
+	server := newTestSyncServer()
+	conn := newTestSyncConnection("a")
+
+	server.handleMessage(conn, SyncMessage{Type: SyncMessageHello, Client: "a", Name: "A", Code: "code"})
+
+	for _, id := range []string{"z", "y", "x"} {
+		server.handleMessage(conn, SyncMessage{Type: SyncMessageTask, SyncID: id, Data: json.RawMessage(`{"Description":"` + id + `"}`)})
+	}
+
+	server.handleMessage(conn, SyncMessage{Type: SyncMessageTask, SyncID: "y", Base: 1, Deleted: true})
+
+	if !server.Changed {
+		t.Fatal("expected accepted changes to mark the server as changed")
+	}
+
+	data := server.PlanData(`{"Version":"0.7.2"}`)
+
+	var plan struct {
+		Tasks []struct{ Description string }
+	}
+
+	if err := json.Unmarshal([]byte(data), &plan); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tasks are written in the order they were first shared, leaving out deleted ones.
+	if len(plan.Tasks) != 2 || plan.Tasks[0].Description != "z" || plan.Tasks[1].Description != "x" {
+		t.Fatalf("expected Tasks z and x, got %+v", plan.Tasks)
+	}
+
+	if server.Changed {
+		t.Fatal("expected writing out the plan to clear the changed flag")
+	}
+
+}
+
+func TestSyncServerJoinCode(t *testing.T) {
+
+	server := newTestSyncServer()
+
+	a := newTestSyncConnection("a")
+	server.handleMessage(a, SyncMessage{Type: SyncMessageHello, Client: "a", Name: "A", Code: "code"})
+	received(a)
+
+	for _, code := range []string{"", "wrong", "cod"} {
+
+		intruder := newTestSyncConnection("intruder")
+
+		server.handleMessage(intruder, SyncMessage{Type: SyncMessageHello, Client: "intruder", Name: "Intruder", Code: code})
+
+		if messages := received(intruder); len(messages) != 1 || messages[0].Type != SyncMessageDenied {
+			t.Fatalf("expected joining with the code %q to be denied, got %+v", code, messages)
+		}
+
+		// Nothing else a client that was denied sends is listened to.
+		server.handleMessage(intruder, SyncMessage{Type: SyncMessageTask, SyncID: "task", Data: json.RawMessage(`{"Description":"intruder"}`)})
+		server.handleMessage(intruder, SyncMessage{Type: SyncMessageBoards, Boards: []string{"Intruder"}})
+
+		if len(server.Tasks) != 0 || len(server.Boards) != 0 || server.Clients[intruder] {
+			t.Fatalf("expected a client that was denied not to be able to change the session")
+		}
+
+		if messages := received(a); len(messages) != 0 {
+			t.Fatalf("expected nothing from a client that was denied to be sent on, got %+v", messages)
+		}
+
+	}
+
+}
+
+func TestSyncHostRunsHooksForRemoteChanges(t *testing.T) {
+
+	project := newTestProject()
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	client := &SyncClient{
+		Project:  project,
+		ID:       "host",
+		Hosting:  true,
+		taskIDs:  map[*Task]string{},
+		tasks:    map[string]*Task{},
+		versions: map[string]int{},
+		synced:   map[*Task]string{},
+	}
+
+	project.Sync = client
+
+	// Events are only queued up if a script is listening for them.
+	for _, event := range []string{HookTaskCreated, HookTaskCompleted} {
+		project.Hooks.handlers[event] = []*lua.LFunction{{}}
+	}
+
+	// The Task is made on another Board, as if it were made by someone else in the session.
+	remote := newTestTask(NewBoard(project), TASK_TYPE_BOOLEAN, "Boss fight", 0, 0, 4, 1)
+	remote.Board.ID = board.ID
+
+	events := func() []string {
+		names := []string{}
+		for _, event := range project.Hooks.events {
+			names = append(names, event.Name)
+		}
+		project.Hooks.events = []scriptEvent{}
+		return names
+	}
+
+	client.applyTask(SyncMessage{Type: SyncMessageTask, SyncID: "remote-1", Version: 1, Client: "remote", Data: json.RawMessage(remote.Serialize())})
+
+	if names := events(); len(names) != 1 || names[0] != HookTaskCreated {
+		t.Fatalf("expected the host to run the created event for a Task someone else made, got %v", names)
+	}
+
+	remote.CompletionCheckbox.Checked = true
+
+	client.applyTask(SyncMessage{Type: SyncMessageTask, SyncID: "remote-1", Version: 2, Client: "remote", Data: json.RawMessage(remote.Serialize())})
+
+	if names := events(); len(names) != 1 || names[0] != HookTaskCompleted {
+		t.Fatalf("expected the host to run the completed event for a Task someone else completed, got %v", names)
+	}
+
+	// The host knows the Task is complete now, so changing something else about it isn't completing it again.
+	task := client.tasks["remote-1"]
+	task.Description.SetText("Boss fight (done)")
+	project.Hooks.TaskChanged(task, false, false)
+
+	if names := events(); len(names) != 0 {
+		t.Fatalf("expected no more events once the Task was already complete, got %v", names)
+	}
+
+}
@@ -540,6 +540,12 @@ func (tb *TableData) SetPanel() {
 
 }
 
+// Elements returns the Table's rows, followed by its columns.
+func (tb *TableData) Elements() []*tableElement {
+	elements := append([]*tableElement{}, tb.Rows...)
+	return append(elements, tb.Columns...)
+}
+
 func (tb *TableData) IsComplete() bool {
 	return tb.CompletionCount() >= tb.CompletionMax()
 }
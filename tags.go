@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Tags categorize Tasks. They can be written into a Task's description as hashtags (like #bug or #art), or set in the
+// Task's Tags field; either way, they're shown as colored chips beside the Task, can be searched for (#art, or tag:art),
+// and are counted in the tag summary.
+
+// tagPattern matches hashtags in text; tags have to start with a letter, so things like issue numbers (#12) and Markdown
+// headings (# Heading) aren't taken as tags.
+var tagPattern = regexp.MustCompile(`(?:^|[\s(\[,])#(\p{L}[\p{L}\p{N}_\-/]*)`)
+
+// parseTags returns the hashtags in the text given.
+func parseTags(text string) []string {
+
+	tags := []string{}
+
+	for _, match := range tagPattern.FindAllStringSubmatch(text, -1) {
+		tags = append(tags, strings.ToLower(match[1]))
+	}
+
+	return tags
+
+}
+
+// splitTags returns the tags in a list separated by spaces or commas, as typed into a Task's Tags field.
+func splitTags(text string) []string {
+
+	tags := []string{}
+
+	for _, tag := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if tag = strings.ToLower(strings.TrimLeft(tag, "#")); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+
+}
+
+// uniqueTags returns the tags given without duplicates, keeping their order.
+func uniqueTags(tags []string) []string {
+
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			unique = append(unique, tag)
+		}
+	}
+
+	return unique
+
+}
+
+// Tags returns the Task's tags, both set in its Tags field and written in its description.
+func (task *Task) Tags() []string {
+
+	// Parsing the description every frame would be wasteful, so the tags are kept until they're invalidated (when the
+	// text is edited, an undo state is captured, or the Task is deserialized)
+	if task.tags == nil {
+		task.tags = uniqueTags(append(splitTags(task.TagsTextbox.Text()), parseTags(task.Description.Text())...))
+	}
+
+	return task.tags
+
+}
+
+// InvalidateTags has the Task's tags worked out again the next time they're needed.
+func (task *Task) InvalidateTags() {
+	task.tags = nil
+}
+
+var tagColors = []rl.Color{
+	{230, 105, 105, 255},
+	{230, 160, 90, 255},
+	{220, 200, 90, 255},
+	{130, 200, 110, 255},
+	{90, 190, 180, 255},
+	{100, 150, 225, 255},
+	{160, 120, 220, 255},
+	{215, 120, 180, 255},
+}
+
+// tagColor returns the color a tag's chip is drawn in; each tag always gets the same color.
+func tagColor(tag string) rl.Color {
+	hash := fnv.New32a()
+	hash.Write([]byte(tag))
+	return tagColors[hash.Sum32()%uint32(len(tagColors))]
+}
+
+// DrawTagChips draws the Task's tags as colored chips to the right of it.
+func (task *Task) DrawTagChips() {
+
+	if !task.Board.Project.ShowTagChips.Checked {
+		return
+	}
+
+	scale := float32(0.75)
+	height := float32(task.Board.Project.GridSize) - 4
+	pos := rl.Vector2{task.Rect.X + task.Rect.Width + 4, task.Rect.Y + 2}
+
+	for _, tag := range task.Tags() {
+
+		text := "#" + tag
+		size, _ := TextSize(text, false)
+
+		chip := rl.Rectangle{pos.X, pos.Y, size.X*scale + 6, height}
+
+		color := tagColor(tag)
+		if task.FilteredOut {
+			color.A = 64
+		}
+
+		rl.DrawRectangleRec(chip, color)
+		DrawTextColoredScale(rl.Vector2{chip.X + 3, chip.Y + (height-size.Y*scale)/2}, rl.Black, text, scale)
+
+		pos.X += chip.Width + 2
+
+	}
+
+}
+
+type tagCount struct {
+	Tag         string
+	Tasks       int
+	Completable int
+	Completed   int
+}
+
+// TagCounts returns how many Tasks in the Project have each tag, and how many of them are complete, most used first.
+func (project *Project) TagCounts() []*tagCount {
+
+	counts := map[string]*tagCount{}
+
+	for _, task := range project.GetAllTasks() {
+
+		for _, tag := range task.Tags() {
+
+			count, exists := counts[tag]
+			if !exists {
+				count = &tagCount{Tag: tag}
+				counts[tag] = count
+			}
+
+			count.Tasks++
+
+			if task.IsCompletable() {
+				count.Completable++
+				if task.IsComplete() {
+					count.Completed++
+				}
+			}
+
+		}
+
+	}
+
+	sorted := []*tagCount{}
+	for _, count := range counts {
+		sorted = append(sorted, count)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Tasks != sorted[j].Tasks {
+			return sorted[i].Tasks > sorted[j].Tasks
+		}
+		return sorted[i].Tag < sorted[j].Tag
+	})
+
+	return sorted
+
+}
+
+// DrawTagSummary draws the tag summary panel above the bottom-left of the status bar, if it's open. Clicking on a tag
+// searches for the Tasks that have it.
+func (project *Project) DrawTagSummary() {
+
+	project.TagSummaryPanel = rl.Rectangle{}
+
+	if !project.TagSummaryOpen {
+		return
+	}
+
+	counts := project.TagCounts()
+
+	w := float32(320)
+	h := float32(24)
+
+	if len(counts) == 0 {
+		rect := rl.Rectangle{8, project.StatusBar.Y - h - 4, w, h}
+		rl.DrawRectangleRec(rect, getThemeColor(GUI_INSIDE))
+		rl.DrawRectangleLinesEx(rect, 1, getThemeColor(GUI_OUTLINE))
+		DrawGUIText(rl.Vector2{rect.X + 8, rect.Y + 2}, "No Tasks are tagged yet.")
+		project.TagSummaryPanel = rect
+		return
+	}
+
+	// Only so many tags fit; the least used are left off
+	if len(counts) > 16 {
+		counts = counts[:16]
+	}
+
+	y := project.StatusBar.Y - float32(len(counts))*h - 4
+
+	project.TagSummaryPanel = rl.Rectangle{8, y, w, float32(len(counts)) * h}
+
+	for _, count := range counts {
+
+		text := fmt.Sprintf("#%s : %d Task(s)", count.Tag, count.Tasks)
+		if count.Completable > 0 {
+			text += fmt.Sprintf(", %d / %d complete", count.Completed, count.Completable)
+		}
+
+		rect := rl.Rectangle{8, y, w, h}
+
+		if ImmediateButton(rect, text, false) {
+			project.Searchbar.SetText("#" + count.Tag)
+			project.FocusedSearchTask = 0
+			project.SearchForTasks()
+		}
+
+		rl.DrawRectangleRec(rl.Rectangle{rect.X + 2, rect.Y + 2, 4, rect.Height - 4}, tagColor(count.Tag))
+
+		y += h
+
+	}
+
+}
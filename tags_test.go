@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+
+	texts := map[string][]string{
+		"#bug":                              {"bug"},
+		"Fix the #Bug in the #art/sprites":  {"bug", "art/sprites"},
+		"Draw the boss (#art, #boss-fight)": {"art", "boss-fight"},
+		"[#ui] Menus\n#sound_fx":            {"ui", "sound_fx"},
+		"Fixes #12":                         {},
+		"# Heading":                         {},
+		"email@example.com#nope":            {},
+		"https://example.com/page#section":  {},
+		"#été ":                             {"été"},
+		"#bug #bug":                         {"bug", "bug"},
+		"":                                  {},
+	}
+
+	for text, expected := range texts {
+		if tags := parseTags(text); !reflect.DeepEqual(tags, expected) {
+			t.Errorf("expected %q to have the tags %v, got %v", text, expected, tags)
+		}
+	}
+
+	if tags := splitTags("#art, sound  ,BUG,,"); !reflect.DeepEqual(tags, []string{"art", "sound", "bug"}) {
+		t.Errorf("expected the Tags field to have art, sound, and bug, got %v", tags)
+	}
+
+}
+
+func TestTaskTags(t *testing.T) {
+
+	project := newTestProject()
+	board := NewBoard(project)
+	project.Boards = []*Board{board}
+
+	task := newTestTask(board, TASK_TYPE_BOOLEAN, "Draw the #boss", 0, 0, 4, 1)
+	task.TagsTextbox.SetText("art, #boss")
+
+	if tags := task.Tags(); !reflect.DeepEqual(tags, []string{"art", "boss"}) {
+		t.Fatalf("expected the Task to have the tags art and boss, got %v", tags)
+	}
+
+	// The tags are kept until the Task changes.
+	task.Description.SetText("Draw the #boss #music")
+
+	task.UndoChange = true
+	task.CreateUndoState()
+
+	if tags := task.Tags(); !reflect.DeepEqual(tags, []string{"art", "boss", "music"}) {
+		t.Fatalf("expected the Task's tags to update when it changed, got %v", tags)
+	}
+
+}
@@ -3,12 +3,12 @@ package main
 import (
 	"fmt"
 	"math"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/chonla/roman-number-go"
+	"github.com/ncruces/zenity"
 	"github.com/tanema/gween/ease"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -26,6 +26,9 @@ const (
 	TASK_TYPE_MAP
 	TASK_TYPE_WHITEBOARD
 	TASK_TYPE_TABLE
+	TASK_TYPE_MEDIA
+	TASK_TYPE_DOCUMENT
+	TASK_TYPE_BOARD
 )
 
 const (
@@ -65,7 +68,11 @@ type Task struct {
 	TimerMode                    *ButtonGroup
 	TimerRepeating               *Checkbox
 	TimerRunning                 bool
+	MediaPlaying                 bool
+	MediaPosition                float32
+	MediaLoop                    *Checkbox
 	TimerTriggerMode             *ButtonGroup
+	Command                      *Textbox
 	DeadlineOn                   *Checkbox
 	DeadlineDay                  *NumberSpinner
 	DeadlineMonth                *Spinner
@@ -95,6 +102,11 @@ type Task struct {
 	ID                  int
 	PercentageComplete  float32
 	Visible             bool
+	FilteredOut         bool
+	TagsTextbox         *Textbox
+	PriorityButtons     *ButtonGroup
+	AssigneeSpinner     *Spinner
+	tags                []string
 
 	LineEndings []*Task
 	LineStart   *Task
@@ -118,9 +130,17 @@ type Task struct {
 	Contents        Contents
 	ContentBank     map[int]Contents
 	MapImage        *MapImage
+	MapTileset      *Textbox
+	MapLoadTileset  *Button
+	MapTileSize     *NumberSpinner
+	MapLayer        *NumberSpinner
+	MapLayerVisible *Checkbox
 	Whiteboard      *Whiteboard
 	TableData       *TableData
 	Locked          bool
+	LinkedBoardID   string
+	BoardSpinner    *Spinner
+	linkOptions     []*Board
 }
 
 func ParseTaskType(taskData gjson.Result) (taskType int, ok bool) {
@@ -131,6 +151,7 @@ func ParseTaskType(taskData gjson.Result) (taskType int, ok bool) {
 		case 1:  ok = true; taskType = TASK_TYPE_PROGRESSION
 		case 2:  ok = true; taskType = TASK_TYPE_NOTE
 		case 3:  ok = true; taskType = TASK_TYPE_IMAGE
+		case 4:  ok = true; taskType = TASK_TYPE_MEDIA // Formerly Sound Tasks
 		case 5:  ok = true; taskType = TASK_TYPE_TIMER
 		case 6:  ok = true; taskType = TASK_TYPE_LINE
 		case 7:  ok = true; taskType = TASK_TYPE_MAP
@@ -149,6 +170,9 @@ func ParseTaskType(taskData gjson.Result) (taskType int, ok bool) {
 		case "Map":         ok = true; taskType = TASK_TYPE_MAP
 		case "Whiteboard":  ok = true; taskType = TASK_TYPE_WHITEBOARD
 		case "Table":       ok = true; taskType = TASK_TYPE_TABLE
+		case "Media":       ok = true; taskType = TASK_TYPE_MEDIA
+		case "Document":    ok = true; taskType = TASK_TYPE_DOCUMENT
+		case "Board":       ok = true; taskType = TASK_TYPE_BOARD
 		default:            ok = false
 		}
 	} else {
@@ -168,6 +192,9 @@ func TaskTypeStr(e int) string {
 	case TASK_TYPE_MAP:         return "Map"
 	case TASK_TYPE_WHITEBOARD:  return "Whiteboard"
 	case TASK_TYPE_TABLE:       return "Table"
+	case TASK_TYPE_MEDIA:       return "Media"
+	case TASK_TYPE_DOCUMENT:    return "Document"
+	case TASK_TYPE_BOARD:       return "Board"
 	default:                    return ""
 	}
 }
@@ -201,7 +228,7 @@ func NewTask(board *Board) *Task {
 	task := &Task{
 		Rect:                         rl.Rectangle{0, 0, 16, 16},
 		Board:                        board,
-		TaskType:                     NewButtonGroup(0, 32, 500, 32, 3, "Check Box", "Progression", "Note", "Image", "Timer", "Line", "Map", "Whiteboard", "Table"),
+		TaskType:                     NewButtonGroup(0, 32, 500, 32, 3, "Check Box", "Progression", "Note", "Image", "Timer", "Line", "Map", "Whiteboard", "Table", "Media", "Document", "Board Link"),
 		Description:                  NewTextbox(0, 64, 512, 32),
 		TimerName:                    NewTextbox(0, 64, 512, 16),
 		CompletionCheckbox:           NewCheckbox(0, 96, 32, 32),
@@ -211,6 +238,9 @@ func NewTask(board *Board) *Task {
 		ID:                           board.Project.FirstFreeID(),
 		ResetImageSizeButton:         NewButton(0, 0, 192, 32, "Reset Image Size", false),
 		FilePathTextbox:              NewTextbox(0, 64, 512, 16),
+		TagsTextbox:                  NewTextbox(0, 64, 512, 16),
+		PriorityButtons:              NewButtonGroup(0, 0, 400, 32, 1, priorityNames...),
+		AssigneeSpinner:              NewSpinner(0, 0, 256, 40, assigneeNobody),
 		DeadlineMonth:                NewSpinner(0, 128, 200, 40, months...),
 		DeadlineDay:                  NewNumberSpinner(0, 80, 160, 40),
 		DeadlineYear:                 NewNumberSpinner(0, 128, 160, 40),
@@ -223,17 +253,30 @@ func NewTask(board *Board) *Task {
 		DailyMinute:                  NewNumberSpinner(0, 0, 160, 40),
 		TimerRepeating:               NewCheckbox(0, 0, 32, 32),
 		TimerTriggerMode:             NewButtonGroup(0, 0, 400, 32, 1, "None", "Toggle", "Set", "Clear"),
+		Command:                      NewTextbox(0, 0, 512, 16),
 		gridPositions:                []Position{},
 		Valid:                        true,
 		LoadMediaButton:              NewButton(0, 0, 128, 32, "Load", false),
+		MediaLoop:                    NewCheckbox(0, 0, 32, 32),
 		CreationLabel:                NewLabel("Creation time"),
 		CompletionTimeLabel:          NewLabel("Completion time"),
 		LineBezier:                   NewCheckbox(0, 64, 32, 32),
 		LineHeads:                    NewCheckbox(0, 64, 32, 32),
 		LineEndings:                  []*Task{},
 		ContentBank:                  map[int]Contents{},
+		MapTileset:                   NewTextbox(0, 0, 512, 16),
+		MapLoadTileset:               NewButton(0, 0, 128, 32, "Load", false),
+		MapTileSize:                  NewNumberSpinner(0, 0, 160, 40),
+		MapLayer:                     NewNumberSpinner(0, 0, 160, 40),
+		MapLayerVisible:              NewCheckbox(0, 0, 32, 32),
+		BoardSpinner:                 NewSpinner(0, 0, 256, 40, "None"),
 	}
 
+	task.MapTileSize.Minimum = 1
+	task.MapTileSize.SetNumber(int(board.Project.GridSize))
+	task.MapLayer.Minimum = 1
+	task.MapLayer.SetNumber(1)
+
 	task.DailyDay.EnableOption(days[0])
 
 	task.DailyHour.Maximum = 23
@@ -250,6 +293,11 @@ func NewTask(board *Board) *Task {
 	task.DeadlineMonth.ExpandUpwards = true
 	task.DeadlineMonth.ExpandMaxRowCount = 5
 
+	task.AssigneeSpinner.ExpandUpwards = true
+	task.AssigneeSpinner.ExpandMaxRowCount = 5
+
+	task.BoardSpinner.ExpandMaxRowCount = 5
+
 	task.CreationTime = time.Now()
 	task.CompletionProgressionCurrent.Textbox.MaxCharactersPerLine = 19
 	task.CompletionProgressionCurrent.Textbox.AllowNewlines = false
@@ -266,6 +314,12 @@ func NewTask(board *Board) *Task {
 
 	task.FilePathTextbox.VerticalAlignment = ALIGN_CENTER
 
+	task.TagsTextbox.AllowNewlines = false
+	task.TagsTextbox.VerticalAlignment = ALIGN_CENTER
+
+	task.Command.AllowNewlines = false
+	task.Command.VerticalAlignment = ALIGN_CENTER
+
 	task.DeadlineDay.Minimum = 1
 	task.DeadlineDay.Maximum = 31
 	task.DeadlineDay.Loop = true
@@ -306,6 +360,12 @@ func (task *Task) SetPanel() {
 	row.Item(NewLabel("Created On:"))
 	row.Item(task.CreationLabel)
 
+	row = column.Row()
+	row.Item(NewLabel("Tags:"), TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_NOTE, TASK_TYPE_IMAGE, TASK_TYPE_TIMER,
+		TASK_TYPE_MAP, TASK_TYPE_WHITEBOARD, TASK_TYPE_TABLE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT, TASK_TYPE_BOARD)
+	row.Item(task.TagsTextbox, TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_NOTE, TASK_TYPE_IMAGE, TASK_TYPE_TIMER,
+		TASK_TYPE_MAP, TASK_TYPE_WHITEBOARD, TASK_TYPE_TABLE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT, TASK_TYPE_BOARD)
+
 	column.Row().Item(NewLabel("Task Description:"),
 		TASK_TYPE_BOOLEAN,
 		TASK_TYPE_PROGRESSION,
@@ -326,15 +386,19 @@ func (task *Task) SetPanel() {
 	task.TimerName.SetFocused(true)
 
 	row = column.Row()
-	row.Item(NewLabel("Filepath:"), TASK_TYPE_IMAGE)
+	row.Item(NewLabel("Filepath:"), TASK_TYPE_IMAGE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT)
 	row = column.Row()
-	row.Item(task.FilePathTextbox, TASK_TYPE_IMAGE)
+	row.Item(task.FilePathTextbox, TASK_TYPE_IMAGE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT)
 	row = column.Row()
-	row.Item(task.LoadMediaButton, TASK_TYPE_IMAGE)
+	row.Item(task.LoadMediaButton, TASK_TYPE_IMAGE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT)
 
 	row = column.Row()
 	row.Item(task.ResetImageSizeButton, TASK_TYPE_IMAGE)
 
+	row = column.Row()
+	row.Item(NewLabel("Loop Audio:"), TASK_TYPE_MEDIA)
+	row.Item(task.MediaLoop, TASK_TYPE_MEDIA)
+
 	task.FilePathTextbox.SetFocused(true)
 
 	row = column.Row()
@@ -380,6 +444,16 @@ func (task *Task) SetPanel() {
 
 	// row.Item(NewLabel("Date"), TASK_TYPE_TIMER).Name = "timer_date"
 
+	row = column.Row()
+	row.Item(NewLabel("Priority:"), TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_TABLE)
+	row.Item(task.PriorityButtons, TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_TABLE)
+
+	row = column.Row()
+	row.Item(NewLabel("Assigned To:"), TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_TABLE)
+	row.Item(task.AssigneeSpinner, TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_TABLE)
+
+	task.SyncAssigneeOptions("")
+
 	row = column.Row()
 	row.Item(NewLabel("Repeating:"), TASK_TYPE_TIMER).Name = "timer_repeating"
 	row.Item(task.TimerRepeating, TASK_TYPE_TIMER).Name = "timer_repeating"
@@ -389,6 +463,12 @@ func (task *Task) SetPanel() {
 	row = column.Row()
 	row.Item(task.TimerTriggerMode, TASK_TYPE_TIMER).Name = "timer_trigger"
 
+	row = column.Row()
+	row.Item(NewLabel("Command to Run When Completed:"), TASK_TYPE_BOOLEAN)
+	row.Item(NewLabel("Command to Run When Timer Goes Off:"), TASK_TYPE_TIMER)
+	row = column.Row()
+	row.Item(task.Command, TASK_TYPE_BOOLEAN, TASK_TYPE_TIMER)
+
 	// row.Item(NewLabel("Stopwatch"), TASK_TYPE_TIMER).Name = "timer_stopwatch"
 
 	row = column.Row()
@@ -410,6 +490,50 @@ func (task *Task) SetPanel() {
 	row.Item(NewButton(0, 0, 128, 32, "Clear", false), TASK_TYPE_MAP, TASK_TYPE_WHITEBOARD).Name = "clear"
 	row.Item(NewButton(0, 0, 128, 32, "Invert", false), TASK_TYPE_WHITEBOARD).Name = "invert"
 
+	row = column.Row()
+	row.Item(NewLabel("Tileset:"), TASK_TYPE_MAP)
+	row = column.Row()
+	row.Item(task.MapTileset, TASK_TYPE_MAP)
+	row = column.Row()
+	row.Item(task.MapLoadTileset, TASK_TYPE_MAP)
+	row.Item(NewLabel("Tile Size:"), TASK_TYPE_MAP)
+	row.Item(task.MapTileSize, TASK_TYPE_MAP)
+
+	row = column.Row()
+	row.Item(NewLabel("Layer:"), TASK_TYPE_MAP)
+	row.Item(task.MapLayer, TASK_TYPE_MAP)
+	row.Item(NewLabel("Visible:"), TASK_TYPE_MAP)
+	row.Item(task.MapLayerVisible, TASK_TYPE_MAP)
+	row = column.Row()
+	row.Item(NewButton(0, 0, 128, 32, "Add Layer", false), TASK_TYPE_MAP).Name = "add layer"
+	row.Item(NewButton(0, 0, 128, 32, "Delete Layer", false), TASK_TYPE_MAP).Name = "delete layer"
+
+	row = column.Row()
+	row.Item(NewButton(0, 0, 256, 32, "Export Image...", false), TASK_TYPE_MAP, TASK_TYPE_WHITEBOARD).Name = "export image"
+	row.Item(NewButton(0, 0, 256, 32, "Export Tiled Map...", false), TASK_TYPE_MAP).Name = "export tiled map"
+
+	row = column.Row()
+	row.Item(NewLabel("Linked Board:"), TASK_TYPE_BOARD)
+	row = column.Row()
+	row.Item(task.BoardSpinner, TASK_TYPE_BOARD)
+	row = column.Row()
+	row.Item(NewButton(0, 0, 256, 32, "Create New Board", false), TASK_TYPE_BOARD).Name = "create linked board"
+
+	task.SyncBoardLinkOptions()
+
+	if task.MapImage != nil {
+		task.MapTileset.SetText(task.MapImage.TilesetPath)
+		task.MapTileSize.SetNumber(int(task.MapImage.TileSize))
+		task.SyncMapLayerPanel()
+	}
+
+}
+
+// SyncMapLayerPanel updates the layer controls in the edit panel to match the Map's current layer.
+func (task *Task) SyncMapLayerPanel() {
+	task.MapLayer.Maximum = len(task.MapImage.Layers)
+	task.MapLayer.SetNumber(task.MapImage.LayerIndex + 1)
+	task.MapLayerVisible.Checked = task.MapImage.CurrentLayer().Visible
 }
 
 func (task *Task) Clone() *Task {
@@ -419,6 +543,7 @@ func (task *Task) Clone() *Task {
 	copyData.Description = task.Description.Clone()
 
 	copyData.TimerRunning = false // Copies shouldn't be running
+	copyData.MediaPlaying = false
 
 	copyData.TaskType = copyData.TaskType.Clone()
 
@@ -429,6 +554,10 @@ func (task *Task) Clone() *Task {
 	copyData.CompletionProgressionMax = task.CompletionProgressionMax.Clone()
 
 	copyData.FilePathTextbox = task.FilePathTextbox.Clone()
+	copyData.TagsTextbox = task.TagsTextbox.Clone()
+	copyData.PriorityButtons = task.PriorityButtons.Clone()
+	copyData.AssigneeSpinner = task.AssigneeSpinner.Clone()
+	copyData.tags = nil
 
 	copyData.Contents = nil // We'll leave it to the copy to create its own contents
 	copyData.ContentBank = map[int]Contents{}
@@ -440,6 +569,7 @@ func (task *Task) Clone() *Task {
 	copyData.TimerMode = copyData.TimerMode.Clone()
 	copyData.TimerRepeating = copyData.TimerRepeating.Clone()
 	copyData.TimerTriggerMode = copyData.TimerTriggerMode.Clone()
+	copyData.Command = copyData.Command.Clone()
 
 	copyData.DailyDay = copyData.DailyDay.Clone()
 	copyData.DailyHour = copyData.DailyHour.Clone()
@@ -449,15 +579,25 @@ func (task *Task) Clone() *Task {
 	copyData.CountdownSecond = copyData.CountdownSecond.Clone()
 
 	copyData.LoadMediaButton = copyData.LoadMediaButton.Clone()
+	copyData.MediaLoop = copyData.MediaLoop.Clone()
 
 	copyData.DeadlineOn = copyData.DeadlineOn.Clone()
 	copyData.DeadlineDay = task.DeadlineDay.Clone()
 	copyData.DeadlineMonth = copyData.DeadlineMonth.Clone()
 	copyData.DeadlineYear = task.DeadlineYear.Clone()
 
+	copyData.MapTileset = copyData.MapTileset.Clone()
+	copyData.MapLoadTileset = copyData.MapLoadTileset.Clone()
+	copyData.MapTileSize = copyData.MapTileSize.Clone()
+	copyData.MapLayer = copyData.MapLayer.Clone()
+	copyData.MapLayerVisible = copyData.MapLayerVisible.Clone()
+
 	copyData.LineBezier = copyData.LineBezier.Clone()
 	copyData.LineHeads = copyData.LineHeads.Clone()
 
+	copyData.BoardSpinner = copyData.BoardSpinner.Clone()
+	copyData.linkOptions = nil
+
 	copyData.ID = copyData.Board.Project.FirstFreeID()
 
 	copyData.ReceiveMessage(MessageTaskClose, nil) // We do this to recreate the resources for the Task, if necessary.
@@ -486,6 +626,7 @@ func (task *Task) Serialize() string {
 	jsonData := "{}"
 
 	jsonData, _ = sjson.Set(jsonData, `BoardIndex`, task.Board.Index())
+	jsonData, _ = sjson.Set(jsonData, `BoardID`, task.Board.ID)
 
 	// IT CAN BE NEGATIVE ZERO HOHMYGOSH; That's why we call Project.LockPositionToGrid, because it also handles settings -0 to 0.
 	pos := task.Board.Project.RoundPositionToGrid(task.Position)
@@ -493,7 +634,7 @@ func (task *Task) Serialize() string {
 	jsonData, _ = sjson.Set(jsonData, `Position\.X`, pos.X)
 	jsonData, _ = sjson.Set(jsonData, `Position\.Y`, pos.Y)
 
-	if task.Is(TASK_TYPE_IMAGE, TASK_TYPE_MAP, TASK_TYPE_WHITEBOARD) {
+	if task.Is(TASK_TYPE_IMAGE, TASK_TYPE_MAP, TASK_TYPE_WHITEBOARD, TASK_TYPE_MEDIA) {
 		jsonData, _ = sjson.Set(jsonData, `ImageDisplaySize\.X`, math.Round(float64(task.DisplaySize.X)))
 		jsonData, _ = sjson.Set(jsonData, `ImageDisplaySize\.Y`, math.Round(float64(task.DisplaySize.Y)))
 	}
@@ -503,33 +644,43 @@ func (task *Task) Serialize() string {
 	jsonData, _ = sjson.Set(jsonData, `Progression\.Max`, task.CompletionProgressionMax.Number())
 	jsonData, _ = sjson.Set(jsonData, `Description`, task.Description.Text())
 
-	if task.UsesMedia() && task.FilePathTextbox.Text() != "" {
-
-		resourcePath := task.FilePathTextbox.Text()
-
-		if resource := task.Board.Project.RetrieveResource(resourcePath); resource != nil && resource.DownloadResponse == nil {
-
-			// Turn the file path absolute if it's not a remote path
-			relative, err := filepath.Rel(filepath.Dir(task.Board.Project.FilePath), resourcePath)
+	if tags := splitTags(task.TagsTextbox.Text()); len(tags) > 0 {
+		jsonData, _ = sjson.Set(jsonData, `Tags`, uniqueTags(tags))
+	}
 
-			if err == nil {
+	if task.Priority() != PRIORITY_NONE {
+		jsonData, _ = sjson.Set(jsonData, `Priority`, priorityNames[task.Priority()])
+	}
 
-				jsonData, _ = sjson.Set(jsonData, `FilePath`, strings.Split(relative, string(filepath.Separator)))
-				resourcePath = ""
+	if task.Assignee() != "" {
+		jsonData, _ = sjson.Set(jsonData, `Assignee`, task.Assignee())
+	}
 
-			}
+	if task.UsesMedia() && task.FilePathTextbox.Text() != "" {
 
-		}
+		resourcePath := task.FilePathTextbox.Text()
 
-		if resourcePath != "" {
+		// Local files are saved relative to the project file; remote paths are saved as-is
+		if resource := task.Board.Project.RetrieveResource(resourcePath); resource != nil && resource.DownloadResponse == nil {
+			jsonData, _ = sjson.Set(jsonData, `FilePath`, task.Board.Project.MediaPathToData(resourcePath))
+		} else {
 			jsonData, _ = sjson.Set(jsonData, `FilePath`, resourcePath)
 		}
 
 	}
 
 	jsonData, _ = sjson.Set(jsonData, `Selected`, task.Selected)
+
+	if task.Is(TASK_TYPE_BOOLEAN, TASK_TYPE_TIMER) && task.Command.Text() != "" {
+		jsonData, _ = sjson.Set(jsonData, `Command`, task.Command.Text())
+	}
 	jsonData, _ = sjson.Set(jsonData, `TaskType\.CurrentChoice`, TaskTypeStr(task.TaskType.CurrentChoice))
 
+	if task.Is(TASK_TYPE_MEDIA) {
+		jsonData, _ = sjson.Set(jsonData, `MediaPosition`, task.MediaPosition)
+		jsonData, _ = sjson.Set(jsonData, `MediaLoop`, task.MediaLoop.Checked)
+	}
+
 	if task.Is(TASK_TYPE_TIMER) {
 		jsonData, _ = sjson.Set(jsonData, `TimerMode\.CurrentChoice`, task.TimerMode.CurrentChoice)
 		jsonData, _ = sjson.Set(jsonData, `TimerRunning`, task.TimerRunning)
@@ -589,28 +740,41 @@ func (task *Task) Serialize() string {
 	}
 
 	if task.Is(TASK_TYPE_MAP) && task.MapImage != nil {
-		data := [][]int32{}
-		for y := 0; y < int(task.MapImage.cellHeight); y++ {
-			data = append(data, []int32{})
-			for x := 0; x < int(task.MapImage.cellWidth); x++ {
-				data[y] = append(data[y], task.MapImage.Data[y][x])
-			}
+		jsonData, _ = sjson.Set(jsonData, `MapFormat`, MapFormat)
+		jsonData, _ = sjson.SetRaw(jsonData, `MapLayers`, task.MapImage.SerializeLayers())
+		jsonData, _ = sjson.Set(jsonData, `MapLayer`, task.MapImage.LayerIndex)
+		if task.MapImage.TilesetPath != "" {
+			jsonData, _ = sjson.Set(jsonData, `MapTileset`, task.Board.Project.MediaPathToData(task.MapImage.TilesetPath))
+			jsonData, _ = sjson.Set(jsonData, `MapTileSize`, task.MapImage.TileSize)
 		}
-		jsonData, _ = sjson.Set(jsonData, `MapData`, data)
 	}
 
 	if task.Is(TASK_TYPE_WHITEBOARD) && task.Whiteboard != nil {
-		jsonData, _ = sjson.Set(jsonData, `Whiteboard`, task.Whiteboard.Serialize())
+		jsonData, _ = sjson.SetRaw(jsonData, `Whiteboard`, task.Whiteboard.Serialize())
 	}
 
 	if task.Is(TASK_TYPE_TABLE) && task.TableData != nil {
 		jsonData, _ = sjson.SetRaw(jsonData, `TableData`, task.TableData.Serialize())
 	}
 
+	if task.Is(TASK_TYPE_BOARD) && task.LinkedBoardID != "" {
+		jsonData, _ = sjson.Set(jsonData, `LinkedBoard`, task.LinkedBoardID)
+	}
+
 	return jsonData
 
 }
 
+// saveData returns the Task's serialized state as it's saved in a plan file: along with its state, it includes the size
+// the Task is drawn at, so that the dashboard can tell which Tasks are stacked together without the fonts needed to work
+// that out. It isn't part of the Task's state itself, as it follows from it.
+func (task *Task) saveData() string {
+	data := task.Serialize()
+	data, _ = sjson.Set(data, `DisplaySize\.X`, task.DisplaySize.X)
+	data, _ = sjson.Set(data, `DisplaySize\.Y`, task.DisplaySize.Y)
+	return data
+}
+
 // Serializable returns if Tasks are able to be serialized properly. Only line endings aren't properly serializeable
 func (task *Task) Serializable() bool {
 	return !task.Is(TASK_TYPE_LINE) || task.LineStart == nil
@@ -661,29 +825,30 @@ func (task *Task) Deserialize(taskData gjson.Result, taskType int) {
 	task.CompletionProgressionMax.SetNumber(getInt(`Progression\.Max`))
 	task.Description.SetText(getString(`Description`))
 
-	if f := taskData.Get(`FilePath`); f.Exists() {
-		if f.IsArray() {
-			str := []string{}
-			for _, component := range f.Array() {
-				str = append(str, component.String())
-			}
+	tags := []string{}
+	for _, tag := range taskData.Get(`Tags`).Array() {
+		tags = append(tags, tag.String())
+	}
+	task.TagsTextbox.SetText(strings.Join(tags, " "))
 
-			// We need to go from the project file as the "root", as otherwise it will be relative
-			// to the current working directory (which is not ideal).
-			str = append([]string{filepath.Dir(task.Board.Project.FilePath)}, str...)
-			joinedElements := strings.Join(str, string(filepath.Separator))
-			abs, _ := filepath.Abs(joinedElements)
+	task.PriorityButtons.CurrentChoice = parsePriority(getString(`Priority`))
+	task.SetAssignee(getString(`Assignee`))
 
-			task.FilePathTextbox.SetText(abs)
-		} else {
-			task.FilePathTextbox.SetText(getString(`FilePath`))
-		}
+	if f := taskData.Get(`FilePath`); f.Exists() {
+		task.FilePathTextbox.SetText(task.Board.Project.MediaPathFromData(f))
 	}
 
 	if hasData(`Selected`) {
 		task.Selected = getBool(`Selected`)
 	}
 
+	task.Command.SetText(getString(`Command`))
+
+	if task.Is(TASK_TYPE_MEDIA) {
+		task.MediaPosition = getFloat(`MediaPosition`)
+		task.MediaLoop.Checked = getBool(`MediaLoop`)
+	}
+
 	if task.Is(TASK_TYPE_TIMER) {
 
 		task.TimerMode.CurrentChoice = getInt(`TimerMode\.CurrentChoice`)
@@ -767,17 +932,19 @@ func (task *Task) Deserialize(taskData gjson.Result, taskType int) {
 		task.Board.Project.LogOn = prevLogOn
 	}
 
-	if hasData(`MapData`) {
+	if hasData(`MapLayers`) || hasData(`MapData`) {
 		if task.MapImage == nil {
 			task.MapImage = NewMapImage(task)
 		}
 
-		for y, row := range taskData.Get(`MapData`).Array() {
-			for x, value := range row.Array() {
-				task.MapImage.Data[y][x] = int32(value.Int())
-			}
+		layers, err := mapLayersFromData(taskData)
+		if err != nil {
+			task.Board.Project.Log("ERROR: Couldn't load Map data: %s", err.Error())
 		}
 
+		task.MapImage.DeserializeLayers(layers, int(taskData.Get(`MapLayer`).Int()))
+		task.MapImage.SetTileset(task.Board.Project.MediaPathFromData(taskData.Get(`MapTileset`)), int32(taskData.Get(`MapTileSize`).Int()))
+
 		task.MapImage.cellWidth = int(int32(task.DisplaySize.X) / task.Board.Project.GridSize)
 		task.MapImage.cellHeight = int((int32(task.DisplaySize.Y) - task.Board.Project.GridSize) / task.Board.Project.GridSize)
 		task.MapImage.Changed = true
@@ -790,12 +957,7 @@ func (task *Task) Deserialize(taskData gjson.Result, taskType int) {
 
 		task.Whiteboard.Resize(task.DisplaySize.X, task.DisplaySize.Y-float32(task.Board.Project.GridSize))
 
-		wbData := []string{}
-		for _, row := range taskData.Get(`Whiteboard`).Array() {
-			wbData = append(wbData, row.String())
-		}
-
-		task.Whiteboard.Deserialize(wbData)
+		task.Whiteboard.Deserialize(taskData.Get(`Whiteboard`))
 
 	}
 
@@ -807,9 +969,17 @@ func (task *Task) Deserialize(taskData gjson.Result, taskType int) {
 		task.TableData.Deserialize(getString(`TableData`))
 	}
 
+	if task.Is(TASK_TYPE_BOARD) {
+		task.LinkedBoardID = getString(`LinkedBoard`)
+	}
+
 	if task.Contents != nil {
 		task.Contents.ReceiveMessage(MessageTaskDeserialization)
 	}
+
+	task.Board.Project.SearchIndex.Invalidate(task)
+	task.Board.Project.InvalidateBoardParents()
+	task.InvalidateTags()
 }
 
 func (task *Task) Update() {
@@ -833,6 +1003,12 @@ func (task *Task) Update() {
 		}
 	}
 
+	task.FilteredOut = !task.Board.Project.FilterMatches(task)
+
+	if task.FilteredOut && task.Board.Project.FilterHides() {
+		task.Visible = false
+	}
+
 	if task.Dragging {
 
 		if task.Selected {
@@ -940,6 +1116,10 @@ func (task *Task) Draw() {
 
 		task.Contents.Draw()
 
+		if !task.Is(TASK_TYPE_LINE) {
+			task.DrawTagChips()
+		}
+
 		displaySize := task.DisplaySize
 
 		if task.TempDisplaySize.X > 0 {
@@ -1000,6 +1180,8 @@ func (task *Task) CreateUndoState() {
 
 		state := NewUndoState(task)
 
+		task.Board.Project.Hooks.TaskChanged(task, task.UndoCreation, task.UndoDeletion)
+
 		if task.UndoCreation {
 			state.Creation = true
 		} else if task.UndoDeletion {
@@ -1008,6 +1190,10 @@ func (task *Task) CreateUndoState() {
 
 		task.Board.UndoHistory.Capture(state, false)
 
+		task.Board.Project.SearchIndex.Invalidate(task)
+		task.InvalidateTags()
+		task.invalidateBoardLink()
+
 		task.UndoChange = false
 		task.UndoCreation = false
 		task.UndoDeletion = false
@@ -1016,6 +1202,10 @@ func (task *Task) CreateUndoState() {
 			task.Board.Project.Modified = true
 		}
 
+		if task.Board.Project.Sync != nil {
+			task.Board.Project.Sync.TaskChanged(task)
+		}
+
 	}
 
 }
@@ -1036,6 +1226,10 @@ func (task *Task) PostDraw() {
 
 		taskEditPanel.Update()
 
+		if task.Description.Changed || task.TagsTextbox.Changed {
+			task.InvalidateTags()
+		}
+
 		if task.TaskType.CurrentChoice != prevType {
 
 			if task.Contents != nil {
@@ -1046,6 +1240,8 @@ func (task *Task) PostDraw() {
 
 			task.SetPanel() // We call this after creating contents because creating a Line task calls SetPanel()
 
+			task.invalidateBoardLink()
+
 			task.Contents.ReceiveMessage(MessageDoubleClick) // We call this so that Tables can know to re-set the Panel
 
 		}
@@ -1149,6 +1345,56 @@ func (task *Task) PostDraw() {
 			}
 		}
 
+		if export := taskEditPanel.FindItems("export image")[0]; export.Element.(*Button).Clicked {
+			task.Board.Project.ExportTaskImage(task)
+		}
+
+		if task.Is(TASK_TYPE_MAP) && task.MapImage != nil {
+
+			if task.MapLoadTileset.Clicked {
+
+				patterns := []string{}
+				patterns = append(patterns, PermutateCaseForString("png", "*.")...)
+				patterns = append(patterns, PermutateCaseForString("bmp", "*.")...)
+				patterns = append(patterns, PermutateCaseForString("jpeg", "*.")...)
+				patterns = append(patterns, PermutateCaseForString("jpg", "*.")...)
+
+				path, err := zenity.SelectFile(zenity.Title("Select tileset image"), zenity.FileFilters{{Name: "Image File", Patterns: patterns}})
+
+				if err == nil && path != "" {
+					task.MapTileset.SetText(path)
+				}
+
+			}
+
+			if task.MapLayer.Changed {
+				task.MapImage.SetLayer(task.MapLayer.Number() - 1)
+				task.MapLayerVisible.Checked = task.MapImage.CurrentLayer().Visible
+			} else if task.MapLayerVisible.Checked != task.MapImage.CurrentLayer().Visible {
+				task.MapImage.CurrentLayer().Visible = task.MapLayerVisible.Checked
+				task.MapImage.Changed = true
+			}
+
+			if add := taskEditPanel.FindItems("add layer")[0]; add.Element.(*Button).Clicked {
+				task.MapImage.AddLayer()
+				task.SyncMapLayerPanel()
+			}
+
+			if delete := taskEditPanel.FindItems("delete layer")[0]; delete.Element.(*Button).Clicked {
+				task.MapImage.DeleteLayer()
+				task.SyncMapLayerPanel()
+			}
+
+			if clear := taskEditPanel.FindItems("clear")[0]; clear.Element.(*Button).Clicked {
+				task.MapImage.Clear()
+			}
+
+			if export := taskEditPanel.FindItems("export tiled map")[0]; export.Element.(*Button).Clicked {
+				task.Board.Project.ExportTaskTiledMap(task)
+			}
+
+		}
+
 		if task.Whiteboard != nil {
 
 			if invert := taskEditPanel.FindItems("invert")[0]; invert.Element.(*Button).Clicked {
@@ -1160,6 +1406,18 @@ func (task *Task) PostDraw() {
 
 		}
 
+		if task.Is(TASK_TYPE_BOARD) {
+
+			if choice := task.BoardSpinner.CurrentChoice; choice < len(task.linkOptions) && task.linkOptions[choice] != task.LinkedBoard() {
+				task.LinkBoard(task.linkOptions[choice])
+			}
+
+			if create := taskEditPanel.FindItems("create linked board")[0]; create.Element.(*Button).Clicked {
+				task.CreateLinkedBoard()
+			}
+
+		}
+
 		task.CreationLabel.Text = task.CreationTime.Format("Monday, Jan 2, 2006, 15:04")
 
 	}
@@ -1184,6 +1442,12 @@ func (task *Task) SetContents() {
 			task.Contents = NewTableContents(task)
 		case TASK_TYPE_IMAGE:
 			task.Contents = NewImageContents(task)
+		case TASK_TYPE_MEDIA:
+			task.Contents = NewMediaContents(task)
+		case TASK_TYPE_DOCUMENT:
+			task.Contents = NewDocumentContents(task)
+		case TASK_TYPE_BOARD:
+			task.Contents = NewBoardLinkContents(task)
 		case TASK_TYPE_MAP:
 			task.Contents = NewMapContents(task)
 		case TASK_TYPE_WHITEBOARD:
@@ -1276,12 +1540,17 @@ func (task *Task) DrawShadow() {
 
 func (task *Task) ReceiveMessage(message string, data map[string]interface{}) {
 
+	task.Board.Project.Hooks.Received(task, message, data)
+
 	if message == MessageSelect {
 
 		if data["task"] == task {
 			if data["invert"] != nil {
 				task.Selected = false
 			} else {
+				if !task.Selected {
+					task.Board.Project.Hooks.Trigger(HookTaskSelected, task)
+				}
 				task.Selected = true
 			}
 		} else if data["task"] == nil || data["task"] != task {
@@ -1318,6 +1587,11 @@ func (task *Task) ReceiveMessage(message string, data map[string]interface{}) {
 			task.Open = false
 			task.Board.Project.TaskOpen = false
 
+			if task.Is(TASK_TYPE_MAP) && task.MapImage != nil {
+				// Like Image Tasks, the tileset is only loaded once editing is done, rather than on each keypress
+				task.MapImage.SetTileset(task.MapTileset.Text(), int32(task.MapTileSize.Number()))
+			}
+
 			task.Board.Project.PreviousTaskType = task.TaskType.CurrentChoice
 
 			// We flip the flag indicating to reorder tasks when possible
@@ -1373,7 +1647,7 @@ func (task *Task) ReceiveMessage(message string, data map[string]interface{}) {
 
 	} else if message == MessageThemeChange {
 		if task.Is(TASK_TYPE_MAP) && task.MapImage != nil {
-			task.MapImage.Changed = true // Force update to change color palette
+			task.MapImage.redraw = true // Force update to change color palette
 		}
 	} else if message == MessageSettingsChange {
 	} else if message == MessageTaskRestore {
@@ -1519,28 +1793,38 @@ func (task *Task) UpdateNeighbors() {
 }
 
 func (task *Task) IsComplete() bool {
+	return isComplete(task)
+}
 
-	if task.Is(TASK_TYPE_BOOLEAN) && len(task.SubTasks) > 0 {
-		for _, child := range task.SubTasks {
-			if !child.IsComplete() {
-				return false
-			}
-		}
-		return true
-	} else {
-		if task.Is(TASK_TYPE_BOOLEAN) {
-			return task.CompletionCheckbox.Checked
-		} else if task.Is(TASK_TYPE_PROGRESSION) {
-			return task.CompletionProgressionMax.Number() > 0 && task.CompletionProgressionCurrent.Number() >= task.CompletionProgressionMax.Number()
-		} else if task.Is(TASK_TYPE_TABLE) && task.TableData != nil {
-			return task.TableData.IsComplete()
-		}
+func (task *Task) IsCompletable() bool {
+	return isCompletable(task)
+}
+
+func (task *Task) completionState() completionState {
+
+	state := completionState{}
+
+	if task.Is(TASK_TYPE_BOOLEAN) {
+		state.Checked = task.CompletionCheckbox.Checked
+	} else if task.Is(TASK_TYPE_PROGRESSION) {
+		state.Current = task.CompletionProgressionCurrent.Number()
+		state.Max = task.CompletionProgressionMax.Number()
+	} else if task.Is(TASK_TYPE_TABLE) && task.TableData != nil {
+		state.HasTable = true
+		state.TableCompleted = task.TableData.CompletionCount()
+		state.TableTotal = task.TableData.CompletionMax()
 	}
-	return false
+
+	return state
+
 }
 
-func (task *Task) IsCompletable() bool {
-	return task.Is(TASK_TYPE_BOOLEAN, TASK_TYPE_PROGRESSION, TASK_TYPE_TABLE)
+func (task *Task) subTaskCount() int {
+	return len(task.SubTasks)
+}
+
+func (task *Task) subTask(index int) completable {
+	return task.SubTasks[index]
 }
 
 func (task *Task) NeighborInDirection(dirX, dirY float32) *Task {
@@ -1679,7 +1963,7 @@ func (task *Task) SmallButton(srcX, srcY, srcW, srcH, dstX, dstY float32) bool {
 
 	color := getThemeColor(GUI_FONT_COLOR)
 
-	mouseOver := rl.CheckCollisionPointRec(GetWorldMousePosition(), dstRect)
+	mouseOver := rl.CheckCollisionPointRec(GetWorldMousePosition(), dstRect) && !task.Board.Project.Presenting
 
 	if task.Selected && mouseOver && !MousePressed(rl.MouseLeftButton) {
 		color = getThemeColor(GUI_INSIDE_DISABLED)
@@ -1744,7 +2028,7 @@ func (task *Task) Destroy() {
 }
 
 func (task *Task) UsesMedia() bool {
-	return task.Is(TASK_TYPE_IMAGE)
+	return task.Is(TASK_TYPE_IMAGE, TASK_TYPE_MEDIA, TASK_TYPE_DOCUMENT)
 }
 
 func (task *Task) Is(taskTypes ...int) bool {
@@ -1795,21 +2079,5 @@ func (task *Task) DistanceTo(other *Task) float32 {
 }
 
 func (task *Task) CountTotals() (int, int, int, int) {
-	cnt, max, rcnt, rmax := 0, 0, 0, 0
-	for _, t := range task.SubTasks {
-		if t.IsCompletable() {
-			max++
-			rmax++
-			if t.IsComplete() {
-				cnt++
-				rcnt++
-			}
-		}
-		if len(t.SubTasks) > 0 {
-			_, _, rc, rm := t.CountTotals()
-			rcnt += rc
-			rmax += rm
-		}
-	}
-	return cnt, max, rcnt, rmax
+	return countTotals(task)
 }
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ncruces/zenity"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Map Tasks can be exported to the Tiled map editor's TMX (XML) and JSON formats. Tile values map directly onto Tiled's
+// global tile IDs, as a Map has a single tileset whose first ID is 1, and 0 is an empty cell in both.
+
+type tiledTileset struct {
+	Path       string
+	TileSize   int
+	Width      int
+	Height     int
+	Columns    int
+	TileCount  int
+	ImageFound bool
+}
+
+type tmxMap struct {
+	XMLName      xml.Name     `xml:"map"`
+	Version      string       `xml:"version,attr"`
+	Orientation  string       `xml:"orientation,attr"`
+	RenderOrder  string       `xml:"renderorder,attr"`
+	Width        int          `xml:"width,attr"`
+	Height       int          `xml:"height,attr"`
+	TileWidth    int          `xml:"tilewidth,attr"`
+	TileHeight   int          `xml:"tileheight,attr"`
+	Infinite     int          `xml:"infinite,attr"`
+	NextLayerID  int          `xml:"nextlayerid,attr"`
+	NextObjectID int          `xml:"nextobjectid,attr"`
+	Tilesets     []tmxTileset `xml:"tileset"`
+	Layers       []tmxLayer   `xml:"layer"`
+}
+
+type tmxTileset struct {
+	FirstGID   int      `xml:"firstgid,attr"`
+	Name       string   `xml:"name,attr"`
+	TileWidth  int      `xml:"tilewidth,attr"`
+	TileHeight int      `xml:"tileheight,attr"`
+	TileCount  int      `xml:"tilecount,attr"`
+	Columns    int      `xml:"columns,attr"`
+	Image      tmxImage `xml:"image"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxLayer struct {
+	ID      int     `xml:"id,attr"`
+	Name    string  `xml:"name,attr"`
+	Width   int     `xml:"width,attr"`
+	Height  int     `xml:"height,attr"`
+	Visible *int    `xml:"visible,attr,omitempty"`
+	Data    tmxData `xml:"data"`
+}
+
+type tmxData struct {
+	Encoding string `xml:"encoding,attr"`
+	CSV      string `xml:",chardata"`
+}
+
+type tiledJSONMap struct {
+	Type         string             `json:"type"`
+	Version      string             `json:"version"`
+	Orientation  string             `json:"orientation"`
+	RenderOrder  string             `json:"renderorder"`
+	Width        int                `json:"width"`
+	Height       int                `json:"height"`
+	TileWidth    int                `json:"tilewidth"`
+	TileHeight   int                `json:"tileheight"`
+	Infinite     bool               `json:"infinite"`
+	NextLayerID  int                `json:"nextlayerid"`
+	NextObjectID int                `json:"nextobjectid"`
+	Layers       []tiledJSONLayer   `json:"layers"`
+	Tilesets     []tiledJSONTileset `json:"tilesets"`
+}
+
+type tiledJSONLayer struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	X       int     `json:"x"`
+	Y       int     `json:"y"`
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	Opacity float32 `json:"opacity"`
+	Visible bool    `json:"visible"`
+	Data    []int32 `json:"data"`
+}
+
+type tiledJSONTileset struct {
+	FirstGID    int    `json:"firstgid"`
+	Name        string `json:"name"`
+	Image       string `json:"image"`
+	ImageWidth  int    `json:"imagewidth"`
+	ImageHeight int    `json:"imageheight"`
+	TileWidth   int    `json:"tilewidth"`
+	TileHeight  int    `json:"tileheight"`
+	TileCount   int    `json:"tilecount"`
+	Columns     int    `json:"columns"`
+	Margin      int    `json:"margin"`
+	Spacing     int    `json:"spacing"`
+}
+
+// tiledTilesetFor describes the tileset of a serialized Map Task, with its image path relative to the exported file,
+// as Tiled expects.
+func tiledTilesetFor(taskData gjson.Result, outputPath string) (tiledTileset, bool) {
+
+	tileset := tiledTileset{
+		Path:     taskData.Get(`MapTileset`).String(),
+		TileSize: int(taskData.Get(`MapTileSize`).Int()),
+	}
+
+	if tileset.Path == "" || tileset.TileSize <= 0 {
+		return tileset, false
+	}
+
+	if file, err := os.Open(tileset.Path); err == nil {
+		if config, _, err := image.DecodeConfig(file); err == nil {
+			tileset.Width = config.Width
+			tileset.Height = config.Height
+			tileset.Columns = config.Width / tileset.TileSize
+			tileset.TileCount = tileset.Columns * (config.Height / tileset.TileSize)
+			tileset.ImageFound = true
+		}
+		file.Close()
+	}
+
+	if absTileset, err := filepath.Abs(tileset.Path); err == nil {
+		if absOutput, err := filepath.Abs(outputPath); err == nil {
+			if relative, err := filepath.Rel(filepath.Dir(absOutput), absTileset); err == nil {
+				tileset.Path = filepath.ToSlash(relative)
+			}
+		}
+	}
+
+	return tileset, true
+
+}
+
+// ExportTiledMap writes a serialized Map Task to a Tiled map file at the given path, choosing TMX or JSON from its extension.
+func ExportTiledMap(taskData gjson.Result, gridSize float32, path string) error {
+
+	if taskType, ok := ParseTaskType(taskData); !ok || taskType != TASK_TYPE_MAP {
+		return fmt.Errorf("only Map Tasks can be exported as Tiled maps")
+	}
+
+	area := exportContentArea(taskData, gridSize)
+	width := int(area.Width / gridSize)
+	height := int(area.Height / gridSize)
+
+	tileset, hasTileset := tiledTilesetFor(taskData, path)
+
+	if hasTileset && !tileset.ImageFound {
+		return fmt.Errorf("couldn't read the tileset image %s", taskData.Get(`MapTileset`).String())
+	}
+
+	tileSize := int(gridSize)
+	if hasTileset {
+		tileSize = tileset.TileSize
+	}
+
+	layers, err := mapLayersFromData(taskData)
+	if err != nil {
+		return err
+	}
+
+	// Layers are cropped (or padded) to the Map's size, and then flattened row by row, as Tiled stores them
+	cells := func(layer *MapLayer) []int32 {
+		data := []int32{}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				value := int32(0)
+				if y < len(layer.Data) && x < len(layer.Data[y]) {
+					value = layer.Data[y][x]
+				}
+				data = append(data, value)
+			}
+		}
+		return data
+	}
+
+	var data []byte
+
+	switch strings.ToLower(filepath.Ext(path)) {
+
+	case ".tmx":
+
+		tmx := tmxMap{
+			Version:      "1.5",
+			Orientation:  "orthogonal",
+			RenderOrder:  "right-down",
+			Width:        width,
+			Height:       height,
+			TileWidth:    tileSize,
+			TileHeight:   tileSize,
+			NextLayerID:  len(layers) + 1,
+			NextObjectID: 1,
+		}
+
+		if hasTileset {
+			tmx.Tilesets = append(tmx.Tilesets, tmxTileset{
+				FirstGID:   1,
+				Name:       strings.TrimSuffix(filepath.Base(tileset.Path), filepath.Ext(tileset.Path)),
+				TileWidth:  tileSize,
+				TileHeight: tileSize,
+				TileCount:  tileset.TileCount,
+				Columns:    tileset.Columns,
+				Image:      tmxImage{Source: tileset.Path, Width: tileset.Width, Height: tileset.Height},
+			})
+		}
+
+		for i, layer := range layers {
+
+			rows := []string{}
+			values := cells(layer)
+
+			for y := 0; y < height; y++ {
+				row := []string{}
+				for _, value := range values[y*width : (y+1)*width] {
+					row = append(row, strconv.Itoa(int(value)))
+				}
+				rows = append(rows, strings.Join(row, ","))
+			}
+
+			layerData := tmxLayer{
+				ID:     i + 1,
+				Name:   layer.Name,
+				Width:  width,
+				Height: height,
+				Data:   tmxData{Encoding: "csv", CSV: "\n" + strings.Join(rows, ",\n") + "\n"},
+			}
+
+			if !layer.Visible {
+				hidden := 0
+				layerData.Visible = &hidden
+			}
+
+			tmx.Layers = append(tmx.Layers, layerData)
+
+		}
+
+		data, err = xml.MarshalIndent(tmx, "", " ")
+		data = append([]byte(xml.Header), data...)
+
+	case ".json":
+
+		tiledMap := tiledJSONMap{
+			Type:         "map",
+			Version:      "1.5",
+			Orientation:  "orthogonal",
+			RenderOrder:  "right-down",
+			Width:        width,
+			Height:       height,
+			TileWidth:    tileSize,
+			TileHeight:   tileSize,
+			NextLayerID:  len(layers) + 1,
+			NextObjectID: 1,
+			Layers:       []tiledJSONLayer{},
+			Tilesets:     []tiledJSONTileset{},
+		}
+
+		if hasTileset {
+			tiledMap.Tilesets = append(tiledMap.Tilesets, tiledJSONTileset{
+				FirstGID:    1,
+				Name:        strings.TrimSuffix(filepath.Base(tileset.Path), filepath.Ext(tileset.Path)),
+				Image:       tileset.Path,
+				ImageWidth:  tileset.Width,
+				ImageHeight: tileset.Height,
+				TileWidth:   tileSize,
+				TileHeight:  tileSize,
+				TileCount:   tileset.TileCount,
+				Columns:     tileset.Columns,
+			})
+		}
+
+		for i, layer := range layers {
+			tiledMap.Layers = append(tiledMap.Layers, tiledJSONLayer{
+				ID:      i + 1,
+				Name:    layer.Name,
+				Type:    "tilelayer",
+				Width:   width,
+				Height:  height,
+				Opacity: 1,
+				Visible: layer.Visible,
+				Data:    cells(layer),
+			})
+		}
+
+		data, err = json.MarshalIndent(tiledMap, "", "  ")
+
+	default:
+		return fmt.Errorf("unsupported Tiled map format %s; use .tmx or .json", filepath.Ext(path))
+
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+
+}
+
+// isTiledMapPath returns if the path given is for a Tiled map, rather than an image.
+func isTiledMapPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".tmx" || ext == ".json"
+}
+
+// ExportTaskTiledMap exports a Map Task to a Tiled map file of the user's choosing.
+func (project *Project) ExportTaskTiledMap(task *Task) {
+
+	path, err := zenity.SelectFileSave(
+		zenity.Title("Select a location to export the Tiled map to."),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilters{
+			{Name: "Tiled Map", Patterns: []string{"*.tmx"}},
+			{Name: "Tiled JSON Map", Patterns: []string{"*.json"}},
+		})
+
+	if err != nil || path == "" {
+		return
+	}
+
+	if !isTiledMapPath(path) {
+		path += ".tmx"
+	}
+
+	if err := ExportTiledMap(gjson.Parse(exportTaskData(task)), float32(project.GridSize), path); err != nil {
+		project.Log("ERROR: Couldn't export Tiled map: %s", err.Error())
+	} else {
+		project.Log("Exported Tiled map to %s.", path)
+	}
+
+}
+
+// exportTaskData serializes a Task for exporting. Tilesets are saved relative to the project, so they're pointed to by
+// their full path, and tilesets downloaded from a URL point to the downloaded copy, so they can be read from disk like
+// any other.
+func exportTaskData(task *Task) string {
+
+	data := task.Serialize()
+
+	if task.MapImage != nil && task.MapImage.TilesetPath != "" {
+		if task.MapImage.Tileset != nil && task.MapImage.Tileset.LocalFilepath != "" {
+			data, _ = sjson.Set(data, `MapTileset`, task.MapImage.Tileset.LocalFilepath)
+		} else {
+			data, _ = sjson.Set(data, `MapTileset`, task.MapImage.TilesetPath)
+		}
+	}
+
+	return data
+
+}
@@ -255,10 +255,20 @@ func NewUndoState(task *Task) *UndoState {
 func (state *UndoState) Apply() {
 	ser := gjson.Parse(state.Serialized)
 	taskType, _ := ParseTaskType(ser)
+
+	// Tasks that have been sent to another Board since this state was captured go back to the Board they were on
+	if board := state.Task.Board.Project.BoardByID(ser.Get(`BoardID`).String()); board != nil && board != state.Task.Board {
+		state.Task.transferToBoard(board)
+	}
+
 	state.Task.Deserialize(ser, taskType)
 	state.Task.UndoChange = false
 	state.Task.UndoCreation = false
 	state.Task.UndoDeletion = false
+
+	if sync := state.Task.Board.Project.Sync; sync != nil {
+		sync.TaskChanged(state.Task)
+	}
 }
 
 func (state *UndoState) Exit(direction int) {
@@ -281,6 +291,10 @@ func (state *UndoState) Exit(direction int) {
 	state.Task.UndoCreation = false
 	state.Task.UndoDeletion = false
 
+	if sync := state.Task.Board.Project.Sync; sync != nil {
+		sync.TaskChanged(state.Task)
+	}
+
 }
 
 func (state *UndoState) SameAs(otherState *UndoState) bool {
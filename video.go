@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// raylib can't decode video, so Video Resources use ffmpeg (if it's installed) to find a video's length and to extract
+// single frames from it as images, which is enough to show a thumbnail and scrub through the video. Both happen in
+// the background; the results are picked up in Update(), as textures have to be loaded on the main thread.
+
+var errNoFFmpeg = errors.New("video previews need ffmpeg to be installed")
+
+type videoProbe struct {
+	Duration float32
+	Err      error
+}
+
+type videoFrame struct {
+	Path string
+	Time float32
+	Err  error
+}
+
+type Video struct {
+	Path      string
+	Duration  float32
+	Texture   rl.Texture2D
+	FrameTime float32
+	Err       error
+
+	tempDir     string
+	probed      bool
+	probes      chan videoProbe
+	frames      chan videoFrame
+	extracting  bool
+	wanted      float32
+	frameWanted bool
+	frameCount  int
+}
+
+func NewVideo(path, tempDir string) *Video {
+
+	video := &Video{
+		Path:    path,
+		tempDir: tempDir,
+		probes:  make(chan videoProbe, 1),
+		frames:  make(chan videoFrame, 1),
+	}
+
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		video.Err = errNoFFmpeg
+		video.probed = true
+		return video
+	}
+
+	go func() {
+
+		out, err := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path).Output()
+
+		probe := videoProbe{Err: err}
+
+		if err == nil {
+			duration, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 32)
+			probe.Duration = float32(duration)
+			probe.Err = err
+		}
+
+		video.probes <- probe
+
+	}()
+
+	return video
+
+}
+
+// RequestFrame asks for the frame at the given time in seconds to be shown. If a frame is already being extracted, only
+// the most recently requested frame is extracted afterwards, so scrubbing doesn't build up a queue.
+func (video *Video) RequestFrame(time float32) {
+	video.wanted = time
+	video.frameWanted = true
+}
+
+func (video *Video) Update() {
+
+	select {
+	case probe := <-video.probes:
+		video.probed = true
+		video.Duration = probe.Duration
+		if probe.Err != nil {
+			video.Err = fmt.Errorf("couldn't read video: %s", probe.Err.Error())
+		}
+	default:
+	}
+
+	select {
+	case frame := <-video.frames:
+
+		video.extracting = false
+
+		if frame.Err != nil {
+			video.Err = fmt.Errorf("couldn't extract video frame: %s", frame.Err.Error())
+		} else {
+
+			if video.Texture.ID > 0 {
+				rl.UnloadTexture(video.Texture)
+			}
+
+			video.Texture = rl.LoadTexture(frame.Path)
+			video.FrameTime = frame.Time
+			os.Remove(frame.Path)
+
+		}
+
+	default:
+	}
+
+	if video.probed && video.Err == nil && video.frameWanted && !video.extracting {
+
+		video.frameWanted = false
+		video.extracting = true
+		video.frameCount++
+
+		time := video.wanted
+		if video.Duration > 0 && time > video.Duration-0.1 {
+			time = video.Duration - 0.1 // Seeking to the very end of a video gives no frame at all
+		}
+		if time < 0 {
+			time = 0
+		}
+
+		out := filepath.Join(video.tempDir, fmt.Sprintf("video_frame_%p_%d.png", video, video.frameCount))
+
+		go func() {
+
+			err := exec.Command("ffmpeg", "-v", "error", "-ss", fmt.Sprintf("%.3f", time), "-i", video.Path,
+				"-frames:v", "1", "-vf", "scale='min(640,iw)':-2", "-y", out).Run()
+
+			if err == nil && !FileExists(out) {
+				err = errors.New("no frame at that time")
+			}
+
+			video.frames <- videoFrame{Path: out, Time: time, Err: err}
+
+		}()
+
+	}
+
+}
+
+// Ready returns if a frame of the video has been loaded to display.
+func (video *Video) Ready() bool {
+	return video.Texture.ID > 0
+}
+
+func (video *Video) Destroy() {
+	if video.Texture.ID > 0 {
+		rl.UnloadTexture(video.Texture)
+		video.Texture = rl.Texture2D{}
+	}
+}
@@ -2,122 +2,545 @@ package main
 
 import (
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
 
 	"github.com/blang/semver"
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/tidwall/gjson"
 )
 
+const (
+	WhiteboardToolPen = iota
+	WhiteboardToolEraser
+	WhiteboardToolLine
+	WhiteboardToolRectangle
+	WhiteboardToolEllipse
+	WhiteboardToolText
+	WhiteboardToolSelect
+)
+
+var whiteboardToolNames = []string{
+	"Pen",
+	"Eraser",
+	"Line",
+	"Rect",
+	"Ellipse",
+	"Text",
+	"Select",
+}
+
+const (
+	WhiteboardStrokePath      = "path"
+	WhiteboardStrokeLine      = "line"
+	WhiteboardStrokeRectangle = "rect"
+	WhiteboardStrokeEllipse   = "ellipse"
+	WhiteboardStrokeText      = "text"
+)
+
+// WhiteboardVersion is the version of the serialized Whiteboard format; version 1 was the old monochrome bitmap, stored
+// as an array of base64-encoded rows, and version 2 stored stroke points as arrays of JSON objects, rather than packed.
+const WhiteboardVersion = 3
+
+// whiteboardPalette is the set of colors that Whiteboard strokes can be drawn in. The first entry is a placeholder for
+// the theme's ink color (the font color), so that, like the old monochrome whiteboards, default strokes follow the theme.
+var whiteboardPalette = []rl.Color{
+	{},
+	{224, 64, 64, 255},
+	{240, 152, 48, 255},
+	{232, 208, 64, 255},
+	{80, 184, 80, 255},
+	{64, 144, 232, 255},
+	{160, 96, 224, 255},
+	{255, 255, 255, 255},
+	{0, 0, 0, 255},
+}
+
+var whiteboardPenSizes = []float32{1, 3, 8}
+var whiteboardTextSizes = []float32{12, 18, 28}
+
+// WhiteboardStroke is a single vector element on a Whiteboard. Points are relative to the top-left corner of the
+// drawing area; paths use all of them, while lines, rectangles and ellipses use the first two as opposite corners,
+// and text uses the first as the top-left of the label. For text, Width is the font size.
+type WhiteboardStroke struct {
+	Kind   string
+	Points []rl.Vector2
+	Color  int
+	Width  float32
+	Text   string `json:",omitempty"`
+}
+
+// packedWhiteboardStroke is how a stroke is serialized, with its Points packed into a string (see packPoints()).
+type packedWhiteboardStroke struct {
+	Kind   string
+	Points string
+	Color  int
+	Width  float32
+	Text   string `json:",omitempty"`
+}
+
+// legacyWhiteboardStroke is how a stroke was serialized in version 2 Whiteboards, with its Points as an array of points;
+// having none of WhiteboardStroke's methods, it's decoded as it is by default.
+type legacyWhiteboardStroke WhiteboardStroke
+
+func (stroke *WhiteboardStroke) MarshalJSON() ([]byte, error) {
+	return json.Marshal(packedWhiteboardStroke{
+		Kind:   stroke.Kind,
+		Points: packPoints(stroke.Points),
+		Color:  stroke.Color,
+		Width:  stroke.Width,
+		Text:   stroke.Text,
+	})
+}
+
+func (stroke *WhiteboardStroke) UnmarshalJSON(data []byte) error {
+
+	packed := packedWhiteboardStroke{}
+
+	if err := json.Unmarshal(data, &packed); err != nil {
+		return err
+	}
+
+	stroke.Kind = packed.Kind
+	stroke.Color = packed.Color
+	stroke.Width = packed.Width
+	stroke.Text = packed.Text
+
+	points, err := unpackPoints(packed.Points)
+	if err != nil {
+		return err
+	}
+
+	stroke.Points = points
+	return nil
+
+}
+
+func (stroke *WhiteboardStroke) Clone() *WhiteboardStroke {
+	newStroke := *stroke
+	newStroke.Points = append([]rl.Vector2{}, stroke.Points...)
+	return &newStroke
+}
+
+// Segments returns the stroke's outline as a series of connected points.
+func (stroke *WhiteboardStroke) Segments() []rl.Vector2 {
+
+	if len(stroke.Points) < 2 || stroke.Kind == WhiteboardStrokePath || stroke.Kind == WhiteboardStrokeLine {
+		return stroke.Points
+	}
+
+	start, end := stroke.Points[0], stroke.Points[1]
+
+	if stroke.Kind == WhiteboardStrokeRectangle {
+		return []rl.Vector2{start, {end.X, start.Y}, end, {start.X, end.Y}, start}
+	} else if stroke.Kind == WhiteboardStrokeEllipse {
+
+		center := rl.Vector2{(start.X + end.X) / 2, (start.Y + end.Y) / 2}
+		radius := rl.Vector2{float32(math.Abs(float64(end.X-start.X))) / 2, float32(math.Abs(float64(end.Y-start.Y))) / 2}
+
+		points := []rl.Vector2{}
+		steps := 48
+		for i := 0; i <= steps; i++ {
+			angle := float64(i) / float64(steps) * math.Pi * 2
+			points = append(points, rl.Vector2{center.X + float32(math.Cos(angle))*radius.X, center.Y + float32(math.Sin(angle))*radius.Y})
+		}
+		return points
+
+	}
+
+	return stroke.Points
+
+}
+
+// Bounds returns the rectangle the stroke covers, not counting its width.
+func (stroke *WhiteboardStroke) Bounds() rl.Rectangle {
+
+	if len(stroke.Points) == 0 {
+		return rl.Rectangle{}
+	}
+
+	if stroke.Kind == WhiteboardStrokeText {
+		size := rl.MeasureTextEx(font, stroke.Text, stroke.Width, spacing)
+		return rl.Rectangle{stroke.Points[0].X, stroke.Points[0].Y, size.X, size.Y}
+	}
+
+	min := stroke.Points[0]
+	max := stroke.Points[0]
+
+	for _, p := range stroke.Points {
+		min.X = float32(math.Min(float64(min.X), float64(p.X)))
+		min.Y = float32(math.Min(float64(min.Y), float64(p.Y)))
+		max.X = float32(math.Max(float64(max.X), float64(p.X)))
+		max.Y = float32(math.Max(float64(max.Y), float64(p.Y)))
+	}
+
+	return rl.Rectangle{min.X, min.Y, max.X - min.X, max.Y - min.Y}
+
+}
+
+// Hit returns if the given point (relative to the Whiteboard) is within margin pixels of the stroke.
+func (stroke *WhiteboardStroke) Hit(point rl.Vector2, margin float32) bool {
+
+	if stroke.Kind == WhiteboardStrokeText {
+		bounds := stroke.Bounds()
+		bounds.X -= margin
+		bounds.Y -= margin
+		bounds.Width += margin * 2
+		bounds.Height += margin * 2
+		return rl.CheckCollisionPointRec(point, bounds)
+	}
+
+	margin += stroke.Width / 2
+
+	segments := stroke.Segments()
+
+	if len(segments) == 1 {
+		return rl.Vector2Distance(point, segments[0]) <= margin
+	}
+
+	for i := 0; i < len(segments)-1; i++ {
+		if distanceToSegment(point, segments[i], segments[i+1]) <= margin {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func (stroke *WhiteboardStroke) Move(dx, dy float32) {
+	for i := range stroke.Points {
+		stroke.Points[i].X += dx
+		stroke.Points[i].Y += dy
+	}
+}
+
+func distanceToSegment(point, start, end rl.Vector2) float32 {
+
+	diff := rl.Vector2Subtract(end, start)
+	lengthSquared := diff.X*diff.X + diff.Y*diff.Y
+
+	if lengthSquared == 0 {
+		return rl.Vector2Distance(point, start)
+	}
+
+	t := ((point.X-start.X)*diff.X + (point.Y-start.Y)*diff.Y) / lengthSquared
+
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+
+	return rl.Vector2Distance(point, rl.Vector2{start.X + diff.X*t, start.Y + diff.Y*t})
+
+}
+
 type Whiteboard struct {
-	Cursor       *rl.Image
-	Task         *Task
-	Texture      rl.RenderTexture2D
-	Editing      bool
-	Width        int32
-	Height       int32
-	PrevClickPos rl.Vector2
-	CursorSize   int
-	Colors       []rl.Color
+	Task       *Task
+	Editing    bool
+	Width      int32
+	Height     int32
+	CursorSize int
+	Tool       int
+	Color      int
+	Inverted   bool
+	Strokes    []*WhiteboardStroke
+	Selection  []*WhiteboardStroke
+	Label      *WhiteboardStroke // The text stroke currently being edited through the label popup
+
+	drawing      *WhiteboardStroke
+	dragStart    rl.Vector2
+	dragging     bool
+	boxSelecting bool
+	erased       bool
 }
 
 func NewWhiteboard(task *Task) *Whiteboard {
 
 	wb := &Whiteboard{
-		Task:         task,
-		PrevClickPos: rl.Vector2{-1, -1},
+		Task: task,
 	}
 
-	wb.SetColors()
+	wb.Resize(0, 0) // Set the initial size; by default, it'll be the minimum size.
 
-	wb.Resize(0, 0) // Set the size of the initial texture; by default, it'll be the minimum size.
+	return wb
+}
 
-	wb.Draw()
+// StrokeColor returns the color used to draw the palette entry given.
+func (whiteboard *Whiteboard) StrokeColor(index int) rl.Color {
+
+	if index <= 0 || index >= len(whiteboardPalette) {
+		if whiteboard.Inverted {
+			return getThemeColor(GUI_INSIDE)
+		}
+		return getThemeColor(GUI_FONT_COLOR)
+	}
+
+	return whiteboardPalette[index]
+
+}
+
+// BackgroundColor returns the color of the Whiteboard's drawing area.
+func (whiteboard *Whiteboard) BackgroundColor() rl.Color {
+	if whiteboard.Inverted {
+		return getThemeColor(GUI_FONT_COLOR)
+	}
+	return getThemeColor(GUI_INSIDE)
+}
+
+// Origin returns the world position of the top-left corner of the drawing area.
+func (whiteboard *Whiteboard) Origin() rl.Vector2 {
+	return rl.Vector2{whiteboard.Task.Rect.X, whiteboard.Task.Rect.Y + 16}
+}
+
+// LocalMousePosition returns the mouse position relative to the drawing area, and whether it's within the drawing area.
+func (whiteboard *Whiteboard) LocalMousePosition() (rl.Vector2, bool) {
+
+	origin := whiteboard.Origin()
+	mp := rl.Vector2Subtract(GetWorldMousePosition(), origin)
+
+	inside := mp.X >= 0 && mp.X < float32(whiteboard.Width) && mp.Y >= 0 && mp.Y < float32(whiteboard.Height)
+
+	mp.X = float32(math.Max(0, math.Min(float64(mp.X), float64(whiteboard.Width))))
+	mp.Y = float32(math.Max(0, math.Min(float64(mp.Y), float64(whiteboard.Height))))
+
+	return mp, inside
+
+}
+
+// Render draws the Whiteboard's background and strokes directly in world space, so that they stay crisp at any zoom level.
+func (whiteboard *Whiteboard) Render() {
+
+	origin := whiteboard.Origin()
+
+	rl.DrawRectangleRec(rl.Rectangle{origin.X + 1, origin.Y + 1, float32(whiteboard.Width) - 2, float32(whiteboard.Height) - 2}, whiteboard.BackgroundColor())
+
+	topLeft := rl.GetWorldToScreen2D(origin, camera)
+	bottomRight := rl.GetWorldToScreen2D(rl.Vector2{origin.X + float32(whiteboard.Width), origin.Y + float32(whiteboard.Height)}, camera)
+
+	rl.BeginScissorMode(int32(topLeft.X), int32(topLeft.Y), int32(bottomRight.X-topLeft.X), int32(bottomRight.Y-topLeft.Y))
+
+	strokes := whiteboard.Strokes
+	if whiteboard.drawing != nil {
+		strokes = append(append([]*WhiteboardStroke{}, strokes...), whiteboard.drawing)
+	}
+
+	for _, stroke := range strokes {
+		whiteboard.renderStroke(stroke, origin, whiteboard.StrokeColor(stroke.Color))
+	}
+
+	if whiteboard.Editing && whiteboard.Task.Selected {
+
+		highlight := getThemeColor(GUI_OUTLINE_HIGHLIGHTED)
+
+		for _, stroke := range whiteboard.Selection {
+			bounds := stroke.Bounds()
+			pad := stroke.Width/2 + 2
+			if stroke.Kind == WhiteboardStrokeText {
+				pad = 2
+			}
+			bounds.X += origin.X - pad
+			bounds.Y += origin.Y - pad
+			bounds.Width += pad * 2
+			bounds.Height += pad * 2
+			rl.DrawRectangleLinesEx(bounds, 1, highlight)
+		}
+
+		if whiteboard.boxSelecting {
+			mp, _ := whiteboard.LocalMousePosition()
+			box := rectFromPoints(whiteboard.dragStart, mp)
+			box.X += origin.X
+			box.Y += origin.Y
+			rl.DrawRectangleLinesEx(box, 1, highlight)
+		}
+
+		if mp, inside := whiteboard.LocalMousePosition(); inside && whiteboard.Tool == WhiteboardToolEraser {
+			rl.DrawCircleLines(int32(origin.X+mp.X), int32(origin.Y+mp.Y), whiteboard.eraserSize(), highlight)
+		}
+
+	}
+
+	rl.EndScissorMode()
 
-	return wb
+}
+
+func (whiteboard *Whiteboard) renderStroke(stroke *WhiteboardStroke, origin rl.Vector2, color rl.Color) {
+
+	if len(stroke.Points) == 0 {
+		return
+	}
+
+	if stroke.Kind == WhiteboardStrokeText {
+		rl.DrawTextEx(font, stroke.Text, rl.Vector2Add(origin, stroke.Points[0]), stroke.Width, spacing, color)
+		return
+	}
+
+	segments := stroke.Segments()
+
+	for i := 0; i < len(segments)-1; i++ {
+		rl.DrawLineEx(rl.Vector2Add(origin, segments[i]), rl.Vector2Add(origin, segments[i+1]), stroke.Width, color)
+	}
+
+	// Round off the joints of freehand paths so thick strokes don't look segmented.
+	if stroke.Kind == WhiteboardStrokePath && stroke.Width > 1 {
+		for _, point := range segments {
+			rl.DrawCircleV(rl.Vector2Add(origin, point), stroke.Width/2, color)
+		}
+	} else if len(segments) == 1 {
+		rl.DrawCircleV(rl.Vector2Add(origin, segments[0]), stroke.Width/2, color)
+	}
+
+}
+
+func (whiteboard *Whiteboard) eraserSize() float32 {
+	return whiteboardPenSizes[whiteboard.CursorSize] + 3
 }
 
 func (whiteboard *Whiteboard) Draw() {
 
-	clickPos := rl.Vector2{-1, -1}
+	project := whiteboard.Task.Board.Project
 
-	if whiteboard.Task.Board.Project.ProjectSettingsOpen {
+	if project.ProjectSettingsOpen {
 		whiteboard.Editing = false
 	}
 
+	if whiteboard.CursorSize >= len(whiteboardPenSizes) {
+		whiteboard.CursorSize = 0
+	}
+
 	makeUndo := false
 
-	if whiteboard.Editing && whiteboard.Task.Selected {
+	if whiteboard.Editing && whiteboard.Task.Selected && project.PopupAction == "" {
+
+		mp, inside := whiteboard.LocalMousePosition()
 
-		rect := rl.Rectangle{whiteboard.Task.Rect.X, whiteboard.Task.Rect.Y, 16, 16}
+		if MousePressed(rl.MouseLeftButton) && inside {
 
-		mousePos := GetWorldMousePosition()
-		mousePos.Y -= rect.Height
+			whiteboard.dragStart = mp
 
-		cx := int32(mousePos.X - rect.X)
-		cy := int32(mousePos.Y - rect.Y)
-		color := whiteboard.Colors[1]
+			switch whiteboard.Tool {
 
-		if cx >= 0 && cx <= whiteboard.Width-1 && cy >= 0 && cy <= whiteboard.Height-1 {
+			case WhiteboardToolPen:
+				whiteboard.drawing = &WhiteboardStroke{Kind: WhiteboardStrokePath, Points: []rl.Vector2{mp}, Color: whiteboard.Color, Width: whiteboardPenSizes[whiteboard.CursorSize]}
 
-			if MouseDown(rl.MouseLeftButton) {
-				clickPos.X = float32(cx)
-				clickPos.Y = float32(cy)
+			case WhiteboardToolLine, WhiteboardToolRectangle, WhiteboardToolEllipse:
+				kind := WhiteboardStrokeLine
+				if whiteboard.Tool == WhiteboardToolRectangle {
+					kind = WhiteboardStrokeRectangle
+				} else if whiteboard.Tool == WhiteboardToolEllipse {
+					kind = WhiteboardStrokeEllipse
+				}
+				whiteboard.drawing = &WhiteboardStroke{Kind: kind, Points: []rl.Vector2{mp, mp}, Color: whiteboard.Color, Width: whiteboardPenSizes[whiteboard.CursorSize]}
+
+			case WhiteboardToolText:
+				if hit := whiteboard.StrokeAt(mp); hit != nil && hit.Kind == WhiteboardStrokeText {
+					whiteboard.Label = hit
+				} else {
+					whiteboard.Label = &WhiteboardStroke{Kind: WhiteboardStrokeText, Points: []rl.Vector2{mp}, Color: whiteboard.Color, Width: whiteboardTextSizes[whiteboard.CursorSize]}
+				}
+				project.PopupAction = ActionWhiteboardLabel
+				project.PopupArgument = whiteboard.Label.Text
+				if project.PopupArgument == "" {
+					project.PopupArgument = "Label"
+				}
+				project.WhiteboardLabelTarget = whiteboard
+
+			case WhiteboardToolSelect:
+				if hit := whiteboard.StrokeAt(mp); hit != nil {
+					if !whiteboard.IsSelected(hit) {
+						whiteboard.Selection = []*WhiteboardStroke{hit}
+					}
+					whiteboard.dragging = true
+				} else {
+					whiteboard.Selection = []*WhiteboardStroke{}
+					whiteboard.boxSelecting = true
+				}
 
-			} else if MouseDown(rl.MouseRightButton) || MouseReleased(rl.MouseRightButton) {
-				// This if statement has to have MouseReleased too because right click opens the menu
-				// And by ensuring this runs on release of right click, we can consume the input below
-				clickPos.X = float32(cx)
-				clickPos.Y = float32(cy)
-				color = whiteboard.Colors[0]
 			}
 
 		}
 
-		if MouseReleased(rl.MouseLeftButton) || MouseReleased(rl.MouseRightButton) {
-			makeUndo = true
-		}
+		if MouseDown(rl.MouseLeftButton) {
 
-		if clickPos.X >= 0 && clickPos.Y >= 0 {
+			if whiteboard.drawing != nil {
 
-			rl.EndMode2D()
+				if whiteboard.drawing.Kind == WhiteboardStrokePath {
+					last := whiteboard.drawing.Points[len(whiteboard.drawing.Points)-1]
+					if rl.Vector2Distance(last, mp) >= 1.5 {
+						whiteboard.drawing.Points = append(whiteboard.drawing.Points, mp)
+					}
+				} else {
+					whiteboard.drawing.Points[1] = mp
+				}
 
-			rl.BeginTextureMode(whiteboard.Texture)
+			} else if whiteboard.Tool == WhiteboardToolEraser && inside {
 
-			var cursorSizes = []float32{
-				1,
-				3,
-				8,
-			}
+				if whiteboard.EraseAt(mp, whiteboard.eraserSize()) {
+					whiteboard.erased = true
+				}
 
-			cursorSize := cursorSizes[whiteboard.CursorSize]
+			} else if whiteboard.dragging {
 
-			if whiteboard.PrevClickPos.X < 0 && whiteboard.PrevClickPos.Y < 0 {
-				rl.DrawCircleV(clickPos, cursorSize, color)
-			} else {
-				diff := rl.Vector2Subtract(whiteboard.PrevClickPos, clickPos)
-				length := rl.Vector2Length(diff) + 1
-				start := clickPos
-
-				rl.DrawCircleV(start, cursorSize, color)
-
-				for i := 0; i < int(length); i++ {
-					d := diff
-					d.X /= length
-					d.Y /= length
-					rl.DrawCircleV(start, cursorSize, color)
-					start = rl.Vector2Add(start, d)
+				dx, dy := whiteboard.clampSelectionMove(mp.X-whiteboard.dragStart.X, mp.Y-whiteboard.dragStart.Y)
+				for _, stroke := range whiteboard.Selection {
+					stroke.Move(dx, dy)
+				}
+				whiteboard.dragStart.X += dx
+				whiteboard.dragStart.Y += dy
+				if dx != 0 || dy != 0 {
+					whiteboard.erased = true // Reused to mark that something changed over the course of the drag
 				}
 
 			}
 
-			rl.EndTextureMode()
+		}
 
-			rl.BeginMode2D(camera) // We have to call BeginMode2D again because BeginTextureMode modifies the OpenGL view matrix to render at a "GUI" level
-			// And we're not in the GUI, but drawing "into" the world here
+		if MouseReleased(rl.MouseLeftButton) {
 
-			if MouseReleased(rl.MouseRightButton) {
-				ConsumeMouseInput(rl.MouseRightButton)
+			if whiteboard.drawing != nil {
+				bounds := whiteboard.drawing.Bounds()
+				if whiteboard.drawing.Kind == WhiteboardStrokePath || bounds.Width > 0 || bounds.Height > 0 {
+					whiteboard.Strokes = append(whiteboard.Strokes, whiteboard.drawing)
+					makeUndo = true
+				}
+				whiteboard.drawing = nil
+			}
+
+			if whiteboard.boxSelecting {
+				box := rectFromPoints(whiteboard.dragStart, mp)
+				for _, stroke := range whiteboard.Strokes {
+					bounds := stroke.Bounds()
+					bounds.Width = float32(math.Max(1, float64(bounds.Width)))
+					bounds.Height = float32(math.Max(1, float64(bounds.Height)))
+					if rl.CheckCollisionRecs(box, bounds) {
+						whiteboard.Selection = append(whiteboard.Selection, stroke)
+					}
+				}
 			}
 
+			if whiteboard.erased {
+				makeUndo = true
+			}
+
+			whiteboard.erased = false
+			whiteboard.dragging = false
+			whiteboard.boxSelecting = false
+
+		}
+
+		// Right-clicking a stroke erases it (or the whole selection, if it's part of it), whichever tool is in use.
+		if inside && MouseReleased(rl.MouseRightButton) {
+			if hit := whiteboard.StrokeAt(mp); hit != nil {
+				if whiteboard.IsSelected(hit) {
+					whiteboard.DeleteSelection()
+				} else {
+					whiteboard.RemoveStroke(hit)
+				}
+				makeUndo = true
+			}
+			ConsumeMouseInput(rl.MouseRightButton)
 		}
 
 	}
@@ -126,10 +549,12 @@ func (whiteboard *Whiteboard) Draw() {
 
 	if whiteboard.Task.Selected {
 
+		x := whiteboard.Task.Rect.X + 16
+
 		if whiteboard.Editing {
-			editButton = whiteboard.Task.SmallButton(32, 32, 16, 16, whiteboard.Task.Rect.X+16, whiteboard.Task.Rect.Y)
+			editButton = whiteboard.Task.SmallButton(32, 32, 16, 16, x, whiteboard.Task.Rect.Y)
 		} else {
-			editButton = whiteboard.Task.SmallButton(16, 32, 16, 16, whiteboard.Task.Rect.X+16, whiteboard.Task.Rect.Y)
+			editButton = whiteboard.Task.SmallButton(16, 32, 16, 16, x, whiteboard.Task.Rect.Y)
 		}
 
 		if editButton || programSettings.Keybindings.On(KBPencilTool) || (whiteboard.Editing && !whiteboard.Task.Selected) {
@@ -137,26 +562,61 @@ func (whiteboard *Whiteboard) Draw() {
 			ConsumeMouseInput(rl.MouseLeftButton)
 		}
 
-		cursorSrcX := []float32{
-			176,
-			192,
-			208,
-		}
+		if whiteboard.Editing {
 
-		if whiteboard.CursorSize >= len(cursorSrcX) {
-			whiteboard.CursorSize = 0
-		}
+			cursorSrcX := []float32{
+				176,
+				192,
+				208,
+			}
+
+			if programSettings.Keybindings.On(KBChangePencilToolSize) || whiteboard.Task.SmallButton(cursorSrcX[whiteboard.CursorSize], 48, 16, 16, x+16, whiteboard.Task.Rect.Y) {
+				whiteboard.CursorSize = (whiteboard.CursorSize + 1) % len(whiteboardPenSizes)
+				ConsumeMouseInput(rl.MouseLeftButton)
+			}
+
+			// Color swatch; clicking cycles through the palette, and the selection (if any) takes on the new color.
+			swatch := rl.Rectangle{x + 36, whiteboard.Task.Rect.Y + 4, 8, 8}
+			rl.DrawRectangleRec(swatch, whiteboard.StrokeColor(whiteboard.Color))
+			rl.DrawRectangleLinesEx(swatch, 1, getThemeColor(GUI_OUTLINE_HIGHLIGHTED))
+
+			if programSettings.Keybindings.On(KBWhiteboardNextColor) || whiteboard.headerButton(rl.Rectangle{x + 32, whiteboard.Task.Rect.Y, 16, 16}) {
+				whiteboard.Color = (whiteboard.Color + 1) % len(whiteboardPalette)
+				if len(whiteboard.Selection) > 0 {
+					for _, stroke := range whiteboard.Selection {
+						stroke.Color = whiteboard.Color
+					}
+					makeUndo = true
+				}
+				ConsumeMouseInput(rl.MouseLeftButton)
+			}
+
+			// Tool name; clicking cycles through the tools.
+			toolName := whiteboardToolNames[whiteboard.Tool]
+			toolRect := rl.Rectangle{x + 50, whiteboard.Task.Rect.Y, rl.MeasureTextEx(font, toolName, float32(programSettings.FontSize)*0.75, spacing).X + 4, 16}
+			toolColor := getThemeColor(GUI_FONT_COLOR)
+			if rl.CheckCollisionPointRec(GetWorldMousePosition(), toolRect) {
+				toolColor = getThemeColor(GUI_INSIDE_DISABLED)
+			}
+			DrawTextColoredScale(rl.Vector2{toolRect.X + 2, toolRect.Y + 2}, toolColor, toolName, 0.75)
+
+			if programSettings.Keybindings.On(KBWhiteboardNextTool) || whiteboard.headerButton(toolRect) {
+				whiteboard.SetTool((whiteboard.Tool + 1) % len(whiteboardToolNames))
+				ConsumeMouseInput(rl.MouseLeftButton)
+			}
 
-		if whiteboard.Editing && (programSettings.Keybindings.On(KBChangePencilToolSize) || whiteboard.Task.SmallButton(cursorSrcX[whiteboard.CursorSize], 48, 16, 16, whiteboard.Task.Rect.X+32, whiteboard.Task.Rect.Y)) {
-			whiteboard.CursorSize++
-			ConsumeMouseInput(rl.MouseLeftButton)
 		}
 
 	} else {
 		whiteboard.Editing = false
 	}
 
-	whiteboard.PrevClickPos = clickPos
+	if !whiteboard.Editing {
+		whiteboard.drawing = nil
+		whiteboard.dragging = false
+		whiteboard.boxSelecting = false
+		whiteboard.Selection = []*WhiteboardStroke{}
+	}
 
 	if makeUndo {
 		whiteboard.Task.UndoChange = true
@@ -164,13 +624,128 @@ func (whiteboard *Whiteboard) Draw() {
 
 }
 
+func (whiteboard *Whiteboard) headerButton(rect rl.Rectangle) bool {
+	return whiteboard.Task.Selected && MousePressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(GetWorldMousePosition(), rect)
+}
+
 func (whiteboard *Whiteboard) ToggleEditing() {
 	whiteboard.Editing = !whiteboard.Editing
 }
 
-func (whiteboard *Whiteboard) Resize(w, h float32) {
+func (whiteboard *Whiteboard) SetTool(tool int) {
+	whiteboard.Tool = tool
+	whiteboard.drawing = nil
+	if tool != WhiteboardToolSelect {
+		whiteboard.Selection = []*WhiteboardStroke{}
+	}
+}
+
+// StrokeAt returns the topmost stroke at the given point (relative to the drawing area), or nil if there isn't one.
+func (whiteboard *Whiteboard) StrokeAt(point rl.Vector2) *WhiteboardStroke {
+	for i := len(whiteboard.Strokes) - 1; i >= 0; i-- {
+		if whiteboard.Strokes[i].Hit(point, 2) {
+			return whiteboard.Strokes[i]
+		}
+	}
+	return nil
+}
+
+// EraseAt removes any strokes within radius of the given point, returning true if any were removed.
+func (whiteboard *Whiteboard) EraseAt(point rl.Vector2, radius float32) bool {
+
+	remaining := []*WhiteboardStroke{}
+
+	for _, stroke := range whiteboard.Strokes {
+		if !stroke.Hit(point, radius) {
+			remaining = append(remaining, stroke)
+		}
+	}
+
+	erased := len(remaining) != len(whiteboard.Strokes)
+	whiteboard.Strokes = remaining
+	return erased
+
+}
+
+func (whiteboard *Whiteboard) IsSelected(stroke *WhiteboardStroke) bool {
+	for _, s := range whiteboard.Selection {
+		if s == stroke {
+			return true
+		}
+	}
+	return false
+}
+
+func (whiteboard *Whiteboard) RemoveStroke(stroke *WhiteboardStroke) {
+	for i, s := range whiteboard.Strokes {
+		if s == stroke {
+			whiteboard.Strokes = append(whiteboard.Strokes[:i], whiteboard.Strokes[i+1:]...)
+			break
+		}
+	}
+}
+
+func (whiteboard *Whiteboard) DeleteSelection() {
+	for _, stroke := range whiteboard.Selection {
+		whiteboard.RemoveStroke(stroke)
+	}
+	whiteboard.Selection = []*WhiteboardStroke{}
+}
+
+// SetLabel finishes editing the label started with the Text tool; an empty label is removed.
+func (whiteboard *Whiteboard) SetLabel(text string) {
+
+	if whiteboard.Label == nil {
+		return
+	}
+
+	whiteboard.RemoveStroke(whiteboard.Label)
+
+	if text != "" {
+		whiteboard.Label.Text = text
+		whiteboard.Strokes = append(whiteboard.Strokes, whiteboard.Label)
+	}
+
+	whiteboard.Label = nil
+	whiteboard.Task.UndoChange = true
+
+}
+
+// clampSelectionMove limits a move of the selected strokes so that they stay within the drawing area.
+func (whiteboard *Whiteboard) clampSelectionMove(dx, dy float32) (float32, float32) {
+
+	for _, stroke := range whiteboard.Selection {
+
+		bounds := stroke.Bounds()
+
+		if bounds.X+dx < 0 {
+			dx = -bounds.X
+		} else if bounds.X+bounds.Width+dx > float32(whiteboard.Width) {
+			dx = float32(whiteboard.Width) - bounds.X - bounds.Width
+		}
+
+		if bounds.Y+dy < 0 {
+			dy = -bounds.Y
+		} else if bounds.Y+bounds.Height+dy > float32(whiteboard.Height) {
+			dy = float32(whiteboard.Height) - bounds.Y - bounds.Height
+		}
+
+	}
 
-	ogW, ogH := whiteboard.Width, whiteboard.Height
+	return dx, dy
+
+}
+
+func rectFromPoints(a, b rl.Vector2) rl.Rectangle {
+	return rl.Rectangle{
+		float32(math.Min(float64(a.X), float64(b.X))),
+		float32(math.Min(float64(a.Y), float64(b.Y))),
+		float32(math.Abs(float64(b.X - a.X))),
+		float32(math.Abs(float64(b.Y - a.Y))),
+	}
+}
+
+func (whiteboard *Whiteboard) Resize(w, h float32) {
 
 	project := whiteboard.Task.Board.Project
 
@@ -191,171 +766,198 @@ func (whiteboard *Whiteboard) Resize(w, h float32) {
 		whiteboard.Height = 512
 	}
 
-	if ogW != whiteboard.Width || ogH != whiteboard.Height {
-		whiteboard.RecreateTexture()
-	}
-
 }
 
-func (whiteboard *Whiteboard) RecreateTexture() {
+func (whiteboard *Whiteboard) Copy(other *Whiteboard) {
 
-	newTex := rl.LoadRenderTexture(whiteboard.Width, whiteboard.Height)
+	whiteboard.Resize(float32(other.Width), float32(other.Height))
+	whiteboard.Inverted = other.Inverted
+	whiteboard.Strokes = []*WhiteboardStroke{}
+	for _, stroke := range other.Strokes {
+		whiteboard.Strokes = append(whiteboard.Strokes, stroke.Clone())
+	}
 
-	rl.EndMode2D()
+}
 
-	rl.BeginTextureMode(newTex)
+func (whiteboard *Whiteboard) Clear() {
+	whiteboard.Strokes = []*WhiteboardStroke{}
+	whiteboard.Selection = []*WhiteboardStroke{}
+	whiteboard.Inverted = false
+	whiteboard.Task.UndoChange = true
+}
 
-	rl.DrawRectangle(0, 0, whiteboard.Width, whiteboard.Height, whiteboard.Colors[0])
+// Invert swaps the Whiteboard's background color with the theme's ink color.
+func (whiteboard *Whiteboard) Invert() {
+	whiteboard.Inverted = !whiteboard.Inverted
+	whiteboard.Task.UndoChange = true
+}
 
-	if whiteboard.Texture.ID > 0 {
-		src := rl.Rectangle{0, 0, float32(whiteboard.Texture.Texture.Width), -float32(whiteboard.Texture.Texture.Height)}
-		dst := rl.Rectangle{0, 0, float32(whiteboard.Texture.Texture.Width), float32(whiteboard.Texture.Texture.Height)}
-		rl.DrawTexturePro(whiteboard.Texture.Texture, src, dst, rl.Vector2{}, 0, rl.White)
-	}
+type serializedWhiteboard struct {
+	Version  int
+	Inverted bool `json:",omitempty"`
+	Strokes  []*WhiteboardStroke
+}
 
-	rl.EndTextureMode()
+// Serialize returns the Whiteboard's strokes as a JSON object.
+func (whiteboard *Whiteboard) Serialize() string {
 
-	rl.BeginMode2D(camera)
+	data, _ := json.Marshal(serializedWhiteboard{
+		Version:  WhiteboardVersion,
+		Inverted: whiteboard.Inverted,
+		Strokes:  whiteboard.Strokes,
+	})
 
-	whiteboard.Texture = newTex
+	return string(data)
 
 }
 
-func (whiteboard *Whiteboard) Copy(other *Whiteboard) {
+func (whiteboard *Whiteboard) Deserialize(data gjson.Result) {
 
-	whiteboard.Resize(float32(other.Width), float32(other.Height))
-	rl.BeginTextureMode(whiteboard.Texture)
-	if other.Texture.ID > 0 {
-		src := rl.Rectangle{0, 0, float32(whiteboard.Texture.Texture.Width), -float32(whiteboard.Texture.Texture.Height)}
-		dst := rl.Rectangle{0, 0, float32(whiteboard.Texture.Texture.Width), float32(whiteboard.Texture.Texture.Height)}
-		rl.DrawTexturePro(other.Texture.Texture, src, dst, rl.Vector2{}, 0, rl.White)
-	}
-	rl.EndTextureMode()
+	whiteboard.Selection = []*WhiteboardStroke{}
+	whiteboard.drawing = nil
 
-}
+	project := whiteboard.Task.Board.Project
 
-func (whiteboard *Whiteboard) Clear() {
+	// Older plans stored whiteboards at half resolution, as they were "doubly thick"
+	doubled := project.Loading && project.LoadingVersion.LTE(semver.MustParse("0.6.1-3"))
 
-	// Because this is called from a GUI element, changing the 2D mode isn't necessary
-	// rl.EndMode2D()
-	rl.BeginTextureMode(whiteboard.Texture)
-	rl.DrawRectangle(0, 0, whiteboard.Texture.Texture.Width, whiteboard.Texture.Texture.Height, whiteboard.Colors[0])
-	rl.EndTextureMode()
-	whiteboard.Task.UndoChange = true
-	// rl.BeginMode2D(camera)
+	wb, err := decodeWhiteboard(data, doubled)
 
-}
+	if err != nil {
+		project.Log("ERROR: Couldn't load whiteboard data: %s", err.Error())
+		return
+	}
 
-// Invert replaces the light color with the dark color. Note that this is SUPER HACKY AS THIS IS JUST A "REVERSED" DESERIALIZE() FOR NOW
-func (whiteboard *Whiteboard) Invert() {
+	whiteboard.Inverted = wb.Inverted
+	whiteboard.Strokes = wb.Strokes
 
-	data := whiteboard.Serialize()
+}
 
-	colors := []rl.Color{}
+// decodeWhiteboard parses serialized Whiteboard data, converting it from the old bitmap format if necessary.
+func decodeWhiteboard(data gjson.Result, doubled bool) (*serializedWhiteboard, error) {
 
-	for i := len(data) - 1; i >= 0; i-- {
-		ogData, _ := base64.StdEncoding.DecodeString(data[i])
-		for _, value := range ogData {
-			if value == 1 {
-				colors = append(colors, whiteboard.Colors[0])
-			} else if value == 0 {
-				colors = append(colors, whiteboard.Colors[1])
-			}
+	wb := &serializedWhiteboard{Version: WhiteboardVersion}
 
+	if data.IsArray() {
+		rows := []string{}
+		for _, row := range data.Array() {
+			rows = append(rows, row.String())
 		}
-
+		wb.Strokes = whiteboardStrokesFromBitmap(rows, doubled)
+		return wb, nil
 	}
 
-	rl.UpdateTexture(whiteboard.Texture.Texture, colors)
+	version := data.Get(`Version`).Int()
 
-	whiteboard.Task.UndoChange = true
+	if version > WhiteboardVersion {
+		return nil, fmt.Errorf("the Whiteboard was saved in a newer format (%d) than this version of MasterPlan can read", version)
+	} else if version < 3 {
 
-}
-
-func (whiteboard *Whiteboard) Serialize() []string {
+		legacy := struct {
+			Inverted bool
+			Strokes  []*legacyWhiteboardStroke
+		}{}
 
-	data := []string{}
+		if err := json.Unmarshal([]byte(data.Raw), &legacy); err != nil {
+			return nil, err
+		}
 
-	imgData := rl.GetTextureData(whiteboard.Texture.Texture)
-	rl.ImageFlipVertical(imgData)
-	colors := rl.GetImageData(imgData)
+		wb.Inverted = legacy.Inverted
+		wb.Strokes = []*WhiteboardStroke{}
 
-	i := 0
-	for y := 0; y < int(whiteboard.Height); y++ {
-		encoded := []byte{}
-		for x := 0; x < int(whiteboard.Width); x++ {
-			if colors[i] == whiteboard.Colors[0] {
-				encoded = append(encoded, byte(0))
-			} else if colors[i] == whiteboard.Colors[1] {
-				encoded = append(encoded, byte(1))
+		for _, stroke := range legacy.Strokes {
+			if stroke.Points == nil {
+				stroke.Points = []rl.Vector2{}
 			}
-			i++
+			wb.Strokes = append(wb.Strokes, (*WhiteboardStroke)(stroke))
 		}
-		data = append(data, base64.StdEncoding.EncodeToString(encoded))
+
+	} else if err := json.Unmarshal([]byte(data.Raw), wb); err != nil {
+		return nil, err
 	}
 
-	return data
+	// Once read, the Whiteboard is in the current format, whichever it was saved in.
+	wb.Version = WhiteboardVersion
+
+	if wb.Strokes == nil {
+		wb.Strokes = []*WhiteboardStroke{}
+	}
+
+	return wb, nil
 
 }
 
-func (whiteboard *Whiteboard) Deserialize(data []string) {
+// whiteboardStrokesFromBitmap converts the monochrome bitmap format used before vector whiteboards (an array of rows of
+// base64-encoded 0 / 1 bytes) into strokes. Each horizontal run of set pixels becomes a line, and identical runs on
+// consecutive rows are merged into a single, thicker line. If doubled is true, the bitmap is drawn at twice its size.
+func whiteboardStrokesFromBitmap(rows []string, doubled bool) []*WhiteboardStroke {
 
-	project := whiteboard.Task.Board.Project
+	scale := float32(1)
 
-	colors := []rl.Color{}
+	if doubled {
+		scale = 2
+	}
 
-	whiteboard.SetColors()
+	strokes := []*WhiteboardStroke{}
+	open := map[[2]int]*WhiteboardStroke{}
 
-	for i := len(data) - 1; i >= 0; i-- {
-		ogData, _ := base64.StdEncoding.DecodeString(data[i])
+	for y, row := range rows {
 
-		rowColors := []rl.Color{}
+		pixels, _ := base64.StdEncoding.DecodeString(row)
+		nextOpen := map[[2]int]*WhiteboardStroke{}
 
-		for _, value := range ogData {
+		for x := 0; x < len(pixels); x++ {
 
-			color := whiteboard.Colors[0]
+			if pixels[x] != 1 {
+				continue
+			}
 
-			if value == 1 {
-				color = whiteboard.Colors[1]
+			start := x
+			for x < len(pixels) && pixels[x] == 1 {
+				x++
 			}
 
-			rowColors = append(rowColors, color)
+			run := [2]int{start, x}
 
-			// Append the color again if it's an older plan, as they were "doubly thick"
-			if project.Loading && project.LoadingVersion.LTE(semver.MustParse("0.6.1-3")) {
-				rowColors = append(rowColors, color)
+			if stroke, exists := open[run]; exists {
+				stroke.Width++
+				stroke.Points[0].Y += 0.5
+				stroke.Points[1].Y += 0.5
+				nextOpen[run] = stroke
+			} else {
+				stroke := &WhiteboardStroke{
+					Kind:   WhiteboardStrokeLine,
+					Points: []rl.Vector2{{float32(start), float32(y) + 0.5}, {float32(x), float32(y) + 0.5}},
+					Width:  1,
+				}
+				strokes = append(strokes, stroke)
+				nextOpen[run] = stroke
 			}
 
 		}
 
-		colors = append(colors, rowColors...)
-
-		if project.Loading && project.LoadingVersion.LTE(semver.MustParse("0.6.1-3")) {
-			colors = append(colors, rowColors...)
-		}
+		open = nextOpen
 
 	}
 
-	rl.UpdateTexture(whiteboard.Texture.Texture, colors)
+	for _, stroke := range strokes {
+		stroke.Width *= scale
+		for i := range stroke.Points {
+			stroke.Points[i].X *= scale
+			stroke.Points[i].Y *= scale
+		}
+	}
 
-}
+	return strokes
 
-func (whiteboard *Whiteboard) SetColors() {
-	whiteboard.Colors = []rl.Color{
-		getThemeColor(GUI_INSIDE),
-		getThemeColor(GUI_FONT_COLOR),
-	}
 }
 
 func (whiteboard *Whiteboard) Shift(x, y float32) {
 
-	rl.BeginTextureMode(whiteboard.Texture)
-	rl.DrawRectangle(0, 0, whiteboard.Texture.Texture.Width, whiteboard.Texture.Texture.Height, whiteboard.Colors[0])
-
-	src := rl.Rectangle{-x, y, float32(whiteboard.Texture.Texture.Width), -float32(whiteboard.Texture.Texture.Height)}
-	dst := rl.Rectangle{0, 0, float32(whiteboard.Texture.Texture.Width), float32(whiteboard.Texture.Texture.Height)}
-	rl.DrawTexturePro(whiteboard.Texture.Texture, src, dst, rl.Vector2{}, 0, rl.White)
+	for _, stroke := range whiteboard.Strokes {
+		stroke.Move(x, y)
+	}
 
-	rl.EndTextureMode()
+	whiteboard.Task.UndoChange = true
 
 }